@@ -0,0 +1,574 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+// Package condition implements the expression language of Check.Condition.
+//
+// A condition is evaluated against a single Kubernetes object, e.g. a pod or deployment selected by
+// a hook. Values of the object are referenced with JSONPath expressions in braces, using the
+// kubectl JSONPath syntax, and compared with literals or with each other:
+//
+//	{$.status.readyReplicas} == {$.spec.replicas}
+//	{$.status.phase} == 'Running' && {$.metadata.labels.role} != "standby"
+//	{$.status.containerStatuses[*].ready} == true
+//
+// Grammar:
+//
+//	expression := and { "||" and }
+//	and        := unary { "&&" unary }
+//	unary      := "!" unary | "(" expression ")" | comparison
+//	comparison := operand [ ( "==" | "!=" | "<" | "<=" | ">" | ">=" ) operand ]
+//	operand    := "{" jsonpath "}" | number | 'string' | "string" | true | false | null
+//
+// Semantics:
+//   - A JSONPath that does not match anything yields null. Kubernetes omits fields with zero values,
+//     so e.g. a missing status.readyReplicas is null, not 0.
+//   - A JSONPath yielding several values, e.g. using [*], makes a comparison true only if it is true
+//     for every value. Negating a comparison therefore tests whether it is false for some value, e.g.
+//     !({$.status.containerStatuses[*].ready} == false) holds if any container is ready.
+//   - Numbers compare numerically regardless of their type; strings compare lexically. Ordering
+//     comparisons of values of different types, or of other types, are false.
+//   - An operand without comparison is true if all its values are true, non-zero numbers, or
+//     non-empty strings, lists or maps.
+package condition
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// Expression is a parsed condition
+type Expression struct {
+	text string
+	root node
+}
+
+// Parse parses a condition. It returns an error describing the first syntax error, including
+// invalid JSONPath expressions.
+func Parse(text string) (*Expression, error) {
+	tokens, err := tokenize(text)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tokens) == 1 {
+		return nil, fmt.Errorf("condition is empty")
+	}
+
+	p := &parser{tokens: tokens}
+
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %s at offset %d", t, t.offset)
+	}
+
+	return &Expression{text: text, root: root}, nil
+}
+
+// String returns the text the expression was parsed from
+func (e *Expression) String() string {
+	return e.text
+}
+
+// Evaluate evaluates the expression against an object in its unstructured form, as returned by
+// runtime.DefaultUnstructuredConverter.ToUnstructured
+func (e *Expression) Evaluate(obj map[string]interface{}) (bool, error) {
+	return e.root.eval(obj)
+}
+
+type node interface {
+	eval(obj map[string]interface{}) (bool, error)
+}
+
+type logicalNode struct {
+	and         bool
+	left, right node
+}
+
+func (n *logicalNode) eval(obj map[string]interface{}) (bool, error) {
+	left, err := n.left.eval(obj)
+	if err != nil || left != n.and {
+		return left, err
+	}
+
+	return n.right.eval(obj)
+}
+
+type notNode struct {
+	operand node
+}
+
+func (n *notNode) eval(obj map[string]interface{}) (bool, error) {
+	value, err := n.operand.eval(obj)
+
+	return !value, err
+}
+
+type comparisonNode struct {
+	op          string
+	left, right operand
+}
+
+func (n *comparisonNode) eval(obj map[string]interface{}) (bool, error) {
+	left, err := n.left.values(obj)
+	if err != nil {
+		return false, err
+	}
+
+	right, err := n.right.values(obj)
+	if err != nil {
+		return false, err
+	}
+
+	for _, l := range left {
+		for _, r := range right {
+			if !compare(n.op, l, r) {
+				return false, nil
+			}
+		}
+	}
+
+	return true, nil
+}
+
+type truthNode struct {
+	operand operand
+}
+
+func (n *truthNode) eval(obj map[string]interface{}) (bool, error) {
+	values, err := n.operand.values(obj)
+	if err != nil {
+		return false, err
+	}
+
+	for _, value := range values {
+		if !truthy(value) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+type operand interface {
+	values(obj map[string]interface{}) ([]interface{}, error)
+}
+
+type literal struct {
+	value interface{}
+}
+
+func (l literal) values(map[string]interface{}) ([]interface{}, error) {
+	return []interface{}{l.value}, nil
+}
+
+type path struct {
+	text     string
+	jsonPath *jsonpath.JSONPath
+}
+
+func (p *path) values(obj map[string]interface{}) ([]interface{}, error) {
+	results, err := p.jsonPath.FindResults(obj)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate %s: %w", p.text, err)
+	}
+
+	values := []interface{}{}
+
+	for _, result := range results {
+		for _, value := range result {
+			if value.IsValid() && value.CanInterface() {
+				values = append(values, value.Interface())
+			} else {
+				values = append(values, nil)
+			}
+		}
+	}
+
+	if len(values) == 0 {
+		values = append(values, nil)
+	}
+
+	return values, nil
+}
+
+func compare(op string, left, right interface{}) bool {
+	if l, ok := number(left); ok {
+		if r, ok := number(right); ok {
+			return compareOrdered(op, l, r)
+		}
+	}
+
+	if l, ok := left.(string); ok {
+		if r, ok := right.(string); ok {
+			return compareOrdered(op, l, r)
+		}
+	}
+
+	switch op {
+	case "==":
+		return reflect.DeepEqual(left, right)
+	case "!=":
+		return !reflect.DeepEqual(left, right)
+	default:
+		return false
+	}
+}
+
+func compareOrdered[T float64 | string](op string, left, right T) bool {
+	switch op {
+	case "==":
+		return left == right
+	case "!=":
+		return left != right
+	case "<":
+		return left < right
+	case "<=":
+		return left <= right
+	case ">":
+		return left > right
+	case ">=":
+		return left >= right
+	}
+
+	return false
+}
+
+func number(value interface{}) (float64, bool) {
+	v := reflect.ValueOf(value)
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	default:
+		return 0, false
+	}
+}
+
+func truthy(value interface{}) bool {
+	if value == nil {
+		return false
+	}
+
+	if n, ok := number(value); ok {
+		return n != 0
+	}
+
+	v := reflect.ValueOf(value)
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.String, reflect.Slice, reflect.Map:
+		return v.Len() != 0
+	default:
+		return true
+	}
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenOperator
+	tokenPath
+	tokenLiteral
+)
+
+type token struct {
+	kind   tokenKind
+	text   string
+	offset int
+	value  interface{}
+}
+
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of condition"
+	}
+
+	return strconv.Quote(t.text)
+}
+
+var operators = []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "(", ")"}
+
+func tokenize(text string) ([]token, error) {
+	tokens := []token{}
+
+	for i := 0; i < len(text); {
+		c := text[i]
+
+		switch {
+		case unicode.IsSpace(rune(c)):
+			i++
+
+			continue
+		case c == '{':
+			end, err := scanPath(text, i)
+			if err != nil {
+				return nil, err
+			}
+
+			tokens = append(tokens, token{kind: tokenPath, text: text[i:end], offset: i})
+			i = end
+
+			continue
+		case c == '\'' || c == '"':
+			end, value, err := scanString(text, i)
+			if err != nil {
+				return nil, err
+			}
+
+			tokens = append(tokens, token{kind: tokenLiteral, text: text[i:end], offset: i, value: value})
+			i = end
+
+			continue
+		case c == '-' || c == '.' || (c >= '0' && c <= '9'):
+			end := i + 1
+			for end < len(text) && strings.IndexByte("0123456789.eE+-", text[end]) >= 0 {
+				end++
+			}
+
+			value, err := strconv.ParseFloat(text[i:end], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q at offset %d", text[i:end], i)
+			}
+
+			tokens = append(tokens, token{kind: tokenLiteral, text: text[i:end], offset: i, value: value})
+			i = end
+
+			continue
+		case unicode.IsLetter(rune(c)):
+			end := i + 1
+			for end < len(text) && (unicode.IsLetter(rune(text[end])) || unicode.IsDigit(rune(text[end]))) {
+				end++
+			}
+
+			word := text[i:end]
+			values := map[string]interface{}{"true": true, "false": false, "null": nil}
+
+			value, ok := values[word]
+			if !ok {
+				return nil, fmt.Errorf("unknown identifier %q at offset %d, use quotes for strings", word, i)
+			}
+
+			tokens = append(tokens, token{kind: tokenLiteral, text: word, offset: i, value: value})
+			i = end
+
+			continue
+		}
+
+		found := false
+
+		for _, op := range operators {
+			if strings.HasPrefix(text[i:], op) {
+				tokens = append(tokens, token{kind: tokenOperator, text: op, offset: i})
+				i += len(op)
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			return nil, fmt.Errorf("unexpected character %q at offset %d", c, i)
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, offset: len(text)}), nil
+}
+
+// scanPath returns the end of the JSONPath starting with the brace at start. Braces in quoted
+// strings of filters do not count.
+func scanPath(text string, start int) (int, error) {
+	depth := 0
+
+	for i := start; i < len(text); i++ {
+		switch text[i] {
+		case '\'', '"':
+			end, _, err := scanString(text, i)
+			if err != nil {
+				return 0, err
+			}
+
+			i = end - 1
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i + 1, nil
+			}
+		}
+	}
+
+	return 0, fmt.Errorf("unterminated JSONPath at offset %d", start)
+}
+
+// scanString returns the end and the value of the quoted string starting at start
+func scanString(text string, start int) (int, string, error) {
+	quote := text[start]
+
+	var value strings.Builder
+
+	for i := start + 1; i < len(text); i++ {
+		switch text[i] {
+		case quote:
+			return i + 1, value.String(), nil
+		case '\\':
+			if i+1 < len(text) {
+				i++
+			}
+		}
+
+		value.WriteByte(text[i])
+	}
+
+	return 0, "", fmt.Errorf("unterminated string at offset %d", start)
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+
+	return t
+}
+
+func (p *parser) accept(op string) bool {
+	if t := p.peek(); t.kind == tokenOperator && t.text == op {
+		p.pos++
+
+		return true
+	}
+
+	return false
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		left = &logicalNode{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept("&&") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		left = &logicalNode{and: true, left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.accept("!") {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		return &notNode{operand: operand}, nil
+	}
+
+	if p.accept("(") {
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if t := p.next(); t.kind != tokenOperator || t.text != ")" {
+			return nil, fmt.Errorf("expected \")\" instead of %s at offset %d", t, t.offset)
+		}
+
+		return expr, nil
+	}
+
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	t := p.peek()
+	if t.kind != tokenOperator {
+		return &truthNode{operand: left}, nil
+	}
+
+	switch t.text {
+	case "==", "!=", "<", "<=", ">", ">=":
+		p.pos++
+	default:
+		return &truthNode{operand: left}, nil
+	}
+
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	return &comparisonNode{op: t.text, left: left, right: right}, nil
+}
+
+func (p *parser) parseOperand() (operand, error) {
+	t := p.next()
+
+	switch t.kind {
+	case tokenLiteral:
+		return literal{value: t.value}, nil
+	case tokenPath:
+		jsonPath := jsonpath.New(t.text).AllowMissingKeys(true)
+		if err := jsonPath.Parse(t.text); err != nil {
+			return nil, fmt.Errorf("invalid JSONPath %s at offset %d: %w", t.text, t.offset, err)
+		}
+
+		return &path{text: t.text, jsonPath: jsonPath}, nil
+	default:
+		return nil, fmt.Errorf("expected a JSONPath or literal instead of %s at offset %d", t, t.offset)
+	}
+}
@@ -0,0 +1,70 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package condition_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/ramendr/recipe/api/condition"
+)
+
+var object = map[string]interface{}{
+	"metadata": map[string]interface{}{
+		"name":   "db",
+		"labels": map[string]interface{}{"role": "primary"},
+	},
+	"spec": map[string]interface{}{"replicas": int64(3)},
+	"status": map[string]interface{}{
+		"readyReplicas": int64(3),
+		"phase":         "Running",
+		"conditions": []interface{}{
+			map[string]interface{}{"type": "Available", "status": "True"},
+			map[string]interface{}{"type": "Progressing", "status": "True"},
+		},
+		"containerStatuses": []interface{}{
+			map[string]interface{}{"name": "db", "ready": true},
+			map[string]interface{}{"name": "sidecar", "ready": false},
+		},
+	},
+}
+
+var _ = Describe("Condition", func() {
+	DescribeTable("evaluates",
+		func(text string, expected bool) {
+			expr, err := condition.Parse(text)
+			Expect(err).ToNot(HaveOccurred())
+
+			result, err := expr.Evaluate(object)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(Equal(expected))
+		},
+		Entry("paths compared with each other", "{$.status.readyReplicas} == {$.spec.replicas}", true),
+		Entry("paths without root", "{.status.readyReplicas} >= {.spec.replicas}", true),
+		Entry("numbers", "{$.spec.replicas} > 2.5", true),
+		Entry("strings", `{$.status.phase} == 'Running' && {$.metadata.labels.role} != "standby"`, true),
+		Entry("filters", `{$.status.conditions[?(@.type=="Available")].status} == "True"`, true),
+		Entry("every value of lists", "{$.status.containerStatuses[*].ready} == true", false),
+		Entry("negation and grouping", "!({$.spec.replicas} == 0 || {$.status.phase} == 'Failed')", true),
+		Entry("missing fields as null", "{$.status.unavailableReplicas} == null", true),
+		Entry("ordering of null", "{$.status.unavailableReplicas} < 1", false),
+		Entry("truthiness", "{$.metadata.labels} && !{$.status.unavailableReplicas}", true),
+		Entry("precedence of && over ||", "true || false && false", true),
+	)
+
+	DescribeTable("rejects",
+		func(text, message string) {
+			_, err := condition.Parse(text)
+			Expect(err).To(MatchError(ContainSubstring(message)))
+		},
+		Entry("empty conditions", " ", "empty"),
+		Entry("unterminated paths", "{$.status.phase == 'Running'", "unterminated"),
+		Entry("invalid paths", "{$.status[} == 1", "invalid JSONPath"),
+		Entry("unquoted strings", "{$.status.phase} == Running", "unknown identifier"),
+		Entry("missing operands", "{$.spec.replicas} ==", "expected a JSONPath or literal"),
+		Entry("unbalanced parentheses", "({$.spec.replicas} == 1", `expected ")"`),
+		Entry("trailing tokens", "{$.spec.replicas} 1", "unexpected"),
+		Entry("unknown operators", "{$.spec.replicas} = 1", "unexpected character"),
+	)
+})
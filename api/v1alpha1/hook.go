@@ -0,0 +1,93 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package v1alpha1
+
+import "time"
+
+// Hook types
+const (
+	// HookTypeExec runs the command of an operation in the selected pods
+	HookTypeExec string = "exec"
+	// HookTypeScale scales the selected workloads down or up
+	HookTypeScale string = "scale"
+	// HookTypeCheck evaluates conditions on the selected resources
+	HookTypeCheck string = "check"
+)
+
+// Commands of the operations of scale hooks
+const (
+	// ScaleDown scales the selected workloads to zero replicas and waits for their pods to terminate
+	ScaleDown string = "down"
+	// ScaleUp restores the replicas recorded by ScaleDown and waits for the pods to become ready
+	ScaleUp string = "up"
+)
+
+// ScaleCommands lists the commands accepted by operations of scale hooks
+var ScaleCommands = []string{ScaleDown, ScaleUp}
+
+// ScaleSelectResources lists the values of SelectResource accepted by scale hooks
+var ScaleSelectResources = []string{"deployment", "statefulset", "replicaset"}
+
+// OriginalReplicasAnnotation is set by ScaleDown on a workload to the replicas it had before, and
+// removed by ScaleUp when restoring them
+const OriginalReplicasAnnotation = "ramendr.openshift.io/original-replicas"
+
+// Defaults of retry policies, in seconds
+const (
+	// DefaultRetryDelay is the delay between attempts of operations and checks without backoff
+	DefaultRetryDelay = 5
+	// DefaultMaxRetryDelay is the maximum delay of exponential backoff that does not set one
+	DefaultMaxRetryDelay = 60
+)
+
+// EffectiveRetryPolicy returns the retry policy of an operation or check of the hook: the fields the
+// policy of the operation or check sets, and the ones of the hook otherwise
+func (h *Hook) EffectiveRetryPolicy(policy RetryPolicy) RetryPolicy {
+	if policy.Retries == nil {
+		policy.Retries = h.Retries
+	}
+
+	if policy.Backoff == nil {
+		policy.Backoff = h.Backoff
+	}
+
+	if policy.RetryOn == nil {
+		policy.RetryOn = h.RetryOn
+	}
+
+	return policy
+}
+
+// MaxAttempts returns the number of times an operation or check is run at most: once, and once for
+// each retry
+func (p RetryPolicy) MaxAttempts() int {
+	if p.Retries == nil || *p.Retries < 0 {
+		return 1
+	}
+
+	return 1 + *p.Retries
+}
+
+// RetryDelay returns the delay before a retry, counting retries from 1
+func (p RetryPolicy) RetryDelay(retry int) time.Duration {
+	if p.Backoff == nil {
+		return DefaultRetryDelay * time.Second
+	}
+
+	delay := time.Duration(p.Backoff.Delay) * time.Second
+	if p.Backoff.Type != BackoffExponential {
+		return delay
+	}
+
+	maxDelay := time.Duration(p.Backoff.MaxDelay) * time.Second
+	if maxDelay <= 0 {
+		maxDelay = DefaultMaxRetryDelay * time.Second
+	}
+
+	for i := 1; i < retry && delay < maxDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, maxDelay)
+}
@@ -0,0 +1,55 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package v1alpha1_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/utils/ptr"
+
+	Recipe "github.com/ramendr/recipe/api/v1alpha1"
+)
+
+var _ = Describe("RetryPolicy", func() {
+	It("inherits the fields the operation or check does not set from the hook", func() {
+		hook := &Recipe.Hook{RetryPolicy: Recipe.RetryPolicy{
+			Retries: ptr.To(3),
+			Backoff: &Recipe.Backoff{Delay: 2},
+			RetryOn: &Recipe.RetryOn{ExitCodes: []int{75}},
+		}}
+
+		policy := hook.EffectiveRetryPolicy(Recipe.RetryPolicy{Retries: ptr.To(0)})
+		Expect(policy.MaxAttempts()).To(Equal(1))
+		Expect(policy.Backoff).To(Equal(hook.Backoff))
+		Expect(policy.RetryOn).To(Equal(hook.RetryOn))
+
+		policy = hook.EffectiveRetryPolicy(Recipe.RetryPolicy{})
+		Expect(policy.MaxAttempts()).To(Equal(4))
+		Expect((&Recipe.Hook{}).EffectiveRetryPolicy(Recipe.RetryPolicy{}).MaxAttempts()).To(Equal(1))
+	})
+	It("computes fixed and exponential delays", func() {
+		policy := Recipe.RetryPolicy{}
+		Expect(policy.RetryDelay(3)).To(Equal(Recipe.DefaultRetryDelay * time.Second))
+
+		policy.Backoff = &Recipe.Backoff{Type: Recipe.BackoffFixed, Delay: 2}
+		Expect(policy.RetryDelay(1)).To(Equal(2 * time.Second))
+		Expect(policy.RetryDelay(5)).To(Equal(2 * time.Second))
+
+		policy.Backoff = &Recipe.Backoff{Type: Recipe.BackoffExponential, Delay: 2, MaxDelay: 10}
+		delays := []time.Duration{}
+
+		for retry := 1; retry <= 5; retry++ {
+			delays = append(delays, policy.RetryDelay(retry))
+		}
+
+		Expect(delays).To(Equal([]time.Duration{2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second,
+			10 * time.Second}))
+
+		policy.Backoff.MaxDelay = 0
+		Expect(policy.RetryDelay(100)).To(Equal(Recipe.DefaultMaxRetryDelay * time.Second))
+	})
+})
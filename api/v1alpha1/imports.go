@@ -0,0 +1,306 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package v1alpha1
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// RecipeGetter returns a recipe rendered from its template, with the errors of rendering it. Missing
+// recipes are returned as NotFound API errors.
+// +kubebuilder:object:generate=false
+type RecipeGetter func(namespace, name string) (*Recipe, field.ErrorList, error)
+
+// ImportNamespace returns the namespace of the recipe an import of a recipe in namespace imports
+// from
+func (i *Import) ImportNamespace(namespace string) string {
+	if i.Namespace != "" {
+		return i.Namespace
+	}
+
+	return namespace
+}
+
+// ResolveImports returns a copy of the recipe with the groups and hooks it imports added to its own,
+// together with the recipes it depends on, directly or through other recipes. The copy imports
+// nothing. Imported groups and hooks are taken from the imported recipe with its own imports
+// resolved, and its parameters expanded with their defaults; references to parameters in the copy are
+// thus only the ones of the recipe.
+//
+// The errors report missing recipes, groups and hooks, import cycles, imported names that the recipe
+// defines or imports twice, and imported recipes that cannot be rendered, resolved or expanded. Other
+// errors of getting recipes are returned as error.
+func ResolveImports(recipe *Recipe, get RecipeGetter) (*Recipe, []RecipeDependency, field.ErrorList, error) {
+	r := &importResolver{
+		get:          get,
+		resolved:     map[string]*resolvedImport{},
+		dependencies: map[string]*RecipeDependency{},
+	}
+
+	resolved, errs, err := r.resolve(recipe)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	dependencies := make([]RecipeDependency, 0, len(r.dependencies))
+	for _, dependency := range r.dependencies {
+		dependencies = append(dependencies, *dependency)
+	}
+
+	slices.SortFunc(dependencies, func(a, b RecipeDependency) int {
+		return strings.Compare(a.Namespace+"/"+a.Name, b.Namespace+"/"+b.Name)
+	})
+
+	return resolved, dependencies, errs, nil
+}
+
+// importResolver resolves the imports of a recipe and of the recipes it imports from, recording
+// them as dependencies
+// +kubebuilder:object:generate=false
+type importResolver struct {
+	get RecipeGetter
+	// recipes being resolved, outermost first, to detect cycles
+	stack []string
+	// recipes imported from, by <namespace>/<name>
+	resolved     map[string]*resolvedImport
+	dependencies map[string]*RecipeDependency
+}
+
+// resolvedImport is a recipe imported from, with the errors that prevent importing from it
+// +kubebuilder:object:generate=false
+type resolvedImport struct {
+	recipe *Recipe
+	err    string
+}
+
+func recipeKey(namespace, name string) string {
+	return namespace + "/" + name
+}
+
+func (r *importResolver) resolve(recipe *Recipe) (*Recipe, field.ErrorList, error) {
+	resolved := recipe.DeepCopy()
+	resolved.Spec.Imports = nil
+
+	allErrs := field.ErrorList{}
+
+	if len(recipe.Spec.Imports) == 0 {
+		return resolved, allErrs, nil
+	}
+
+	r.stack = append(r.stack, recipeKey(recipe.Namespace, recipe.Name))
+	defer func() { r.stack = r.stack[:len(r.stack)-1] }()
+
+	importsPath := field.NewPath("spec", "imports")
+
+	// names imported so far, and the recipe they were imported from
+	importedGroups := map[string]string{}
+	importedHooks := map[string]string{}
+
+	for i, imp := range recipe.Spec.Imports {
+		if imp == nil {
+			continue
+		}
+
+		importPath := importsPath.Index(i)
+
+		source, errs, err := r.importFrom(imp.ImportNamespace(recipe.Namespace), imp.Recipe, importPath)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if len(errs) != 0 {
+			allErrs = append(allErrs, errs...)
+
+			continue
+		}
+
+		key := recipeKey(source.Namespace, source.Name)
+
+		for j, name := range imp.Groups {
+			namePath := importPath.Child("groups").Index(j)
+
+			group := source.Spec.FindGroup(name)
+
+			switch {
+			case group == nil:
+				allErrs = append(allErrs, field.NotFound(namePath, name))
+			case recipe.Spec.FindGroup(name) != nil:
+				allErrs = append(allErrs, field.Invalid(namePath, name, "the recipe defines a group of this name"))
+			case importedGroups[name] != "":
+				allErrs = append(allErrs, field.Invalid(namePath, name,
+					fmt.Sprintf("a group of this name is also imported from recipe %s", importedGroups[name])))
+			default:
+				importedGroups[name] = key
+				resolved.Spec.Groups = append(resolved.Spec.Groups, group.DeepCopy())
+			}
+		}
+
+		for j, name := range imp.Hooks {
+			namePath := importPath.Child("hooks").Index(j)
+
+			hook := source.Spec.FindHook(name)
+
+			switch {
+			case hook == nil:
+				allErrs = append(allErrs, field.NotFound(namePath, name))
+			case recipe.Spec.FindHook(name) != nil:
+				allErrs = append(allErrs, field.Invalid(namePath, name, "the recipe defines a hook of this name"))
+			case importedHooks[name] != "":
+				allErrs = append(allErrs, field.Invalid(namePath, name,
+					fmt.Sprintf("a hook of this name is also imported from recipe %s", importedHooks[name])))
+			default:
+				importedHooks[name] = key
+				resolved.Spec.Hooks = append(resolved.Spec.Hooks, hook.DeepCopy())
+			}
+		}
+	}
+
+	return resolved, allErrs, nil
+}
+
+// importFrom returns the recipe to import from resolved and expanded, with its parameter references
+// escaped so that expanding the parameters of the importing recipe leaves the imported groups and
+// hooks unchanged, and records it as a dependency of the recipe being resolved
+func (r *importResolver) importFrom(namespace, name string, importPath *field.Path,
+) (*Recipe, field.ErrorList, error) {
+	key := recipeKey(namespace, name)
+	recipePath := importPath.Child("recipe")
+
+	// the recipe being resolved is a dependency unless it is the one ResolveImports was called for
+	importer := r.dependencies[r.stack[len(r.stack)-1]]
+	if importer != nil && !slices.Contains(importer.Imports, key) {
+		importer.Imports = append(importer.Imports, key)
+	}
+
+	if i := slices.Index(r.stack, key); i != -1 {
+		cycle := append(slices.Clone(r.stack[i:]), key)
+
+		return nil, field.ErrorList{field.Invalid(recipePath, name,
+			fmt.Sprintf("import cycle %s", strings.Join(cycle, " -> ")))}, nil
+	}
+
+	imported, ok := r.resolved[key]
+	if !ok {
+		var err error
+
+		imported, err = r.resolveImported(namespace, name)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		r.resolved[key] = imported
+	}
+
+	switch {
+	case imported.recipe == nil:
+		return nil, field.ErrorList{field.NotFound(recipePath, key)}, nil
+	case imported.err != "":
+		return nil, field.ErrorList{field.Invalid(recipePath, name,
+			fmt.Sprintf("cannot import from recipe %s: %s", key, imported.err))}, nil
+	}
+
+	return imported.recipe, nil, nil
+}
+
+func (r *importResolver) resolveImported(namespace, name string) (*resolvedImport, error) {
+	key := recipeKey(namespace, name)
+
+	dependency := &RecipeDependency{Namespace: namespace, Name: name}
+	r.dependencies[key] = dependency
+
+	recipe, errs, err := r.get(namespace, name)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return &resolvedImport{}, nil
+		}
+
+		return nil, err
+	}
+
+	dependency.Generation = recipe.Generation
+
+	if len(errs) != 0 {
+		return &resolvedImport{recipe: recipe, err: errs.ToAggregate().Error()}, nil
+	}
+
+	resolved, errs, err := r.resolve(recipe)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(errs) != 0 {
+		return &resolvedImport{recipe: recipe, err: errs.ToAggregate().Error()}, nil
+	}
+
+	expanded, errs := ExpandParameters(resolved, nil)
+	if len(errs) != 0 {
+		return &resolvedImport{recipe: recipe, err: errs.ToAggregate().Error()}, nil
+	}
+
+	escapeParameterReferences(&expanded.Spec)
+
+	return &resolvedImport{recipe: expanded}, nil
+}
+
+// importsGroup reports whether an import of the recipe names the group
+func (s *RecipeSpec) importsGroup(name string) bool {
+	return slices.ContainsFunc(s.Imports, func(imp *Import) bool {
+		return imp != nil && slices.Contains(imp.Groups, name)
+	})
+}
+
+// importsHook reports whether an import of the recipe names the hook
+func (s *RecipeSpec) importsHook(name string) bool {
+	return slices.ContainsFunc(s.Imports, func(imp *Import) bool {
+		return imp != nil && slices.Contains(imp.Hooks, name)
+	})
+}
+
+// validateImports checks that imports do not name groups and hooks the recipe defines or imports
+// from another import. What imports name is checked once they are resolved by ResolveImports.
+func validateImports(spec *RecipeSpec, importsPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	groups := map[string]bool{}
+	hooks := map[string]bool{}
+
+	for i, imp := range spec.Imports {
+		if imp == nil {
+			continue
+		}
+
+		for j, name := range imp.Groups {
+			namePath := importsPath.Index(i).Child("groups").Index(j)
+
+			switch {
+			case spec.FindGroup(name) != nil:
+				allErrs = append(allErrs, field.Invalid(namePath, name, "the recipe defines a group of this name"))
+			case groups[name]:
+				allErrs = append(allErrs, field.Duplicate(namePath, name))
+			}
+
+			groups[name] = true
+		}
+
+		for j, name := range imp.Hooks {
+			namePath := importsPath.Index(i).Child("hooks").Index(j)
+
+			switch {
+			case spec.FindHook(name) != nil:
+				allErrs = append(allErrs, field.Invalid(namePath, name, "the recipe defines a hook of this name"))
+			case hooks[name]:
+				allErrs = append(allErrs, field.Duplicate(namePath, name))
+			}
+
+			hooks[name] = true
+		}
+	}
+
+	return allErrs
+}
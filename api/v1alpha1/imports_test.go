@@ -0,0 +1,185 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package v1alpha1_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
+
+	Recipe "github.com/ramendr/recipe/api/v1alpha1"
+)
+
+// recipeGetter gets recipes from a list, rendered as is
+func recipeGetter(recipes ...*Recipe.Recipe) Recipe.RecipeGetter {
+	return func(namespace, name string) (*Recipe.Recipe, field.ErrorList, error) {
+		for _, recipe := range recipes {
+			if recipe.Namespace == namespace && recipe.Name == name {
+				return recipe, nil, nil
+			}
+		}
+
+		return nil, nil, apierrors.NewNotFound(Recipe.GroupVersion.WithResource("recipes").GroupResource(), name)
+	}
+}
+
+func kafkaHooks() *Recipe.Recipe {
+	return &Recipe.Recipe{
+		ObjectMeta: metav1.ObjectMeta{Name: "kafka-hooks", Namespace: "shared", Generation: 3},
+		Spec: Recipe.RecipeSpec{
+			Parameters: []*Recipe.Parameter{{Name: "namespace", Default: ptr.To("kafka")}},
+			Hooks: []*Recipe.Hook{{
+				Name:      "kafka",
+				Type:      "exec",
+				Namespace: "${namespace}",
+				Ops: []*Recipe.Operation{
+					{Name: "flush", Command: "/bin/flush --topics $${TOPICS}"},
+				},
+			}},
+			Groups: []*Recipe.Group{{Name: "topics", Type: "resource", IncludedNamespaces: []string{"${namespace}"}}},
+		},
+	}
+}
+
+func importingRecipe(imports ...*Recipe.Import) *Recipe.Recipe {
+	return &Recipe.Recipe{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "app-ns"},
+		Spec: Recipe.RecipeSpec{
+			Hooks: []*Recipe.Hook{{
+				Name: "db", Type: "exec", Namespace: "app-ns",
+				Ops: []*Recipe.Operation{{Name: "dump", Command: "/bin/dump"}},
+			}},
+			Workflows: []*Recipe.Workflow{{
+				Name:     "backup",
+				Sequence: []map[string]string{{"hook": "kafka/flush"}, {"hook": "db/dump"}},
+			}},
+			Imports: imports,
+		},
+	}
+}
+
+var _ = Describe("ResolveImports", func() {
+	It("adds imported groups and hooks with the defaults of their recipe expanded", func() {
+		recipe := importingRecipe(&Recipe.Import{Recipe: "kafka-hooks", Namespace: "shared", Hooks: []string{"kafka"}})
+
+		Expect(Recipe.ValidateRecipe(recipe)).To(BeEmpty())
+
+		resolved, dependencies, errs, err := Recipe.ResolveImports(recipe, recipeGetter(kafkaHooks()))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(errs).To(BeEmpty())
+		Expect(dependencies).To(Equal([]Recipe.RecipeDependency{{Namespace: "shared", Name: "kafka-hooks", Generation: 3}}))
+
+		Expect(resolved.Spec.Imports).To(BeNil())
+		Expect(resolved.Spec.Hooks).To(HaveLen(2))
+		Expect(resolved.Spec.Hooks[1].Namespace).To(Equal("kafka"))
+		Expect(Recipe.ValidateRecipe(resolved)).To(BeEmpty())
+
+		// escaped references of the imported recipe expand to what they expand to in it
+		expanded, errs := Recipe.ExpandParameters(resolved, nil)
+		Expect(errs).To(BeEmpty())
+		Expect(expanded.Spec.Hooks[1].Ops[0].Command).To(Equal("/bin/flush --topics ${TOPICS}"))
+	})
+	It("resolves imports transitively and records the dependency graph", func() {
+		middle := &Recipe.Recipe{
+			ObjectMeta: metav1.ObjectMeta{Name: "streaming", Namespace: "app-ns", Generation: 1},
+			Spec: Recipe.RecipeSpec{
+				Imports: []*Recipe.Import{{Recipe: "kafka-hooks", Namespace: "shared", Hooks: []string{"kafka"}}},
+			},
+		}
+		recipe := importingRecipe(&Recipe.Import{Recipe: "streaming", Hooks: []string{"kafka"}})
+
+		resolved, dependencies, errs, err := Recipe.ResolveImports(recipe, recipeGetter(kafkaHooks(), middle))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(errs).To(BeEmpty())
+		Expect(resolved.Spec.FindHook("kafka").Namespace).To(Equal("kafka"))
+		Expect(dependencies).To(Equal([]Recipe.RecipeDependency{
+			{Namespace: "app-ns", Name: "streaming", Generation: 1, Imports: []string{"shared/kafka-hooks"}},
+			{Namespace: "shared", Name: "kafka-hooks", Generation: 3},
+		}))
+	})
+	It("reports missing recipes, groups and hooks as unresolved", func() {
+		recipe := importingRecipe(
+			&Recipe.Import{Recipe: "kafka-hooks", Namespace: "shared", Hooks: []string{"kafka", "zookeeper"}},
+			&Recipe.Import{Recipe: "missing", Groups: []string{"data"}},
+		)
+
+		_, dependencies, errs, err := Recipe.ResolveImports(recipe, recipeGetter(kafkaHooks()))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(errs).To(HaveLen(2))
+		Expect(errs[0]).To(Equal(field.NotFound(field.NewPath("spec", "imports").Index(0).Child("hooks").Index(1),
+			"zookeeper")))
+		Expect(errs[1]).To(Equal(field.NotFound(field.NewPath("spec", "imports").Index(1).Child("recipe"),
+			"app-ns/missing")))
+		Expect(dependencies).To(ContainElement(Recipe.RecipeDependency{Namespace: "app-ns", Name: "missing"}))
+	})
+	It("reports names the recipe defines or imports twice", func() {
+		other := kafkaHooks()
+		other.Namespace = "app-ns"
+		other.Spec.Hooks = append(other.Spec.Hooks, &Recipe.Hook{Name: "db", Type: "exec"})
+
+		recipe := importingRecipe(
+			&Recipe.Import{Recipe: "kafka-hooks", Namespace: "shared", Hooks: []string{"kafka"}},
+			&Recipe.Import{Recipe: "kafka-hooks", Hooks: []string{"kafka", "db"}},
+		)
+
+		_, _, errs, err := Recipe.ResolveImports(recipe, recipeGetter(kafkaHooks(), other))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(errs).To(HaveLen(2))
+		Expect(errs[0].Field).To(Equal("spec.imports[1].hooks[0]"))
+		Expect(errs[0].Detail).To(ContainSubstring("also imported from recipe shared/kafka-hooks"))
+		Expect(errs[1].Field).To(Equal("spec.imports[1].hooks[1]"))
+		Expect(errs[1].Detail).To(ContainSubstring("the recipe defines a hook"))
+
+		errs = Recipe.ValidateRecipe(recipe)
+		Expect(errs).To(HaveLen(2))
+		Expect(errs[0].Type).To(Equal(field.ErrorTypeDuplicate))
+		Expect(errs[1].Field).To(Equal("spec.imports[1].hooks[1]"))
+	})
+	It("validates importing recipes except references to imported groups and hooks", func() {
+		recipe := importingRecipe(&Recipe.Import{
+			Recipe: "kafka-hooks", Namespace: "shared", Groups: []string{"topics"}, Hooks: []string{"kafka"},
+		})
+		recipe.Spec.Workflows[0].Sequence = append(recipe.Spec.Workflows[0].Sequence,
+			map[string]string{"group": "topics"}, map[string]string{"hook": "other/flush"},
+			map[string]string{"hook": "db/load"})
+		recipe.Spec.Hooks[0].Ops[0].InverseOp = "dump"
+
+		errs := Recipe.ValidateRecipe(recipe)
+		Expect(errs).To(HaveLen(3))
+		Expect(errs[0].Field).To(Equal("spec.hooks[0].ops[0].inverseOp"))
+		Expect(errs[1]).To(Equal(field.NotFound(
+			field.NewPath("spec", "workflows").Index(0).Child("sequence").Index(3).Key("hook"), "other")))
+		Expect(errs[2]).To(Equal(field.NotFound(
+			field.NewPath("spec", "workflows").Index(0).Child("sequence").Index(4).Key("hook"), "db/load")))
+	})
+	It("reports import cycles", func() {
+		kafka := kafkaHooks()
+		kafka.Spec.Imports = []*Recipe.Import{{Recipe: "app", Namespace: "app-ns", Hooks: []string{"db"}}}
+		recipe := importingRecipe(&Recipe.Import{Recipe: "kafka-hooks", Namespace: "shared", Hooks: []string{"kafka"}})
+
+		_, dependencies, errs, err := Recipe.ResolveImports(recipe, recipeGetter(kafka, recipe))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(errs).To(HaveLen(1))
+		Expect(errs[0].Field).To(Equal("spec.imports[0].recipe"))
+		Expect(errs[0].Detail).To(ContainSubstring("import cycle app-ns/app -> shared/kafka-hooks -> app-ns/app"))
+		Expect(dependencies).To(Equal([]Recipe.RecipeDependency{
+			{Namespace: "shared", Name: "kafka-hooks", Generation: 3, Imports: []string{"app-ns/app"}},
+		}))
+	})
+	It("reports imported recipes whose parameters have no default", func() {
+		kafka := kafkaHooks()
+		kafka.Spec.Parameters[0].Default = nil
+		recipe := importingRecipe(&Recipe.Import{Recipe: "kafka-hooks", Namespace: "shared", Hooks: []string{"kafka"}})
+
+		_, _, errs, err := Recipe.ResolveImports(recipe, recipeGetter(kafka))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(errs).To(HaveLen(1))
+		Expect(errs[0].Type).To(Equal(field.ErrorTypeInvalid))
+		Expect(errs[0].Detail).To(ContainSubstring("cannot import from recipe shared/kafka-hooks"))
+	})
+})
@@ -0,0 +1,176 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package v1alpha1
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"unicode/utf8"
+)
+
+// Prefixes of name selectors. A name selector without prefix is a regular expression.
+const (
+	// NameSelectorRegexPrefix marks a Go RE2 regular expression, e.g. "regex:^data-[0-9]+$". Like
+	// selectors without prefix, it matches names containing a match, unless anchored with ^ and $.
+	NameSelectorRegexPrefix = "regex:"
+	// NameSelectorGlobPrefix marks a glob pattern matching whole names, e.g. "glob:data-*", where *
+	// matches any sequence of characters, ? any single character, and [...] a character class.
+	NameSelectorGlobPrefix = "glob:"
+)
+
+// NameMatcher matches object names against a compiled name selector
+// +kubebuilder:object:generate=false
+type NameMatcher struct {
+	glob  string
+	regex *regexp.Regexp
+}
+
+// CompileNameSelector compiles the name selector of a group or hook. An empty selector compiles to
+// a matcher matching all names.
+func CompileNameSelector(selector string) (*NameMatcher, error) {
+	if glob, ok := strings.CutPrefix(selector, NameSelectorGlobPrefix); ok {
+		// Match only fails for malformed patterns, independent of the name
+		if _, err := path.Match(glob, ""); err != nil {
+			return nil, fmt.Errorf("invalid glob %q: %w", glob, err)
+		}
+
+		return &NameMatcher{glob: glob}, nil
+	}
+
+	regex, err := regexp.Compile(strings.TrimPrefix(selector, NameSelectorRegexPrefix))
+	if err != nil {
+		return nil, err
+	}
+
+	return &NameMatcher{regex: regex}, nil
+}
+
+// Match reports whether a name matches the selector
+func (m *NameMatcher) Match(name string) bool {
+	if m.regex != nil {
+		return m.regex.MatchString(name)
+	}
+
+	matched, _ := path.Match(m.glob, name)
+
+	return matched
+}
+
+// MatchName reports whether a name matches a name selector. An empty selector matches all names,
+// an invalid one none.
+func MatchName(selector, name string) bool {
+	if selector == "" {
+		return true
+	}
+
+	matcher, err := CompileNameSelector(selector)
+
+	return err == nil && matcher.Match(name)
+}
+
+// NameSelectorWarnings returns why a name selector can never match a DNS-1123 subdomain, which
+// object names are, if it is anchored in a way that rules them out: names must start or end with
+// text that is not allowed there, or anchors are surrounded by text.
+func NameSelectorWarnings(selector string) []string {
+	if glob, ok := strings.CutPrefix(selector, NameSelectorGlobPrefix); ok {
+		// globs are anchored at both ends
+		literal := strings.IndexAny(glob, `*?[\`)
+		if literal == -1 {
+			literal = len(glob)
+		}
+
+		suffix := strings.LastIndexAny(glob, `*?]`)
+
+		return anchoredLiteralWarnings(glob[:literal], glob[suffix+1:])
+	}
+
+	re, err := syntax.Parse(strings.TrimPrefix(selector, NameSelectorRegexPrefix), syntax.Perl)
+	if err != nil {
+		return nil
+	}
+
+	re = re.Simplify()
+
+	subs := []*syntax.Regexp{re}
+	if re.Op == syntax.OpConcat {
+		subs = re.Sub
+	}
+
+	warnings := []string{}
+
+	var prefix, suffix string
+
+	for i, sub := range subs {
+		switch sub.Op {
+		case syntax.OpBeginText, syntax.OpBeginLine:
+			switch {
+			case i > 0 && subs[i-1].Op == syntax.OpLiteral:
+				warnings = append(warnings, "^ follows text, so it can never match within a name")
+			case i == 0 && len(subs) > 1 && subs[1].Op == syntax.OpLiteral:
+				prefix = literalString(subs[1])
+			}
+		case syntax.OpEndText, syntax.OpEndLine:
+			switch {
+			case i < len(subs)-1 && subs[i+1].Op == syntax.OpLiteral:
+				warnings = append(warnings, "$ is followed by text, so it can never match within a name")
+			case i == len(subs)-1 && i > 0 && subs[i-1].Op == syntax.OpLiteral:
+				suffix = literalString(subs[i-1])
+			}
+		}
+	}
+
+	return append(warnings, anchoredLiteralWarnings(prefix, suffix)...)
+}
+
+func literalString(re *syntax.Regexp) string {
+	if re.Flags&syntax.FoldCase != 0 {
+		return strings.ToLower(string(re.Rune))
+	}
+
+	return string(re.Rune)
+}
+
+// anchoredLiteralWarnings checks the literal text that names must start and end with
+func anchoredLiteralWarnings(prefix, suffix string) []string {
+	warnings := []string{}
+
+	literals := []string{prefix}
+	if suffix != prefix {
+		literals = append(literals, suffix)
+	}
+
+	for _, literal := range literals {
+		if i := strings.IndexFunc(literal, func(r rune) bool { return !isDNS1123Char(r) }); i != -1 {
+			r, _ := utf8.DecodeRuneInString(literal[i:])
+			warnings = append(warnings, fmt.Sprintf(
+				"names must contain %q, which is not allowed in DNS-1123 names: lower case alphanumeric "+
+					"characters, '-' and '.'", r))
+
+			return warnings
+		}
+	}
+
+	if prefix != "" && !isAlphanumeric(rune(prefix[0])) {
+		warnings = append(warnings, fmt.Sprintf("names must start with %q, but DNS-1123 names start with an "+
+			"alphanumeric character", prefix[0]))
+	}
+
+	if suffix != "" && !isAlphanumeric(rune(suffix[len(suffix)-1])) {
+		warnings = append(warnings, fmt.Sprintf("names must end with %q, but DNS-1123 names end with an "+
+			"alphanumeric character", suffix[len(suffix)-1]))
+	}
+
+	return warnings
+}
+
+func isAlphanumeric(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= '0' && r <= '9'
+}
+
+func isDNS1123Char(r rune) bool {
+	return isAlphanumeric(r) || r == '-' || r == '.'
+}
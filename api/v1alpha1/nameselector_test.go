@@ -0,0 +1,78 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package v1alpha1_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	Recipe "github.com/ramendr/recipe/api/v1alpha1"
+)
+
+var _ = Describe("NameSelector", func() {
+	DescribeTable("matches names",
+		func(selector, name string, expected bool) {
+			Expect(Recipe.MatchName(selector, name)).To(Equal(expected))
+		},
+		Entry("empty selector", "", "data-1", true),
+		Entry("regular expression", "data-[0-9]", "app-data-1", true),
+		Entry("anchored regular expression", "^data-[0-9]$", "app-data-1", false),
+		Entry("prefixed regular expression", "regex:^data-[0-9]$", "data-1", true),
+		Entry("glob", "glob:data-*", "data-1", true),
+		Entry("glob matching whole names", "glob:data-?", "app-data-1", false),
+		Entry("glob character class", "glob:data-[0-9]", "data-a", false),
+		Entry("invalid selector", "data-(", "data-(", false),
+	)
+	It("rejects invalid patterns", func() {
+		for _, selector := range []string{"data-(", "regex:[a-", "glob:data-[0-9"} {
+			_, err := Recipe.CompileNameSelector(selector)
+			Expect(err).To(HaveOccurred(), "selector %s", selector)
+		}
+	})
+	DescribeTable("warns about anchors that rule out DNS-1123 names",
+		func(selector string, expected ...string) {
+			warnings := Recipe.NameSelectorWarnings(selector)
+			Expect(warnings).To(HaveLen(len(expected)))
+			for i, warning := range expected {
+				Expect(warnings[i]).To(ContainSubstring(warning))
+			}
+		},
+		Entry("valid regular expression", "^data-[0-9]+$"),
+		Entry("unanchored upper case", "Data"),
+		Entry("case insensitive", "(?i)^Data"),
+		Entry("upper case prefix", "^Data-", `names must contain 'D'`),
+		Entry("dash prefix", "^-data", `names must start with '-'`),
+		Entry("dot suffix", "regex:data.*\\.$", `names must end with '.'`),
+		Entry("text before ^", "data^x", "^ follows text"),
+		Entry("text after $", "data$x", "$ is followed by text"),
+		Entry("valid glob", "glob:data-*"),
+		Entry("glob with underscore", "glob:data_*", `names must contain '_'`),
+		Entry("glob with dash suffix", "glob:*-", `names must end with '-'`),
+	)
+})
+
+var _ = Describe("RecipeWarnings", func() {
+	It("validates name selectors and warns about ones that never match", func() {
+		recipe := &Recipe.Recipe{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-recipe", Namespace: "test-ns"},
+			Spec: Recipe.RecipeSpec{
+				Groups:  []*Recipe.Group{{Name: "data", Type: "volume", NameSelector: "data-("}},
+				Volumes: &Recipe.Group{Name: "volumes", Type: "volume", NameSelector: "glob:PVC-*"},
+				Hooks:   []*Recipe.Hook{{Name: "db", Type: "exec", NameSelector: "^db-[0-9]+$"}},
+			},
+		}
+
+		errs := Recipe.ValidateRecipe(recipe)
+		Expect(errs).To(HaveLen(1))
+		Expect(errs[0].Field).To(Equal("spec.groups[0].nameSelector"))
+
+		warnings := Recipe.RecipeWarnings(recipe)
+		Expect(warnings).To(HaveLen(1))
+		Expect(warnings[0].Type).To(Equal(field.ErrorTypeInvalid))
+		Expect(warnings[0].Field).To(Equal("spec.volumes.nameSelector"))
+	})
+})
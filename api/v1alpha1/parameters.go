@@ -0,0 +1,345 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package v1alpha1
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+var parameterNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// FindParameter returns the parameter with the given name, or nil if there is none
+func (s *RecipeSpec) FindParameter(name string) *Parameter {
+	for _, parameter := range s.Parameters {
+		if parameter != nil && parameter.Name == name {
+			return parameter
+		}
+	}
+
+	return nil
+}
+
+// ValidateValue checks that a value is of the type of the parameter
+func (p *Parameter) ValidateValue(value string) error {
+	switch p.Type {
+	case "", ParameterTypeString:
+	case ParameterTypeInteger:
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return fmt.Errorf("parameter %q is of type integer", p.Name)
+		}
+	case ParameterTypeBoolean:
+		if value != "true" && value != "false" {
+			return fmt.Errorf("parameter %q is of type boolean, must be true or false", p.Name)
+		}
+	default:
+		return fmt.Errorf("parameter %q has unsupported type %q", p.Name, p.Type)
+	}
+
+	return nil
+}
+
+// ExpandParameters returns a copy of the recipe with the references to its parameters replaced by
+// their values: the given value, or the default of the parameter. References have the form ${name},
+// and $${ yields a literal ${. They are replaced in Hook.Namespace, Hook.NameSelector,
+// Hook.LabelSelector, Operation.Command and Operation.Container, and in Group.IncludedNamespaces,
+// Group.NameSelector, Group.LabelSelector and Group.IncludedNamespacesByLabel of the groups and
+// volumes, where label selectors have their values expanded, and in the when conditions of the
+// steps of workflows.
+//
+// Commands are run by shells, so references in commands that do not name declared parameters, e.g.
+// ${MYSQL_USER}, are left to the shell. The errors report values of undeclared parameters, values not
+// matching the type of their parameter, parameters without value, and references to undeclared
+// parameters in other fields. References to parameters without value are left in place, so that
+// the copy can still be inspected.
+func ExpandParameters(recipe *Recipe, values map[string]string) (*Recipe, field.ErrorList) {
+	parametersPath := field.NewPath("spec", "parameters")
+	allErrs := field.ErrorList{}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	for _, name := range names {
+		if recipe.Spec.FindParameter(name) == nil {
+			allErrs = append(allErrs, field.NotFound(parametersPath, name))
+		}
+	}
+
+	resolved := map[string]string{}
+
+	for i, parameter := range recipe.Spec.Parameters {
+		if parameter == nil {
+			continue
+		}
+
+		value, ok := values[parameter.Name]
+		if !ok && parameter.Default != nil {
+			value, ok = *parameter.Default, true
+		}
+
+		if !ok {
+			allErrs = append(allErrs, field.Required(parametersPath.Index(i),
+				fmt.Sprintf("parameter %q has no default, a value must be given", parameter.Name)))
+
+			continue
+		}
+
+		if err := parameter.ValidateValue(value); err != nil {
+			allErrs = append(allErrs, field.Invalid(parametersPath.Index(i), value, err.Error()))
+
+			continue
+		}
+
+		resolved[parameter.Name] = value
+	}
+
+	expanded := recipe.DeepCopy()
+
+	allErrs = append(allErrs, expandSpec(&expanded.Spec, field.NewPath("spec"), func(name string) (string, bool) {
+		if value, ok := resolved[name]; ok {
+			return value, true
+		}
+
+		// parameters without valid value are reported above
+		return parameterReference(name), recipe.Spec.FindParameter(name) != nil
+	})...)
+
+	return expanded, allErrs
+}
+
+// validateParameters checks the defaults of the parameters and that references outside of commands
+// name declared parameters, or parameters the template of the recipe may declare. The values of
+// parameters are only known when the recipe is used.
+func validateParameters(spec *RecipeSpec, specPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	for i, parameter := range spec.Parameters {
+		if parameter != nil && parameter.Default != nil {
+			if err := parameter.ValidateValue(*parameter.Default); err != nil {
+				allErrs = append(allErrs, field.Invalid(specPath.Child("parameters").Index(i).Child("default"),
+					*parameter.Default, err.Error()))
+			}
+		}
+	}
+
+	return append(allErrs, expandSpec(spec.DeepCopy(), specPath, func(name string) (string, bool) {
+		return parameterReference(name), spec.FindParameter(name) != nil || spec.Template != nil
+	})...)
+}
+
+// HasParameterReference reports whether a value references parameters, and so can only be
+// validated once the recipe is expanded
+func HasParameterReference(value string) bool {
+	return strings.Contains(value, "${")
+}
+
+func parameterReference(name string) string {
+	return "${" + name + "}"
+}
+
+// expandString replaces the parameter references of a value by what lookup returns for them, for
+// the names lookup reports as parameters. In shell commands, references that are not valid or do
+// not name parameters are shell variables and left as they are; in other values they are errors.
+func expandString(value string, shell bool, lookup func(name string) (string, bool)) (string, error) {
+	var expanded strings.Builder
+
+	for {
+		start := strings.Index(value, "${")
+		if start == -1 {
+			expanded.WriteString(value)
+
+			return expanded.String(), nil
+		}
+
+		if start > 0 && value[start-1] == '$' {
+			expanded.WriteString(value[:start-1] + "${")
+			value = value[start+2:]
+
+			continue
+		}
+
+		end := strings.IndexByte(value[start:], '}')
+		if end == -1 {
+			if shell {
+				expanded.WriteString(value)
+
+				return expanded.String(), nil
+			}
+
+			return "", fmt.Errorf("unterminated parameter reference %q", value[start:])
+		}
+
+		name := value[start+2 : start+end]
+		valid := parameterNameRegexp.MatchString(name)
+
+		replacement, ok := "", false
+		if valid {
+			replacement, ok = lookup(name)
+		}
+
+		switch {
+		case ok:
+			expanded.WriteString(value[:start] + replacement)
+		case shell:
+			expanded.WriteString(value[:start+end+1])
+		case !valid:
+			return "", fmt.Errorf("invalid parameter name %q", name)
+		default:
+			return "", fmt.Errorf("parameter %q is not declared", name)
+		}
+
+		value = value[start+end+1:]
+	}
+}
+
+// escapeParameterReferences escapes the parameter references of the fields of a spec in which
+// parameters are expanded, so that expanding them yields the fields unchanged
+func escapeParameterReferences(spec *RecipeSpec) {
+	transformSpec(spec, field.NewPath("spec"), func(value string, _ bool) (string, error) {
+		return strings.ReplaceAll(value, "${", "$${"), nil
+	})
+}
+
+func expandSpec(spec *RecipeSpec, specPath *field.Path, lookup func(name string) (string, bool),
+) field.ErrorList {
+	return transformSpec(spec, specPath, func(value string, shell bool) (string, error) {
+		return expandString(value, shell, lookup)
+	})
+}
+
+// expander transforms the fields of a spec in which parameters are expanded in place, collecting
+// errors. Transform is told whether the value is a shell command.
+// +kubebuilder:object:generate=false
+type expander struct {
+	transform func(value string, shell bool) (string, error)
+	errs      field.ErrorList
+}
+
+func transformSpec(spec *RecipeSpec, specPath *field.Path, transform func(value string, shell bool) (string, error),
+) field.ErrorList {
+	e := &expander{transform: transform}
+
+	for i, group := range spec.Groups {
+		if group != nil {
+			e.group(group, specPath.Child("groups").Index(i))
+		}
+	}
+
+	if spec.Volumes != nil {
+		e.group(spec.Volumes, specPath.Child("volumes"))
+	}
+
+	for i, hook := range spec.Hooks {
+		if hook == nil {
+			continue
+		}
+
+		hookPath := specPath.Child("hooks").Index(i)
+		e.string(&hook.Namespace, hookPath.Child("namespace"))
+		e.string(&hook.NameSelector, hookPath.Child("nameSelector"))
+		e.labelSelector(hook.LabelSelector, hookPath.Child("labelSelector"))
+
+		for j, op := range hook.Ops {
+			if op != nil {
+				e.command(&op.Command, hookPath.Child("ops").Index(j).Child("command"))
+				e.string(&op.Container, hookPath.Child("ops").Index(j).Child("container"))
+			}
+		}
+	}
+
+	for i, workflow := range spec.Workflows {
+		if workflow == nil {
+			continue
+		}
+
+		workflowPath := specPath.Child("workflows").Index(i)
+
+		for j, entry := range workflow.Sequence {
+			e.when(entry, workflowPath.Child("sequence").Index(j))
+		}
+
+		for j, parallel := range workflow.Parallel {
+			if parallel != nil {
+				for k, entry := range parallel.Steps {
+					e.when(entry, workflowPath.Child("parallel").Index(j).Child("steps").Index(k))
+				}
+			}
+		}
+	}
+
+	return e.errs
+}
+
+// when expands the when condition of a sequence entry, if it has one
+func (e *expander) when(entry map[string]string, stepPath *field.Path) {
+	if value, ok := entry[StepKeyWhen]; ok {
+		e.string(&value, stepPath.Key(StepKeyWhen))
+		entry[StepKeyWhen] = value
+	}
+}
+
+func (e *expander) group(group *Group, groupPath *field.Path) {
+	for i := range group.IncludedNamespaces {
+		e.string(&group.IncludedNamespaces[i], groupPath.Child("includedNamespaces").Index(i))
+	}
+
+	e.string(&group.NameSelector, groupPath.Child("nameSelector"))
+	e.labelSelector(group.LabelSelector, groupPath.Child("labelSelector"))
+	e.labelSelector(group.IncludedNamespacesByLabel, groupPath.Child("includedNamespacesByLabel"))
+}
+
+func (e *expander) labelSelector(selector *metav1.LabelSelector, selectorPath *field.Path) {
+	if selector == nil {
+		return
+	}
+
+	keys := make([]string, 0, len(selector.MatchLabels))
+	for key := range selector.MatchLabels {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		value := selector.MatchLabels[key]
+		e.string(&value, selectorPath.Child("matchLabels").Key(key))
+		selector.MatchLabels[key] = value
+	}
+
+	for i := range selector.MatchExpressions {
+		valuesPath := selectorPath.Child("matchExpressions").Index(i).Child("values")
+		for j := range selector.MatchExpressions[i].Values {
+			e.string(&selector.MatchExpressions[i].Values[j], valuesPath.Index(j))
+		}
+	}
+}
+
+func (e *expander) string(value *string, path *field.Path) {
+	e.transformValue(value, false, path)
+}
+
+func (e *expander) command(value *string, path *field.Path) {
+	e.transformValue(value, true, path)
+}
+
+func (e *expander) transformValue(value *string, shell bool, path *field.Path) {
+	expanded, err := e.transform(*value, shell)
+	if err != nil {
+		e.errs = append(e.errs, field.Invalid(path, *value, err.Error()))
+
+		return
+	}
+
+	*value = expanded
+}
@@ -0,0 +1,145 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package v1alpha1_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
+
+	Recipe "github.com/ramendr/recipe/api/v1alpha1"
+)
+
+func parameterRecipe() *Recipe.Recipe {
+	return &Recipe.Recipe{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-recipe", Namespace: "test-ns"},
+		Spec: Recipe.RecipeSpec{
+			Parameters: []*Recipe.Parameter{
+				{Name: "namespace"},
+				{Name: "app", Default: ptr.To("db")},
+				{Name: "container", Default: ptr.To("postgres")},
+				{Name: "replicas", Type: Recipe.ParameterTypeInteger, Default: ptr.To("1")},
+			},
+			Groups: []*Recipe.Group{{
+				Name:               "config",
+				Type:               "resource",
+				IncludedNamespaces: []string{"${namespace}", "${namespace}-config"},
+				LabelSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"app": "${app}"},
+					MatchExpressions: []metav1.LabelSelectorRequirement{
+						{Key: "tier", Operator: metav1.LabelSelectorOpIn, Values: []string{"${app}-backend"}},
+					},
+				},
+			}},
+			Hooks: []*Recipe.Hook{{
+				Name:         "db",
+				Type:         "exec",
+				Namespace:    "${namespace}",
+				NameSelector: "glob:${app}-*",
+				Ops: []*Recipe.Operation{{
+					Name:      "dump",
+					Container: "${container}",
+					Command:   "/bin/dump --replicas ${replicas} --format $${FORMAT}",
+				}},
+			}},
+			Workflows: []*Recipe.Workflow{{
+				Name: Recipe.BackupWorkflowName,
+				Sequence: []map[string]string{
+					{"hook": "db/dump", "when": "statefulset ${namespace}/${app}: {$.spec.replicas} > ${replicas}"},
+				},
+			}},
+		},
+	}
+}
+
+var _ = Describe("ExpandParameters", func() {
+	It("replaces references with values and defaults", func() {
+		recipe := parameterRecipe()
+
+		expanded, errs := Recipe.ExpandParameters(recipe, map[string]string{"namespace": "tenant-1", "app": "pg"})
+		Expect(errs).To(BeEmpty())
+
+		group := expanded.Spec.Groups[0]
+		Expect(group.IncludedNamespaces).To(Equal([]string{"tenant-1", "tenant-1-config"}))
+		Expect(group.LabelSelector.MatchLabels).To(Equal(map[string]string{"app": "pg"}))
+		Expect(group.LabelSelector.MatchExpressions[0].Values).To(Equal([]string{"pg-backend"}))
+
+		hook := expanded.Spec.Hooks[0]
+		Expect(hook.Namespace).To(Equal("tenant-1"))
+		Expect(hook.NameSelector).To(Equal("glob:pg-*"))
+		Expect(hook.Ops[0].Container).To(Equal("postgres"))
+		Expect(hook.Ops[0].Command).To(Equal("/bin/dump --replicas 1 --format ${FORMAT}"))
+		Expect(expanded.Spec.Workflows[0].Sequence[0]).To(Equal(map[string]string{
+			"hook": "db/dump", "when": "statefulset tenant-1/pg: {$.spec.replicas} > 1",
+		}))
+
+		Expect(recipe).To(Equal(parameterRecipe()))
+	})
+	It("reports missing, undeclared and mistyped values", func() {
+		recipe := parameterRecipe()
+
+		expanded, errs := Recipe.ExpandParameters(recipe, map[string]string{"replicas": "many", "unknown": "x"})
+
+		parametersPath := field.NewPath("spec", "parameters")
+		Expect(errs).To(HaveLen(3))
+		Expect(errs[0]).To(Equal(field.NotFound(parametersPath, "unknown")))
+		Expect(errs[1].Type).To(Equal(field.ErrorTypeRequired))
+		Expect(errs[1].Field).To(Equal(parametersPath.Index(0).String()))
+		Expect(errs[2].Type).To(Equal(field.ErrorTypeInvalid))
+		Expect(errs[2].Field).To(Equal(parametersPath.Index(3).String()))
+
+		Expect(expanded.Spec.Hooks[0].Namespace).To(Equal("${namespace}"))
+	})
+	It("reports malformed and undeclared references", func() {
+		recipe := parameterRecipe()
+		recipe.Spec.Hooks[0].Namespace = "${namespace"
+		recipe.Spec.Hooks[0].NameSelector = "${format}"
+		recipe.Spec.Groups[0].IncludedNamespaces[1] = "${1namespace}"
+
+		_, errs := Recipe.ExpandParameters(recipe, map[string]string{"namespace": "tenant-1"})
+
+		fields := []string{}
+		for _, err := range errs {
+			Expect(err.Type).To(Equal(field.ErrorTypeInvalid))
+			fields = append(fields, err.Field)
+		}
+		Expect(fields).To(Equal([]string{
+			"spec.groups[0].includedNamespaces[1]",
+			"spec.hooks[0].namespace",
+			"spec.hooks[0].nameSelector",
+		}))
+	})
+	It("leaves shell variables in commands to the shell", func() {
+		recipe := parameterRecipe()
+		recipe.Spec.Hooks[0].Ops[0].Command = `/bin/sh -c "mysql -u ${MYSQL_USER} -h ${HOST:-db} ${#ARGS} ${app}`
+
+		Expect(Recipe.ValidateRecipe(recipe)).To(BeEmpty())
+
+		expanded, errs := Recipe.ExpandParameters(recipe, map[string]string{"namespace": "tenant-1"})
+		Expect(errs).To(BeEmpty())
+		Expect(expanded.Spec.Hooks[0].Ops[0].Command).To(Equal(
+			`/bin/sh -c "mysql -u ${MYSQL_USER} -h ${HOST:-db} ${#ARGS} db`))
+
+		recipe.Spec.Hooks[0].Ops[0].Command = "/bin/sh -c 'echo ${unterminated'"
+		expanded, errs = Recipe.ExpandParameters(recipe, map[string]string{"namespace": "tenant-1"})
+		Expect(errs).To(BeEmpty())
+		Expect(expanded.Spec.Hooks[0].Ops[0].Command).To(Equal(recipe.Spec.Hooks[0].Ops[0].Command))
+	})
+	It("validates references and defaults without values", func() {
+		recipe := parameterRecipe()
+		Expect(Recipe.ValidateRecipe(recipe)).To(BeEmpty())
+
+		recipe.Spec.Parameters[3].Default = ptr.To("many")
+		recipe.Spec.Hooks[0].Ops[0].Container = "${format}"
+
+		errs := Recipe.ValidateRecipe(recipe)
+		Expect(errs).To(HaveLen(2))
+		Expect(errs[0].Field).To(Equal("spec.parameters[3].default"))
+		Expect(errs[1].Field).To(Equal("spec.hooks[0].ops[0].container"))
+		Expect(errs[1].Detail).To(ContainSubstring(`parameter "format" is not declared`))
+	})
+})
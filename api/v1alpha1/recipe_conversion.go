@@ -0,0 +1,8 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package v1alpha1
+
+// Hub marks Recipe as the version other versions of Recipes are converted to and from. Recipes are
+// stored in this version.
+func (*Recipe) Hub() {}
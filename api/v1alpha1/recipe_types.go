@@ -10,12 +10,30 @@ import (
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
+// Reserved workflow names. A recipe may define workflows of these names to customize them; when it
+// does not, the default described by RecipeSpec.EffectiveWorkflow applies.
 const (
-	BackupWorkflowName  string = "backup"
+	// BackupWorkflowName backs up groups. Defaults to backing up all groups except restore-only ones,
+	// in order.
+	BackupWorkflowName string = "backup"
+	// RestoreWorkflowName restores groups. Defaults to restoring all groups in order, where groups
+	// that a restore-only group refers to are restored by that group.
 	RestoreWorkflowName string = "restore"
-
-	// TODO
-	// Do we want to add capture and recover workflows?
+	// CaptureWorkflowName captures the application state, e.g. periodically. Defaults to the backup
+	// workflow.
+	CaptureWorkflowName string = "capture"
+	// RecoverWorkflowName recovers the application from its captured state. Defaults to the restore
+	// workflow.
+	RecoverWorkflowName string = "recover"
+	// FailoverWorkflowName recovers the application on a peer cluster after its cluster failed.
+	// Defaults to the recover workflow.
+	FailoverWorkflowName string = "failover"
+	// RelocateWorkflowName moves the application to a peer cluster in a planned way. Defaults to the
+	// recover workflow.
+	RelocateWorkflowName string = "relocate"
+	// CleanupWorkflowName runs after the application was moved away from a cluster. It cannot back up
+	// or restore groups, and defaults to doing nothing.
+	CleanupWorkflowName string = "cleanup"
 )
 
 // RecipeSpec defines the desired state of Recipe
@@ -42,12 +60,92 @@ type RecipeSpec struct {
 	//+listType=map
 	//+listMapKey=name
 	//+optional
+	// +kubebuilder:validation:MaxItems=64
 	Workflows []*Workflow `json:"workflows"`
+	// Parameters of the recipe, referenced as ${name} in the namespace, selectors and operations of
+	// hooks, in the included namespaces and selectors of groups, and in the when conditions of steps.
+	// References in commands that name no parameter, e.g. ${HOME}, are left to the shell.
+	//+listType=map
+	//+listMapKey=name
+	//+optional
+	Parameters []*Parameter `json:"parameters,omitempty"`
+	// Template the recipe instantiates. The groups, hooks, workflows and parameters of the recipe are
+	// added to the ones of the template, replacing the ones of the same name.
+	//+optional
+	Template *TemplateReference `json:"template,omitempty"`
+	// Groups and hooks imported from other recipes, e.g. the hooks shared by the applications using a
+	// database. Imported groups and hooks are used like the ones of the recipe, and must not have the
+	// name of one of them.
+	//+optional
+	Imports []*Import `json:"imports,omitempty"`
+}
+
+// Import names groups and hooks of another recipe to use in this recipe. They are imported as the
+// other recipe defines them, rendered from its template and with its own imports, and with the
+// defaults of its parameters expanded.
+// +kubebuilder:validation:XValidation:rule="(has(self.groups) && size(self.groups) > 0) || (has(self.hooks) && size(self.hooks) > 0)",message="an import must name groups or hooks"
+type Import struct {
+	// Name of the Recipe to import from
+	// +kubebuilder:validation:MinLength=1
+	Recipe string `json:"recipe"`
+	// Namespace of the Recipe to import from. Defaults to the namespace of the importing recipe.
+	// Importing from another namespace requires permission to get recipes in that namespace.
+	//+optional
+	Namespace string `json:"namespace,omitempty"`
+	// Names of the groups to import
+	//+listType=set
+	//+optional
+	Groups []string `json:"groups,omitempty"`
+	// Names of the hooks to import
+	//+listType=set
+	//+optional
+	Hooks []string `json:"hooks,omitempty"`
+}
+
+// TemplateReference refers to a RecipeTemplate and gives values for its parameters
+type TemplateReference struct {
+	// Name of the RecipeTemplate
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// Values of the parameters of the template, by parameter name. They replace the defaults of the
+	// parameters.
+	//+optional
+	Values map[string]string `json:"values,omitempty"`
+}
+
+// Types of parameters
+const (
+	ParameterTypeString  string = "string"
+	ParameterTypeInteger string = "integer"
+	ParameterTypeBoolean string = "boolean"
+)
+
+// Parameter declares a value that is given when the recipe is used, e.g. the namespace of an
+// application instance
+type Parameter struct {
+	// Name of the parameter, referenced as ${name}
+	// +kubebuilder:validation:Pattern=`^[A-Za-z_][A-Za-z0-9_]*$`
+	Name string `json:"name"`
+	// Type of the values of the parameter: string (default), integer or boolean
+	// +kubebuilder:validation:Enum=string;integer;boolean
+	// +kubebuilder:default=string
+	//+optional
+	Type string `json:"type,omitempty"`
+	// Value used when none is given. Parameters without default require a value.
+	//+optional
+	Default *string `json:"default,omitempty"`
+	// Description of the parameter
+	//+optional
+	Description string `json:"description,omitempty"`
 }
 
 // Groups defined in the recipe refine / narrow-down the scope of its parent groups defined in the
 // Application CR. Recipe groups are always be associated to a parent group in Application CR -
 // explicitly or implicitly. Recipe groups can be used in the context of backup and/or restore workflows
+// +kubebuilder:validation:XValidation:rule="self.type == 'volume' || !has(self.nameSelector)",message="nameSelector is valid for volume groups only, resource groups select by labelSelector and includedResourceTypes"
+// +kubebuilder:validation:XValidation:rule="self.type == 'volume' || !has(self.selectResource)",message="selectResource is valid for volume groups only, resource groups select by includedResourceTypes"
+// +kubebuilder:validation:XValidation:rule="self.type == 'resource' || !has(self.restoreStatus)",message="restoreStatus is valid for resource groups only"
+// +kubebuilder:validation:XValidation:rule="self.type == 'resource' || !has(self.restoreOverwriteResources)",message="restoreOverwriteResources is valid for resource groups only"
 type Group struct {
 	// Name of the group
 	Name string `json:"name"`
@@ -68,7 +166,8 @@ type Group struct {
 	// Select items based on label
 	//+optional
 	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
-	// If specified, resource's object name needs to match this expression. Valid for volume groups only.
+	// If specified, resource's object name needs to match this expression: a Go regular expression,
+	// optionally prefixed with "regex:", or a glob prefixed with "glob:". Valid for volume groups only.
 	NameSelector string `json:"nameSelector,omitempty"`
 	// Determines the resource type which the fields labelSelector and nameSelector apply to for selecting PVCs. Default selection is pvc. Valid for volume groups only.
 	// +kubebuilder:validation:Enum=pvc;pod;deployment;statefulset
@@ -84,11 +183,11 @@ type Group struct {
 	IncludedNamespaces []string `json:"includedNamespaces,omitempty"`
 	// List of namespace to exclude
 	ExcludedNamespaces []string `json:"excludedNamespaces,omitempty"`
-	// RestoreStatus restores status if set to all the includedResources specified. Specify '*' to restore all statuses for all the CRs
+	// RestoreStatus restores status if set to all the includedResources specified. Specify '*' to restore all statuses for all the CRs. Valid for resource groups only.
 	RestoreStatus *GroupRestoreStatus `json:"restoreStatus,omitempty"`
 	// Defaults to true, if set to false, a failure is not necessarily handled as fatal
 	Essential *bool `json:"essential,omitempty"`
-	// Whether to overwrite resources during restore. Default to false.
+	// Whether to overwrite resources during restore. Default to false. Valid for resource groups only.
 	RestoreOverwriteResources *bool `json:"restoreOverwriteResources,omitempty"`
 }
 
@@ -102,16 +201,61 @@ type GroupRestoreStatus struct {
 
 // Workflow is the sequence of actions to take
 type Workflow struct {
-	// Name of recipe. Names "backup" and "restore" are reserved and implicitly used by default for
-	// backup or restore respectively
+	// Name of the workflow. Names "backup", "restore", "capture", "recover", "failover", "relocate"
+	// and "cleanup" are reserved: they have a default behavior if the workflow is omitted, and
+	// restrict the steps of the workflow, e.g. restore-only groups cannot be backed up.
 	Name string `json:"name"`
 	// List of the names of groups or hooks, in the order in which they should be executed
-	// Format: <group|hook>: <group or hook name>[/<hook op>]
+	// Format: <group|hook>: <group or hook name>[/<hook op>], parallel: <name of a parallel set>, or
+	// workflow: <name of a workflow>, which runs the steps of another workflow of the recipe that is
+	// not reserved, with its own failure behavior, backing up or restoring groups as this workflow
+	// does. Workflows must not call themselves, directly or through other workflows.
+	// A step may have a condition, and is skipped unless it holds:
+	// when: <kind> [<namespace>/]<name>[: <condition>]
+	// It holds if the namespace, pvc, pod, deployment, statefulset or replicaset exists and the
+	// condition, in the language of the conditions of checks, is true for it.
+	// A hook step may override the timeout of its op in seconds with timeout: <seconds>, and group and
+	// hook steps may override how their failure is handled with onError: <fail|continue>.
+	// +kubebuilder:validation:MaxItems=256
 	Sequence []map[string]string `json:"sequence"`
 	// Implies behaviour in case of failure: any-error (default), essential-error, full-error
 	// +kubebuilder:validation:Enum=any-error;essential-error;full-error
 	// +kubebuilder:default=any-error
 	FailOn string `json:"failOn,omitempty"`
+	// Sets of steps that run concurrently, referenced from the sequence as "parallel: <name>"
+	//+optional
+	//+listType=map
+	//+listMapKey=name
+	// +kubebuilder:validation:MaxItems=32
+	Parallel []*ParallelSteps `json:"parallel,omitempty"`
+}
+
+// DefaultMaxParallel is the number of steps of a parallel set running at the same time, unless the
+// set specifies otherwise
+const DefaultMaxParallel = 5
+
+// Limits of the number of workflows of a recipe, of steps of a sequence, of parallel sets of a
+// workflow and of steps of a parallel set, matching the MaxItems of the fields
+const (
+	MaxWorkflows     = 64
+	MaxSequenceSteps = 256
+	MaxParallelSets  = 32
+	MaxParallelSteps = 64
+)
+
+// ParallelSteps is a set of steps of a workflow that run concurrently. FailOn of the workflow applies
+// to each step of the set: a failure stopping the workflow stops starting further steps of the set,
+// and the workflow stops once the running steps completed.
+type ParallelSteps struct {
+	// Name of the set, unique within the workflow
+	Name string `json:"name"`
+	// Steps of the set, in the format of the sequence of the workflow. Steps refer to groups or hooks.
+	// +kubebuilder:validation:MaxItems=64
+	Steps []map[string]string `json:"steps"`
+	// Maximum number of steps running at the same time. Defaults to 5.
+	// +kubebuilder:validation:Minimum=1
+	//+optional
+	MaxParallel int `json:"maxParallel,omitempty"`
 }
 
 // Hooks are actions to take during recipe processing
@@ -128,7 +272,8 @@ type Hook struct {
 	// If specified, resource object needs to match this label selector
 	//+optional
 	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
-	// If specified, resource's object name needs to match this expression
+	// If specified, resource's object name needs to match this expression: a Go regular expression,
+	// optionally prefixed with "regex:", or a glob prefixed with "glob:"
 	NameSelector string `json:"nameSelector,omitempty"`
 	// Boolean flag that indicates whether to execute command on a single pod or on all pods that
 	// match the selector
@@ -149,6 +294,8 @@ type Hook struct {
 	Chks []*Check `json:"chks,omitempty"`
 	// Defaults to true, if set to false, a failure is not necessarily handled as fatal
 	Essential *bool `json:"essential,omitempty"`
+	// Default retry policy of the operations and checks of the hook
+	RetryPolicy `json:",inline"`
 }
 
 // Operation to be invoked by the hook
@@ -164,30 +311,235 @@ type Operation struct {
 	OnError string `json:"onError,omitempty"`
 	// How long to wait for the command to execute, in seconds
 	Timeout int `json:"timeout,omitempty"`
-	// Name of another operation that reverts the effect of this operation (e.g. quiesce vs. unquiesce)
+	// Name of another operation that reverts the effect of this operation (e.g. quiesce vs. unquiesce).
+	// When a workflow fails, the inverse operations of the operations that succeeded and were not
+	// reverted by the workflow itself are run in reverse order.
 	InverseOp string `json:"inverseOp,omitempty"`
+	// Retry policy of the operation. Fields it does not set default to the ones of the hook.
+	RetryPolicy `json:",inline"`
 }
 
 // Operation to be invoked by the hook
 type Check struct {
 	// Name of the check. Needs to be unique within the hook
 	Name string `json:"name"`
-	// The condition to check for
+	// The condition to check for, evaluated against each object the hook selects until it is true for
+	// all of them. JSONPath expressions in braces reference values of the object and are compared
+	// with literals or each other, e.g. "{$.status.readyReplicas} == {$.spec.replicas}". Comparisons
+	// (==, !=, <, <=, >, >=) combine with &&, || and !, and strings are quoted. Without condition, the
+	// check holds once the hook selects objects.
 	Condition string `json:"condition,omitempty"`
 	// How to handle when check does not become true. Defaults to Fail.
 	OnError string `json:"onError,omitempty"`
-	// How long to wait for the check to execute, in seconds
+	// How long to wait for the condition to become true, in seconds. Defaults to the timeout of the
+	// hook.
 	Timeout int `json:"timeout,omitempty"`
+	// Retry policy of the check, which waits for the condition again for each retry. Fields it does
+	// not set default to the ones of the hook.
+	RetryPolicy `json:",inline"`
+}
+
+// RetryPolicy defines whether and how failing operations and checks are retried. Each attempt has
+// the full timeout of the operation or check.
+type RetryPolicy struct {
+	// Number of times a failing operation or check is retried. Defaults to 0, no retries.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=20
+	//+optional
+	Retries *int `json:"retries,omitempty"`
+	// Delay between attempts. Defaults to a fixed delay of 5 seconds.
+	//+optional
+	Backoff *Backoff `json:"backoff,omitempty"`
+	// Failures to retry. If unset, every failure is retried.
+	//+optional
+	RetryOn *RetryOn `json:"retryOn,omitempty"`
+}
+
+// Backoff types
+const (
+	// BackoffFixed waits the same delay before each retry
+	BackoffFixed string = "fixed"
+	// BackoffExponential doubles the delay after each retry, up to the maximum delay
+	BackoffExponential string = "exponential"
+)
+
+// Backoff is the delay between the attempts of an operation or check
+type Backoff struct {
+	// Type of backoff: fixed (default) or exponential
+	// +kubebuilder:validation:Enum=fixed;exponential
+	// +kubebuilder:default=fixed
+	//+optional
+	Type string `json:"type,omitempty"`
+	// Delay before the first retry, in seconds
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=5
+	//+optional
+	Delay int `json:"delay,omitempty"`
+	// Maximum delay between attempts of exponential backoff, in seconds. Defaults to 60.
+	// +kubebuilder:validation:Minimum=1
+	//+optional
+	MaxDelay int `json:"maxDelay,omitempty"`
+}
+
+// RetryOn selects the failures of an operation or check to retry: failures of commands of exec
+// operations that exited with one of the exit codes, or wrote a match of the regular expression to
+// stderr, in any of the pods they ran in. Other failures, e.g. of checks or scale operations, or of
+// commands that could not be run, are not retried when RetryOn is set.
+type RetryOn struct {
+	// Exit codes of commands to retry
+	//+listType=set
+	//+optional
+	ExitCodes []int `json:"exitCodes,omitempty"`
+	// Go regular expression matching the stderr of commands to retry
+	//+optional
+	StderrRegex string `json:"stderrRegex,omitempty"`
+}
+
+const (
+	// RecipeConditionValid is true when the recipe passed semantic validation
+	RecipeConditionValid string = "Valid"
+	// RecipeConditionResolved is true when every reference of the recipe, e.g. a workflow step
+	// naming a group or hook, could be resolved
+	RecipeConditionResolved string = "Resolved"
+	// RecipeConditionReady is true when the recipe is both valid and resolved, and can be used by
+	// workflows
+	RecipeConditionReady string = "Ready"
+)
+
+// Reasons of the recipe conditions. Ready is false with the reason of the first condition that is
+// not true.
+const (
+	RecipeReasonValid            string = "Valid"
+	RecipeReasonValidationFailed string = "ValidationFailed"
+	RecipeReasonResolved         string = "Resolved"
+	RecipeReasonUnresolved       string = "UnresolvedReferences"
+	RecipeReasonReady            string = "Ready"
+)
+
+// FindingSeverity is the severity of a validation finding
+// +kubebuilder:validation:Enum=Error;Warning
+type FindingSeverity string
+
+const (
+	// FindingSeverityError marks findings that make the recipe unusable
+	FindingSeverityError FindingSeverity = "Error"
+	// FindingSeverityWarning marks findings that are likely mistakes but do not prevent the recipe
+	// from being used
+	FindingSeverityWarning FindingSeverity = "Warning"
+)
+
+// Finding is a single result of validating a recipe
+type Finding struct {
+	// Severity of the finding
+	Severity FindingSeverity `json:"severity"`
+	// Path of the offending field, e.g. spec.workflows[0].sequence[1][hook]
+	Path string `json:"path"`
+	// Type of the problem, e.g. FieldValueNotFound
+	//+optional
+	Type string `json:"type,omitempty"`
+	// Human readable description of the problem
+	Message string `json:"message"`
+}
+
+// MaxSelectionPreviewObjects is the number of matched objects listed in a SelectionPreview
+const MaxSelectionPreviewObjects = 20
+
+// SelectionPreview shows what a group or hook of the recipe currently selects in the cluster
+type SelectionPreview struct {
+	// Kind of the recipe item: group, hook, or volumes for the volumes group
+	// +kubebuilder:validation:Enum=group;hook;volumes
+	Kind string `json:"kind"`
+	// Name of the group or hook
+	Name string `json:"name"`
+	// Namespaces the selectors were evaluated in
+	//+optional
+	Namespaces []string `json:"namespaces,omitempty"`
+	// Number of matched objects by type
+	Counts SelectionCounts `json:"counts"`
+	// Matched objects as <type>/<namespace>/<name>, limited to the first 20
+	//+optional
+	Objects []string `json:"objects,omitempty"`
+	// Whether matched objects were left out of objects
+	//+optional
+	Truncated bool `json:"truncated,omitempty"`
+	// Error that prevented resolving the selectors
+	//+optional
+	Error string `json:"error,omitempty"`
+}
+
+// SelectionCounts is the number of objects a group or hook selects, by type
+type SelectionCounts struct {
+	Namespaces   int32 `json:"namespaces"`
+	PVCs         int32 `json:"pvcs"`
+	Pods         int32 `json:"pods"`
+	Deployments  int32 `json:"deployments"`
+	StatefulSets int32 `json:"statefulsets"`
+}
+
+// RenderedTemplate identifies the generation of a RecipeTemplate a recipe was rendered from
+type RenderedTemplate struct {
+	// Name of the RecipeTemplate
+	Name string `json:"name"`
+	// Generation of the RecipeTemplate
+	Generation int64 `json:"generation"`
+}
+
+// RecipeDependency is a node of the dependency graph of a recipe: a recipe it imports from, directly
+// or through other recipes
+type RecipeDependency struct {
+	// Namespace of the recipe
+	Namespace string `json:"namespace"`
+	// Name of the recipe
+	Name string `json:"name"`
+	// Generation of the recipe that was imported from, unset if the recipe was not found
+	//+optional
+	Generation int64 `json:"generation,omitempty"`
+	// Recipes this recipe imports from, as <namespace>/<name>
+	//+optional
+	Imports []string `json:"imports,omitempty"`
 }
 
 // RecipeStatus defines the observed state of Recipe
 type RecipeStatus struct {
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+	// Generation of the recipe that was last processed by the reconciler
+	//+optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions Valid, Resolved and Ready of the recipe
+	//+listType=map
+	//+listMapKey=type
+	//+optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// Findings of the last validation, errors first
+	//+optional
+	Findings []Finding `json:"findings,omitempty"`
+	// Template the recipe was rendered from, for recipes referencing a template
+	//+optional
+	Template *RenderedTemplate `json:"template,omitempty"`
+	// EffectiveSpec is the spec rendered from the template and with the imported groups and hooks,
+	// for recipes referencing a template or importing. Findings and selections refer to it.
+	//+optional
+	EffectiveSpec *RecipeSpec `json:"effectiveSpec,omitempty"`
+	// Recipes the recipe imports from, directly or through other recipes. The imports of the recipe
+	// itself are its spec.imports.
+	//+listType=map
+	//+listMapKey=namespace
+	//+listMapKey=name
+	//+optional
+	Dependencies []RecipeDependency `json:"dependencies,omitempty"`
+	// What the groups and hooks of the recipe currently select in the cluster
+	//+listType=map
+	//+listMapKey=kind
+	//+listMapKey=name
+	//+optional
+	Selections []SelectionPreview `json:"selections,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:storageversion
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Reason",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].reason`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // Recipe is the Schema for the recipes API
 type Recipe struct {
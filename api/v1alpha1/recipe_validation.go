@@ -0,0 +1,771 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package v1alpha1
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"github.com/ramendr/recipe/api/condition"
+)
+
+// ValidateRecipe performs the semantic checks that the OpenAPI schema of the CRD cannot express,
+// such as resolving the references of workflow sequences. It is shared by the admission webhook and
+// the reconciler so that both report identical errors. References to the groups and hooks a recipe
+// imports are checked once ResolveImports added them. Likewise, references of recipes referencing a
+// template to groups, hooks, workflows and parameters the template may define, and restore-only
+// groups, which may refer to groups and be backed up by workflows of the template, are checked once
+// rendered by RenderRecipe.
+func ValidateRecipe(recipe *Recipe) field.ErrorList {
+	specPath := field.NewPath("spec")
+
+	allErrs := field.ErrorList{}
+	if recipe.Spec.Template == nil {
+		allErrs = append(allErrs, validateGroups(&recipe.Spec, recipe.Namespace, specPath.Child("groups"))...)
+	}
+
+	allErrs = append(allErrs, validateHooks(&recipe.Spec, specPath.Child("hooks"))...)
+	allErrs = append(allErrs, validateWorkflows(&recipe.Spec, specPath.Child("workflows"))...)
+	allErrs = append(allErrs, validateImports(&recipe.Spec, specPath.Child("imports"))...)
+	allErrs = append(allErrs, validateParameters(&recipe.Spec, specPath)...)
+
+	for _, selector := range nameSelectors(&recipe.Spec, specPath) {
+		if _, err := CompileNameSelector(selector.selector); err != nil {
+			allErrs = append(allErrs, field.Invalid(selector.path, selector.selector, err.Error()))
+		}
+	}
+
+	return allErrs
+}
+
+// RecipeWarnings returns the findings of the recipe that are likely mistakes but do not make it
+// invalid, such as name selectors that can never match an object name. The admission webhook returns
+// them as warnings.
+func RecipeWarnings(recipe *Recipe) field.ErrorList {
+	warnings := field.ErrorList{}
+
+	for _, selector := range nameSelectors(&recipe.Spec, field.NewPath("spec")) {
+		for _, warning := range NameSelectorWarnings(selector.selector) {
+			warnings = append(warnings, field.Invalid(selector.path, selector.selector, warning))
+		}
+	}
+
+	if recipe.Spec.Template == nil {
+		warnings = append(warnings, backupRefWarnings(&recipe.Spec, field.NewPath("spec", "groups"))...)
+	}
+
+	return warnings
+}
+
+// backupRefWarnings reports restore-only groups whose selection cannot be verified to be a subset of
+// the backup of the group they refer to, because either group selects namespaces by label or objects
+// by label expressions
+func backupRefWarnings(spec *RecipeSpec, groupsPath *field.Path) field.ErrorList {
+	warnings := field.ErrorList{}
+
+	for i, group := range spec.Groups {
+		if group == nil || !group.IsRestoreOnly() {
+			continue
+		}
+
+		backupGroup := spec.FindGroup(group.BackupRef)
+		if backupGroup == nil || backupGroup == group {
+			continue
+		}
+
+		groupPath := groupsPath.Index(i)
+
+		switch {
+		case group.IncludedNamespacesByLabel != nil:
+			warnings = append(warnings, field.Invalid(groupPath.Child("includedNamespacesByLabel"),
+				group.IncludedNamespacesByLabel, fmt.Sprintf(
+					"namespaces selected by label cannot be verified to be included in the backup of group %q",
+					backupGroup.Name)))
+		case backupGroup.IncludedNamespacesByLabel != nil:
+			warnings = append(warnings, field.Invalid(groupPath.Child("backupRef"), group.BackupRef,
+				fmt.Sprintf("group %q selects namespaces by label, the namespaces of the group cannot be "+
+					"verified to be included in its backup", backupGroup.Name)))
+		}
+
+		switch {
+		case group.LabelSelector != nil && len(group.LabelSelector.MatchExpressions) != 0:
+			warnings = append(warnings, field.Invalid(groupPath.Child("labelSelector", "matchExpressions"),
+				group.LabelSelector.MatchExpressions, fmt.Sprintf(
+					"label expressions cannot be verified to select objects included in the backup of group %q",
+					backupGroup.Name)))
+		case backupGroup.LabelSelector != nil && len(backupGroup.LabelSelector.MatchExpressions) != 0:
+			warnings = append(warnings, field.Invalid(groupPath.Child("backupRef"), group.BackupRef,
+				fmt.Sprintf("group %q selects objects by label expressions, the objects of the group cannot be "+
+					"verified to be included in its backup", backupGroup.Name)))
+		}
+	}
+
+	return warnings
+}
+
+// nameSelector is a name selector of a recipe and its path
+// +kubebuilder:object:generate=false
+type nameSelector struct {
+	path     *field.Path
+	selector string
+}
+
+// nameSelectors returns the non-empty name selectors of the groups, volumes and hooks of a recipe,
+// except ones referencing parameters
+func nameSelectors(spec *RecipeSpec, specPath *field.Path) []nameSelector {
+	selectors := []nameSelector{}
+
+	for i, group := range spec.Groups {
+		if group != nil && isValidatable(group.NameSelector) {
+			selectors = append(selectors,
+				nameSelector{specPath.Child("groups").Index(i).Child("nameSelector"), group.NameSelector})
+		}
+	}
+
+	if spec.Volumes != nil && isValidatable(spec.Volumes.NameSelector) {
+		selectors = append(selectors, nameSelector{specPath.Child("volumes", "nameSelector"), spec.Volumes.NameSelector})
+	}
+
+	for i, hook := range spec.Hooks {
+		if hook != nil && isValidatable(hook.NameSelector) {
+			selectors = append(selectors,
+				nameSelector{specPath.Child("hooks").Index(i).Child("nameSelector"), hook.NameSelector})
+		}
+	}
+
+	return selectors
+}
+
+// isValidatable reports whether a value is set and does not reference parameters
+func isValidatable(value string) bool {
+	return value != "" && !HasParameterReference(value)
+}
+
+func validateGroups(spec *RecipeSpec, namespace string, groupsPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	var backedUp map[string]bool
+
+	for i, group := range spec.Groups {
+		if group == nil || !group.IsRestoreOnly() {
+			continue
+		}
+
+		if backedUp == nil {
+			backedUp = backedUpGroups(spec)
+		}
+
+		allErrs = append(allErrs, validateBackupRef(spec, namespace, group, backedUp, groupsPath.Index(i))...)
+	}
+
+	return allErrs
+}
+
+// validateBackupRef checks that a restore-only group refers to a group of its type that a workflow
+// backs up, and that it does not select what the backup of that group cannot contain, which would
+// be silently not restored. Groups including no namespaces apply to the namespace of the recipe, as
+// when resolving them. Namespaces selected by label are only known once resolved, so RecipeWarnings
+// reports them instead.
+func validateBackupRef(spec *RecipeSpec, namespace string, group *Group, backedUp map[string]bool,
+	groupPath *field.Path,
+) field.ErrorList {
+	refPath := groupPath.Child("backupRef")
+
+	backupGroup := spec.FindGroup(group.BackupRef)
+
+	switch {
+	case backupGroup == nil && spec.providesGroup(group.BackupRef):
+		return nil
+	case backupGroup == nil:
+		return field.ErrorList{field.NotFound(refPath, group.BackupRef)}
+	case backupGroup == group:
+		return field.ErrorList{field.Invalid(refPath, group.BackupRef, "must name another group")}
+	case backupGroup.IsRestoreOnly():
+		return field.ErrorList{field.Invalid(refPath, group.BackupRef,
+			fmt.Sprintf("group %q is restore-only itself, backupRef must name a group that is backed up",
+				backupGroup.Name))}
+	case backupGroup.Type != group.Type:
+		return field.ErrorList{field.Invalid(refPath, group.BackupRef,
+			fmt.Sprintf("group %q is of type %s, not %s", backupGroup.Name, backupGroup.Type, group.Type))}
+	case !backedUp[backupGroup.Name]:
+		return field.ErrorList{field.Invalid(refPath, group.BackupRef,
+			fmt.Sprintf("group %q is not backed up by any workflow, so there is no backup to restore from",
+				backupGroup.Name))}
+	}
+
+	allErrs := field.ErrorList{}
+
+	if group.IncludedNamespacesByLabel == nil && backupGroup.IncludedNamespacesByLabel == nil {
+		allErrs = append(allErrs, validateRestoreNamespaces(namespace, group, backupGroup,
+			groupPath.Child("includedNamespaces"))...)
+	}
+
+	allErrs = append(allErrs, validateRestoreSubset(groupPath.Child("includedResourceTypes"), "resource type",
+		backupGroup, group.IncludedResourceTypes, backupGroup.IncludedResourceTypes,
+		backupGroup.ExcludedResourceTypes)...)
+
+	if group.LabelSelector != nil && backupGroup.LabelSelector != nil {
+		labelsPath := groupPath.Child("labelSelector", "matchLabels")
+
+		keys := make([]string, 0, len(group.LabelSelector.MatchLabels))
+		for key := range group.LabelSelector.MatchLabels {
+			keys = append(keys, key)
+		}
+
+		slices.Sort(keys)
+
+		for _, key := range keys {
+			value := group.LabelSelector.MatchLabels[key]
+
+			backupValue, ok := backupGroup.LabelSelector.MatchLabels[key]
+			if ok && backupValue != value {
+				allErrs = append(allErrs, field.Invalid(labelsPath.Key(key), value,
+					fmt.Sprintf("group %q backs up only objects labeled %s=%s", backupGroup.Name, key, backupValue)))
+			}
+		}
+	}
+
+	if group.IncludeClusterResources != nil && *group.IncludeClusterResources &&
+		backupGroup.IncludeClusterResources != nil && !*backupGroup.IncludeClusterResources {
+		allErrs = append(allErrs, field.Invalid(groupPath.Child("includeClusterResources"), true,
+			fmt.Sprintf("group %q does not back up cluster-scoped resources", backupGroup.Name)))
+	}
+
+	return allErrs
+}
+
+// validateRestoreNamespaces checks that the namespaces a restore-only group applies to are included
+// in the backup of the group it refers to
+func validateRestoreNamespaces(namespace string, group, backupGroup *Group, path *field.Path) field.ErrorList {
+	backupIncluded := defaultNamespaces(backupGroup.IncludedNamespaces, namespace)
+
+	if len(group.IncludedNamespaces) != 0 || namespace == "" {
+		return validateRestoreSubset(path, "namespace", backupGroup, group.IncludedNamespaces, backupIncluded,
+			backupGroup.ExcludedNamespaces)
+	}
+
+	if len(validateRestoreSubset(path, "namespace", backupGroup, []string{namespace}, backupIncluded,
+		backupGroup.ExcludedNamespaces)) != 0 {
+		return field.ErrorList{field.Invalid(path, group.IncludedNamespaces,
+			fmt.Sprintf("group applies to the namespace %q of the recipe, which is not included in the backup "+
+				"of group %q", namespace, backupGroup.Name))}
+	}
+
+	return nil
+}
+
+// defaultNamespaces returns the included namespaces of a group, or the namespace of the recipe if
+// there are none and it is known
+func defaultNamespaces(included []string, namespace string) []string {
+	if len(included) == 0 && namespace != "" {
+		return []string{namespace}
+	}
+
+	return included
+}
+
+// validateRestoreSubset checks that the namespaces or resource types a restore-only group includes
+// are included in the backup of the group it refers to. Empty lists and "*" include everything.
+func validateRestoreSubset(path *field.Path, what string, backupGroup *Group, restoreIncluded, included,
+	excluded []string,
+) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	for k, value := range restoreIncluded {
+		switch {
+		case value == "*":
+		case slices.Contains(excluded, value):
+			allErrs = append(allErrs, field.Invalid(path.Index(k), value,
+				fmt.Sprintf("%s is excluded from the backup of group %q", what, backupGroup.Name)))
+		case len(included) != 0 && !slices.Contains(included, "*") && !slices.Contains(included, value):
+			allErrs = append(allErrs, field.Invalid(path.Index(k), value,
+				fmt.Sprintf("%s is not included in the backup of group %q, which includes %q", what,
+					backupGroup.Name, included)))
+		}
+	}
+
+	return allErrs
+}
+
+// backedUpGroups returns the names of the groups that the workflows of the recipe back up,
+// including the defaults of omitted reserved workflows
+func backedUpGroups(spec *RecipeSpec) map[string]bool {
+	workflows := slices.Clone(spec.Workflows)
+
+	for _, name := range []string{BackupWorkflowName, CaptureWorkflowName} {
+		if spec.FindWorkflow(name) == nil {
+			workflows = append(workflows, spec.EffectiveWorkflow(name))
+		}
+	}
+
+	groups := map[string]bool{}
+
+	for _, workflow := range workflows {
+		if workflow == nil || backingUpWorkflow(spec, workflow.Name) == "" {
+			continue
+		}
+
+		entries := slices.Clone(workflow.Sequence)
+		for _, parallel := range workflow.Parallel {
+			if parallel != nil {
+				entries = append(entries, parallel.Steps...)
+			}
+		}
+
+		for _, entry := range entries {
+			if step, err := ParseStep(entry); err == nil && step.Kind == StepKindGroup {
+				groups[step.Name] = true
+			}
+		}
+	}
+
+	return groups
+}
+
+func validateHooks(spec *RecipeSpec, hooksPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	for i, hook := range spec.Hooks {
+		if hook == nil {
+			continue
+		}
+
+		if hook.Type == HookTypeScale {
+			allErrs = append(allErrs, validateScaleHook(hook, hooksPath.Index(i))...)
+		}
+
+		allErrs = append(allErrs, validateRetryPolicy(&hook.RetryPolicy, hooksPath.Index(i))...)
+
+		for j, op := range hook.Ops {
+			if op != nil {
+				allErrs = append(allErrs, validateRetryPolicy(&op.RetryPolicy, hooksPath.Index(i).Child("ops").Index(j))...)
+			}
+		}
+
+		// the inverse op is run to revert its op when a workflow fails, so it must exist in the hook
+		for j, op := range hook.Ops {
+			if op == nil || op.InverseOp == "" {
+				continue
+			}
+
+			inversePath := hooksPath.Index(i).Child("ops").Index(j).Child("inverseOp")
+
+			switch {
+			case op.InverseOp == op.Name:
+				allErrs = append(allErrs, field.Invalid(inversePath, op.InverseOp, "must name another op"))
+			case hook.FindOp(op.InverseOp) == nil:
+				allErrs = append(allErrs, field.NotFound(inversePath, op.InverseOp))
+			}
+		}
+
+		for j, chk := range hook.Chks {
+			if chk == nil {
+				continue
+			}
+
+			chkPath := hooksPath.Index(i).Child("chks").Index(j)
+
+			// sequence steps name ops and checks alike, so a name used for both would be ambiguous
+			if hook.FindOp(chk.Name) != nil {
+				allErrs = append(allErrs, field.Duplicate(chkPath.Child("name"), chk.Name))
+			}
+
+			// checks without condition hold once the hook selects objects
+			if chk.Condition != "" {
+				if _, err := condition.Parse(chk.Condition); err != nil {
+					allErrs = append(allErrs, field.Invalid(chkPath.Child("condition"), chk.Condition, err.Error()))
+				}
+			}
+
+			allErrs = append(allErrs, validateRetryPolicy(&chk.RetryPolicy, chkPath)...)
+		}
+	}
+
+	return allErrs
+}
+
+// validateRetryPolicy checks that the stderr expression of a retry policy compiles and that the
+// maximum delay of its backoff is not shorter than the first
+func validateRetryPolicy(policy *RetryPolicy, path *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if retryOn := policy.RetryOn; retryOn != nil && !HasParameterReference(retryOn.StderrRegex) {
+		if _, err := regexp.Compile(retryOn.StderrRegex); err != nil {
+			allErrs = append(allErrs, field.Invalid(path.Child("retryOn", "stderrRegex"), retryOn.StderrRegex,
+				err.Error()))
+		}
+	}
+
+	backoff := policy.Backoff
+	if backoff == nil {
+		return allErrs
+	}
+
+	// no delay, or doubling it, would retry without waiting
+	if backoff.Delay <= 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("backoff", "delay"), backoff.Delay, "must be positive"))
+	}
+
+	if backoff.MaxDelay != 0 && backoff.MaxDelay < backoff.Delay {
+		allErrs = append(allErrs, field.Invalid(path.Child("backoff", "maxDelay"), backoff.MaxDelay,
+			"must not be less than delay"))
+	}
+
+	return allErrs
+}
+
+// validateScaleHook checks that a scale hook selects a kind of workload and that its operations
+// are scale commands
+func validateScaleHook(hook *Hook, hookPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if !slices.Contains(ScaleSelectResources, hook.SelectResource) {
+		allErrs = append(allErrs, field.NotSupported(hookPath.Child("selectResource"), hook.SelectResource,
+			ScaleSelectResources))
+	}
+
+	for j, op := range hook.Ops {
+		if op == nil || HasParameterReference(op.Command) {
+			continue
+		}
+
+		if !slices.Contains(ScaleCommands, strings.TrimSpace(op.Command)) {
+			allErrs = append(allErrs, field.NotSupported(hookPath.Child("ops").Index(j).Child("command"),
+				op.Command, ScaleCommands))
+		}
+	}
+
+	return allErrs
+}
+
+func validateWorkflows(spec *RecipeSpec, workflowsPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if len(spec.Workflows) > MaxWorkflows {
+		allErrs = append(allErrs, field.TooMany(workflowsPath, len(spec.Workflows), MaxWorkflows))
+	}
+
+	for i, workflow := range spec.Workflows {
+		if workflow == nil {
+			continue
+		}
+
+		workflowPath := workflowsPath.Index(i)
+
+		sequencePath := workflowPath.Child("sequence")
+		if len(workflow.Sequence) > MaxSequenceSteps {
+			allErrs = append(allErrs, field.TooMany(sequencePath, len(workflow.Sequence), MaxSequenceSteps))
+		}
+
+		if len(workflow.Parallel) > MaxParallelSets {
+			allErrs = append(allErrs,
+				field.TooMany(workflowPath.Child("parallel"), len(workflow.Parallel), MaxParallelSets))
+		}
+
+		for j, entry := range workflow.Sequence {
+			allErrs = append(allErrs,
+				validateSequenceEntry(spec, workflow, entry, SupportedStepKinds, sequencePath.Index(j))...)
+		}
+
+		for j, parallel := range workflow.Parallel {
+			if parallel == nil {
+				continue
+			}
+
+			stepsPath := workflowPath.Child("parallel").Index(j).Child("steps")
+			if len(parallel.Steps) > MaxParallelSteps {
+				allErrs = append(allErrs, field.TooMany(stepsPath, len(parallel.Steps), MaxParallelSteps))
+			}
+
+			for k, entry := range parallel.Steps {
+				allErrs = append(allErrs,
+					validateSequenceEntry(spec, workflow, entry, ParallelStepKinds, stepsPath.Index(k))...)
+			}
+		}
+	}
+
+	return allErrs
+}
+
+// validateSequenceEntry validates an entry of a sequence or of a parallel set, which support
+// different kinds of steps
+func validateSequenceEntry(spec *RecipeSpec, workflow *Workflow, entry map[string]string, kinds []string,
+	stepPath *field.Path,
+) field.ErrorList {
+	kind, err := stepKind(entry)
+	if err != nil {
+		return field.ErrorList{field.Invalid(stepPath, entry, err.Error())}
+	}
+
+	value := entry[kind]
+	valuePath := stepPath.Key(kind)
+
+	if !slices.Contains(kinds, kind) {
+		return field.ErrorList{field.NotSupported(stepPath, kind, kinds)}
+	}
+
+	step, err := parseStep(kind, value)
+	if err != nil {
+		return field.ErrorList{field.Invalid(valuePath, value, err.Error())}
+	}
+
+	allErrs := field.ErrorList{}
+
+	for _, key := range StepOptionKeys {
+		option, ok := entry[key]
+		if !ok {
+			continue
+		}
+
+		if err := step.setOption(key, option); err != nil {
+			allErrs = append(allErrs, field.Invalid(stepPath.Key(key), option, err.Error()))
+
+			continue
+		}
+
+		if key != StepKeyWhen || HasParameterReference(option) {
+			continue
+		}
+
+		if _, err := ParseWhen(option); err != nil {
+			allErrs = append(allErrs, field.Invalid(stepPath.Key(key), option, err.Error()))
+		}
+	}
+
+	return append(allErrs, validateStepReference(spec, workflow, step, valuePath)...)
+}
+
+func validateStepReference(spec *RecipeSpec, workflow *Workflow, step Step, valuePath *field.Path,
+) field.ErrorList {
+	switch step.Kind {
+	case StepKindParallel:
+		if workflow.FindParallel(step.Name) == nil {
+			return field.ErrorList{field.NotFound(valuePath, step.Name)}
+		}
+	case StepKindGroup:
+		group := spec.FindGroup(step.Name)
+		if group == nil && !spec.providesGroup(step.Name) {
+			return field.ErrorList{field.NotFound(valuePath, step.Name)}
+		}
+
+		return validateGroupStep(spec, workflow, group, valuePath)
+	case StepKindWorkflow:
+		return validateWorkflowStep(spec, workflow, step.Name, valuePath)
+	case StepKindHook:
+		hook := spec.FindHook(step.Name)
+		if hook == nil && spec.providesHook(step.Name) {
+			return nil
+		}
+
+		if hook == nil {
+			return field.ErrorList{field.NotFound(valuePath, step.Name)}
+		}
+
+		if _, err := hook.ResolveOp(step.Op); err != nil {
+			if step.Op != "" {
+				return field.ErrorList{field.NotFound(valuePath, step.Name+"/"+step.Op)}
+			}
+
+			return field.ErrorList{field.Invalid(valuePath, step.Name, err.Error())}
+		}
+	}
+
+	return nil
+}
+
+// providesGroup reports whether a group the recipe does not define may be imported or defined by its
+// template
+func (s *RecipeSpec) providesGroup(name string) bool {
+	return s.Template != nil || s.importsGroup(name)
+}
+
+// providesHook reports whether a hook the recipe does not define may be imported or defined by its
+// template
+func (s *RecipeSpec) providesHook(name string) bool {
+	return s.Template != nil || s.importsHook(name)
+}
+
+// validateWorkflowStep checks that a workflow calls a workflow of the recipe that is not reserved,
+// that it does not call itself through it, and that the cleanup workflow calls no workflow with
+// group steps
+func validateWorkflowStep(spec *RecipeSpec, workflow *Workflow, name string, valuePath *field.Path,
+) field.ErrorList {
+	called := spec.FindWorkflow(name)
+
+	switch {
+	case IsReservedWorkflowName(name):
+		return field.ErrorList{field.Invalid(valuePath, name, "reserved workflows cannot be called")}
+	case called == nil && spec.Template != nil:
+		return nil
+	case called == nil:
+		return field.ErrorList{field.NotFound(valuePath, name)}
+	}
+
+	if calls := callPath(spec, name, workflow.Name, map[string]bool{}); calls != nil {
+		return field.ErrorList{field.Invalid(valuePath, name, fmt.Sprintf("workflow %q calls itself: %s",
+			workflow.Name, strings.Join(append([]string{workflow.Name}, calls...), " -> ")))}
+	}
+
+	if !allowsGroups(workflow.Name) && hasGroupSteps(spec, called, map[string]bool{}) {
+		return field.ErrorList{field.Forbidden(valuePath,
+			fmt.Sprintf("the %s workflow cannot back up or restore groups, and workflow %q has group steps",
+				workflow.Name, name))}
+	}
+
+	return nil
+}
+
+// callPath returns the names of the workflows from the named workflow to target, following the
+// workflow steps of their sequences, or nil if the workflow does not lead to target. Workflows in
+// visited are not followed again.
+func callPath(spec *RecipeSpec, name, target string, visited map[string]bool) []string {
+	if name == target {
+		return []string{name}
+	}
+
+	workflow := spec.FindWorkflow(name)
+	if workflow == nil || visited[name] {
+		return nil
+	}
+
+	visited[name] = true
+
+	for _, entry := range workflow.Sequence {
+		step, err := ParseStep(entry)
+		if err != nil || step.Kind != StepKindWorkflow {
+			continue
+		}
+
+		if calls := callPath(spec, step.Name, target, visited); calls != nil {
+			return append([]string{name}, calls...)
+		}
+	}
+
+	return nil
+}
+
+// hasGroupSteps reports whether a workflow, or a workflow it calls, has group steps
+func hasGroupSteps(spec *RecipeSpec, workflow *Workflow, visited map[string]bool) bool {
+	if visited[workflow.Name] {
+		return false
+	}
+
+	visited[workflow.Name] = true
+
+	entries := slices.Clone(workflow.Sequence)
+	for _, parallel := range workflow.Parallel {
+		if parallel != nil {
+			entries = append(entries, parallel.Steps...)
+		}
+	}
+
+	for _, entry := range entries {
+		step, err := ParseStep(entry)
+		if err != nil {
+			continue
+		}
+
+		switch step.Kind {
+		case StepKindGroup:
+			return true
+		case StepKindWorkflow:
+			if called := spec.FindWorkflow(step.Name); called != nil && hasGroupSteps(spec, called, visited) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// validateGroupStep checks that the workflow can run a step of the group: cleanup workflows have no
+// group steps, and restore-only groups cannot be backed up. Group is nil for imported groups, which
+// are checked for being restore-only once resolved, as are groups of workflows that reserved
+// workflows of a template may call.
+func validateGroupStep(spec *RecipeSpec, workflow *Workflow, group *Group, valuePath *field.Path,
+) field.ErrorList {
+	if !allowsGroups(workflow.Name) {
+		return field.ErrorList{field.Forbidden(valuePath,
+			fmt.Sprintf("the %s workflow cannot back up or restore groups", workflow.Name))}
+	}
+
+	if group == nil || !group.IsRestoreOnly() || spec.Template != nil && !IsReservedWorkflowName(workflow.Name) {
+		return nil
+	}
+
+	switch backingUp := backingUpWorkflow(spec, workflow.Name); backingUp {
+	case "":
+		return nil
+	case workflow.Name:
+		return field.ErrorList{field.Invalid(valuePath, group.Name,
+			fmt.Sprintf("group is restore-only with backupRef %q, and the %s workflow backs up groups",
+				group.BackupRef, workflow.Name))}
+	default:
+		return field.ErrorList{field.Invalid(valuePath, group.Name,
+			fmt.Sprintf("group is restore-only with backupRef %q, and the %s workflow calling workflow %q "+
+				"backs up groups", group.BackupRef, backingUp, workflow.Name))}
+	}
+}
+
+// backingUpWorkflow returns the name of a workflow backing up the groups of the named workflow, or
+// "" if its groups are only restored. Reserved workflows back up or restore groups by name. Other
+// workflows back up or restore groups as the reserved workflows calling them, directly or through
+// other workflows, do, and back up groups when they are not called by any, as they then only run on
+// their own.
+func backingUpWorkflow(spec *RecipeSpec, name string) string {
+	if IsReservedWorkflowName(name) {
+		if IsRestoreWorkflow(name) || !allowsGroups(name) {
+			return ""
+		}
+
+		return name
+	}
+
+	called := false
+
+	for _, workflow := range spec.Workflows {
+		if workflow == nil || !IsReservedWorkflowName(workflow.Name) || !allowsGroups(workflow.Name) ||
+			callPath(spec, workflow.Name, name, map[string]bool{}) == nil {
+			continue
+		}
+
+		if !IsRestoreWorkflow(workflow.Name) {
+			return workflow.Name
+		}
+
+		called = true
+	}
+
+	if called {
+		return ""
+	}
+
+	return name
+}
+
+// IsUnresolved reports whether a validation error is caused by a reference that could not be
+// resolved, as opposed to an invalid value
+func IsUnresolved(err *field.Error) bool {
+	return err.Type == field.ErrorTypeNotFound
+}
+
+// Findings converts validation errors into status findings of the given severity
+func Findings(errs field.ErrorList, severity FindingSeverity) []Finding {
+	findings := make([]Finding, 0, len(errs))
+
+	for _, err := range errs {
+		findings = append(findings, Finding{
+			Severity: severity,
+			Path:     err.Field,
+			Type:     string(err.Type),
+			Message:  err.ErrorBody(),
+		})
+	}
+
+	return findings
+}
@@ -0,0 +1,620 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package v1alpha1_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
+
+	Recipe "github.com/ramendr/recipe/api/v1alpha1"
+)
+
+func sequenceRecipe(sequence ...map[string]string) *Recipe.Recipe {
+	return &Recipe.Recipe{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-recipe", Namespace: "test-ns"},
+		Spec: Recipe.RecipeSpec{
+			Groups: []*Recipe.Group{
+				{Name: "config", Type: "resource"},
+				{Name: "data", Type: "volume"},
+			},
+			Hooks: []*Recipe.Hook{
+				{
+					Name: "db",
+					Type: "exec",
+					Ops: []*Recipe.Operation{
+						{Name: "quiesce", Command: "/bin/quiesce"},
+						{Name: "unquiesce", Command: "/bin/unquiesce"},
+					},
+					Chks: []*Recipe.Check{{Name: "ready"}},
+				},
+				{
+					Name: "cache",
+					Type: "exec",
+					Ops:  []*Recipe.Operation{{Name: "flush", Command: "/bin/flush"}},
+				},
+			},
+			Workflows: []*Recipe.Workflow{
+				{Name: Recipe.BackupWorkflowName, Sequence: sequence},
+			},
+		},
+	}
+}
+
+func stepPath(step int) *field.Path {
+	return field.NewPath("spec", "workflows").Index(0).Child("sequence").Index(step)
+}
+
+var _ = Describe("ParseStep", func() {
+	It("parses groups", func() {
+		step, err := Recipe.ParseStep(map[string]string{"group": "config"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(step).To(Equal(Recipe.Step{Kind: Recipe.StepKindGroup, Name: "config"}))
+	})
+	It("parses hooks with and without op", func() {
+		step, err := Recipe.ParseStep(map[string]string{"hook": "db/quiesce"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(step).To(Equal(Recipe.Step{Kind: Recipe.StepKindHook, Name: "db", Op: "quiesce"}))
+		Expect(step.String()).To(Equal("hook: db/quiesce"))
+
+		step, err = Recipe.ParseStep(map[string]string{"hook": "cache"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(step).To(Equal(Recipe.Step{Kind: Recipe.StepKindHook, Name: "cache"}))
+	})
+	It("parses workflows", func() {
+		step, err := Recipe.ParseStep(map[string]string{"workflow": "quiesce-all"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(step).To(Equal(Recipe.Step{Kind: Recipe.StepKindWorkflow, Name: "quiesce-all"}))
+		Expect(step.String()).To(Equal("workflow: quiesce-all"))
+	})
+	It("parses when conditions", func() {
+		step, err := Recipe.ParseStep(map[string]string{"group": "config", "when": "namespace redis"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(step).To(Equal(Recipe.Step{Kind: Recipe.StepKindGroup, Name: "config", When: "namespace redis"}))
+		Expect(step.String()).To(Equal("group: config"))
+	})
+	It("parses timeouts and onError, and returns steps as entries", func() {
+		entry := map[string]string{"hook": "db/quiesce", "when": "namespace redis", "timeout": "300", "onError": "continue"}
+		step, err := Recipe.ParseStep(entry)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(step).To(Equal(Recipe.Step{
+			Kind: Recipe.StepKindHook, Name: "db", Op: "quiesce", When: "namespace redis", Timeout: 300, OnError: "continue",
+		}))
+		Expect(step.Entry()).To(Equal(entry))
+
+		step, err = Recipe.ParseStep(map[string]string{"group": "config", "onError": "fail"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(step.Entry()).To(Equal(map[string]string{"group": "config", "onError": "fail"}))
+	})
+	It("rejects malformed entries", func() {
+		for _, entry := range []map[string]string{
+			{},
+			{"group": "config", "hook": "db/quiesce"},
+			{"grup": "config"},
+			{"group": ""},
+			{"group": "config/op"},
+			{"hook": "/quiesce"},
+			{"hook": "db/"},
+			{"hook": "db/quiesce/now"},
+			{"when": "namespace redis"},
+			{"group": "config", "when": " "},
+			{"group": "config", "timeout": "60"},
+			{"hook": "db/quiesce", "timeout": "1m"},
+			{"hook": "db/quiesce", "timeout": "0"},
+			{"hook": "db/quiesce", "onError": "ignore"},
+			{"parallel": "groups", "onError": "continue"},
+		} {
+			_, err := Recipe.ParseStep(entry)
+			Expect(err).To(HaveOccurred(), "entry %v", entry)
+		}
+	})
+})
+
+var _ = Describe("ParseWhen", func() {
+	It("parses objects with and without namespace and condition", func() {
+		when, err := Recipe.ParseWhen("namespace redis")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(when).To(Equal(Recipe.StepCondition{Kind: "namespace", Name: "redis"}))
+
+		when, err = Recipe.ParseWhen("statefulset db/postgres: {$.spec.replicas} > 1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(when).To(Equal(Recipe.StepCondition{
+			Kind: "statefulset", Namespace: "db", Name: "postgres", Condition: "{$.spec.replicas} > 1",
+		}))
+		Expect(when.String()).To(Equal("statefulset db/postgres: {$.spec.replicas} > 1"))
+
+		when, err = Recipe.ParseWhen("pod web-0")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(when.Object()).To(Equal("pod web-0"))
+	})
+	It("rejects malformed conditions", func() {
+		for _, text := range []string{
+			"",
+			"redis",
+			"namespace",
+			"secret db/creds",
+			"namespace db/redis",
+			"pod /web-0",
+			"pod db/web/0",
+			"statefulset postgres: {$.spec.replicas} >",
+			"statefulset postgres:",
+		} {
+			_, err := Recipe.ParseWhen(text)
+			Expect(err).To(HaveOccurred(), "condition %q", text)
+		}
+	})
+})
+
+var _ = Describe("ValidateRecipe", func() {
+	It("accepts resolvable sequences", func() {
+		recipe := sequenceRecipe(
+			map[string]string{"hook": "db/quiesce"},
+			map[string]string{"hook": "db/ready"},
+			map[string]string{"group": "config"},
+			map[string]string{"group": "data"},
+			map[string]string{"hook": "cache"},
+			map[string]string{"hook": "db/unquiesce"},
+		)
+
+		Expect(Recipe.ValidateRecipe(recipe)).To(BeEmpty())
+	})
+	It("reports unknown groups", func() {
+		recipe := sequenceRecipe(
+			map[string]string{"group": "config"},
+			map[string]string{"group": "confg"},
+		)
+
+		Expect(Recipe.ValidateRecipe(recipe)).To(Equal(field.ErrorList{
+			field.NotFound(stepPath(1).Key("group"), "confg"),
+		}))
+	})
+	It("reports unknown hooks and ops", func() {
+		recipe := sequenceRecipe(
+			map[string]string{"hook": "dv/quiesce"},
+			map[string]string{"hook": "db/quiesc"},
+		)
+
+		Expect(Recipe.ValidateRecipe(recipe)).To(Equal(field.ErrorList{
+			field.NotFound(stepPath(0).Key("hook"), "dv"),
+			field.NotFound(stepPath(1).Key("hook"), "db/quiesc"),
+		}))
+	})
+	It("reports unknown step kinds", func() {
+		recipe := sequenceRecipe(map[string]string{"hooks": "db/quiesce"})
+
+		Expect(Recipe.ValidateRecipe(recipe)).To(Equal(field.ErrorList{
+			field.NotSupported(stepPath(0), "hooks", Recipe.SupportedStepKinds),
+		}))
+	})
+	It("reports entries with several kinds", func() {
+		entry := map[string]string{"group": "config", "hook": "db/quiesce"}
+		errs := Recipe.ValidateRecipe(sequenceRecipe(entry))
+
+		Expect(errs).To(HaveLen(1))
+		Expect(errs[0].Type).To(Equal(field.ErrorTypeInvalid))
+		Expect(errs[0].Field).To(Equal(stepPath(0).String()))
+	})
+	It("requires an op for hooks with several ops", func() {
+		errs := Recipe.ValidateRecipe(sequenceRecipe(map[string]string{"hook": "db"}))
+
+		Expect(errs).To(HaveLen(1))
+		Expect(errs[0].Type).To(Equal(field.ErrorTypeInvalid))
+		Expect(errs[0].Field).To(Equal(stepPath(0).Key("hook").String()))
+	})
+	It("reports checks named like ops", func() {
+		recipe := sequenceRecipe()
+		recipe.Spec.Hooks[0].Chks = append(recipe.Spec.Hooks[0].Chks, &Recipe.Check{Name: "quiesce"})
+
+		Expect(Recipe.ValidateRecipe(recipe)).To(Equal(field.ErrorList{
+			field.Duplicate(field.NewPath("spec", "hooks").Index(0).Child("chks").Index(1).Child("name"), "quiesce"),
+		}))
+	})
+	It("validates resources and commands of scale hooks", func() {
+		recipe := sequenceRecipe()
+		recipe.Spec.Hooks = append(recipe.Spec.Hooks, &Recipe.Hook{
+			Name:           "web",
+			Type:           Recipe.HookTypeScale,
+			SelectResource: "pod",
+			Ops: []*Recipe.Operation{
+				{Name: "stop", Command: Recipe.ScaleDown},
+				{Name: "restart", Command: "restart"},
+			},
+		})
+
+		hookPath := field.NewPath("spec", "hooks").Index(2)
+		Expect(Recipe.ValidateRecipe(recipe)).To(Equal(field.ErrorList{
+			field.NotSupported(hookPath.Child("selectResource"), "pod", Recipe.ScaleSelectResources),
+			field.NotSupported(hookPath.Child("ops").Index(1).Child("command"), "restart", Recipe.ScaleCommands),
+		}))
+	})
+	It("rejects invalid check conditions", func() {
+		recipe := sequenceRecipe()
+		recipe.Spec.Hooks[0].Chks[0].Condition = "{$.status.phase} == Running"
+
+		errs := Recipe.ValidateRecipe(recipe)
+		Expect(errs).To(HaveLen(1))
+		Expect(errs[0].Type).To(Equal(field.ErrorTypeInvalid))
+		Expect(errs[0].Field).To(Equal("spec.hooks[0].chks[0].condition"))
+	})
+	It("reports inverse ops that are not other ops of the hook", func() {
+		recipe := sequenceRecipe()
+		recipe.Spec.Hooks[0].Ops[0].InverseOp = "unquiesce"
+		recipe.Spec.Hooks[0].Ops[1].InverseOp = "thaw"
+		recipe.Spec.Hooks[1].Ops[0].InverseOp = "flush"
+
+		Expect(Recipe.ValidateRecipe(recipe)).To(Equal(field.ErrorList{
+			field.NotFound(field.NewPath("spec", "hooks").Index(0).Child("ops").Index(1).Child("inverseOp"), "thaw"),
+			field.Invalid(field.NewPath("spec", "hooks").Index(1).Child("ops").Index(0).Child("inverseOp"), "flush",
+				"must name another op"),
+		}))
+	})
+	It("rejects invalid when conditions", func() {
+		recipe := sequenceRecipe(
+			map[string]string{"hook": "db/quiesce", "when": "statefulset postgres: {$.spec.replicas} > 1"},
+			map[string]string{"group": "config", "when": "configmap settings"},
+			map[string]string{"group": "data", "when": "namespace ${ns}"},
+		)
+
+		Expect(Recipe.ValidateRecipe(recipe)).To(Equal(field.ErrorList{
+			field.Invalid(stepPath(1).Key("when"), "configmap settings", `unsupported kind "configmap", must be `+
+				`one of ["namespace" "pvc" "pod" "deployment" "statefulset" "replicaset"]`),
+			field.Invalid(stepPath(2).Key("when"), "namespace ${ns}", `parameter "ns" is not declared`),
+		}))
+	})
+	It("rejects invalid timeouts and onError of steps", func() {
+		recipe := sequenceRecipe(
+			map[string]string{"hook": "db/quiesce", "timeout": "300", "onError": "continue"},
+			map[string]string{"group": "config", "timeout": "60"},
+			map[string]string{"hook": "cache", "onError": "ignore"},
+		)
+
+		Expect(Recipe.ValidateRecipe(recipe)).To(Equal(field.ErrorList{
+			field.Invalid(stepPath(1).Key("timeout"), "60", "timeout is only supported for hook steps"),
+			field.Invalid(stepPath(2).Key("onError"), "ignore", `onError must be one of ["fail" "continue"]`),
+		}))
+	})
+	It("rejects invalid retry policies", func() {
+		recipe := sequenceRecipe()
+		recipe.Spec.Hooks[0].RetryOn = &Recipe.RetryOn{StderrRegex: "lock("}
+		recipe.Spec.Hooks[0].Ops[0].Backoff = &Recipe.Backoff{Type: Recipe.BackoffExponential, Delay: 10, MaxDelay: 5}
+		recipe.Spec.Hooks[0].Chks[0].RetryOn = &Recipe.RetryOn{StderrRegex: "timeout"}
+		recipe.Spec.Hooks[0].Chks[0].Backoff = &Recipe.Backoff{Type: Recipe.BackoffExponential}
+		recipe.Spec.Hooks[1].Ops[0].Backoff = &Recipe.Backoff{Type: Recipe.BackoffFixed}
+
+		errs := Recipe.ValidateRecipe(recipe)
+		Expect(errs).To(HaveLen(4))
+		Expect(errs[0].Field).To(Equal("spec.hooks[0].retryOn.stderrRegex"))
+		Expect(errs[1]).To(Equal(field.Invalid(field.NewPath("spec", "hooks").Index(0).Child("ops").Index(0).
+			Child("backoff", "maxDelay"), 5, "must not be less than delay")))
+		Expect(errs[2]).To(Equal(field.Invalid(field.NewPath("spec", "hooks").Index(0).Child("chks").Index(0).
+			Child("backoff", "delay"), 0, "must be positive")))
+		Expect(errs[3]).To(Equal(field.Invalid(field.NewPath("spec", "hooks").Index(1).Child("ops").Index(0).
+			Child("backoff", "delay"), 0, "must be positive")))
+	})
+	It("resolves parallel sets and their steps", func() {
+		recipe := sequenceRecipe(map[string]string{"parallel": "flush"}, map[string]string{"parallel": "other"})
+		recipe.Spec.Workflows[0].Parallel = []*Recipe.ParallelSteps{{
+			Name: "flush",
+			Steps: []map[string]string{
+				{"hook": "cache/flush"},
+				{"group": "unknown"},
+				{"parallel": "flush"},
+			},
+		}}
+
+		stepsPath := field.NewPath("spec", "workflows").Index(0).Child("parallel").Index(0).Child("steps")
+		Expect(Recipe.ValidateRecipe(recipe)).To(Equal(field.ErrorList{
+			field.NotFound(stepPath(1).Key("parallel"), "other"),
+			field.NotFound(stepsPath.Index(1).Key("group"), "unknown"),
+			field.NotSupported(stepsPath.Index(2), "parallel", Recipe.ParallelStepKinds),
+		}))
+	})
+	It("rejects sequences and parallel sets with too many steps", func() {
+		sequence := make([]map[string]string, Recipe.MaxSequenceSteps+1)
+		for i := range sequence {
+			sequence[i] = map[string]string{"group": "config"}
+		}
+		recipe := sequenceRecipe(sequence...)
+		recipe.Spec.Workflows[0].Parallel = []*Recipe.ParallelSteps{{
+			Name:  "flush",
+			Steps: sequence[:Recipe.MaxParallelSteps+1],
+		}}
+
+		workflowPath := field.NewPath("spec", "workflows").Index(0)
+		Expect(Recipe.ValidateRecipe(recipe)).To(Equal(field.ErrorList{
+			field.TooMany(workflowPath.Child("sequence"), Recipe.MaxSequenceSteps+1, Recipe.MaxSequenceSteps),
+			field.TooMany(workflowPath.Child("parallel").Index(0).Child("steps"),
+				Recipe.MaxParallelSteps+1, Recipe.MaxParallelSteps),
+		}))
+	})
+	It("rejects restore-only groups in workflows backing up groups", func() {
+		recipe := sequenceRecipe(map[string]string{"group": "config"}, map[string]string{"group": "config-subset"})
+		recipe.Spec.Groups = append(recipe.Spec.Groups,
+			&Recipe.Group{Name: "config-subset", Type: "resource", BackupRef: "config"})
+
+		errs := Recipe.ValidateRecipe(recipe)
+		Expect(errs).To(HaveLen(1))
+		Expect(errs[0].Type).To(Equal(field.ErrorTypeInvalid))
+		Expect(errs[0].Field).To(Equal(stepPath(1).Key("group").String()))
+
+		recipe.Spec.Workflows[0].Name = Recipe.FailoverWorkflowName
+		Expect(Recipe.ValidateRecipe(recipe)).To(BeEmpty())
+	})
+	It("checks restore-only groups of called workflows against the reserved workflows calling them", func() {
+		recipe := sequenceRecipe(map[string]string{"workflow": "restore-config"})
+		recipe.Spec.Workflows[0].Name = Recipe.RestoreWorkflowName
+		recipe.Spec.Workflows = append(recipe.Spec.Workflows, &Recipe.Workflow{
+			Name: "restore-config", Sequence: []map[string]string{{"group": "config-subset"}},
+		})
+		recipe.Spec.Groups = append(recipe.Spec.Groups,
+			&Recipe.Group{Name: "config-subset", Type: "resource", BackupRef: "config"})
+		Expect(Recipe.ValidateRecipe(recipe)).To(BeEmpty())
+
+		recipe.Spec.Workflows = append(recipe.Spec.Workflows, &Recipe.Workflow{
+			Name: Recipe.CaptureWorkflowName, Sequence: []map[string]string{{"workflow": "restore-config"}},
+		})
+		errs := Recipe.ValidateRecipe(recipe)
+		Expect(errs).To(HaveLen(1))
+		Expect(errs[0].Field).To(Equal(
+			field.NewPath("spec", "workflows").Index(1).Child("sequence").Index(0).Key("group").String()))
+		Expect(errs[0].Detail).To(ContainSubstring(`the capture workflow calling workflow "restore-config"`))
+	})
+	It("rejects group steps in the cleanup workflow", func() {
+		recipe := sequenceRecipe(map[string]string{"hook": "cache/flush"}, map[string]string{"group": "config"})
+		recipe.Spec.Workflows[0].Name = Recipe.CleanupWorkflowName
+
+		errs := Recipe.ValidateRecipe(recipe)
+		Expect(errs).To(HaveLen(1))
+		Expect(errs[0].Type).To(Equal(field.ErrorTypeForbidden))
+		Expect(errs[0].Field).To(Equal(stepPath(1).Key("group").String()))
+	})
+	It("resolves workflow steps", func() {
+		recipe := sequenceRecipe(
+			map[string]string{"workflow": "quiesce-all"},
+			map[string]string{"workflow": "quiesce"},
+			map[string]string{"workflow": Recipe.RestoreWorkflowName},
+		)
+		recipe.Spec.Workflows = append(recipe.Spec.Workflows, &Recipe.Workflow{
+			Name:     "quiesce-all",
+			Sequence: []map[string]string{{"hook": "db/quiesce"}, {"hook": "cache/flush"}},
+		})
+
+		Expect(Recipe.ValidateRecipe(recipe)).To(Equal(field.ErrorList{
+			field.NotFound(stepPath(1).Key("workflow"), "quiesce"),
+			field.Invalid(stepPath(2).Key("workflow"), Recipe.RestoreWorkflowName,
+				"reserved workflows cannot be called"),
+		}))
+	})
+	It("rejects workflows calling themselves", func() {
+		recipe := sequenceRecipe(map[string]string{"workflow": "quiesce-all"})
+		recipe.Spec.Workflows = append(recipe.Spec.Workflows,
+			&Recipe.Workflow{Name: "quiesce-all", Sequence: []map[string]string{{"workflow": "flush-all"}}},
+			&Recipe.Workflow{Name: "flush-all", Sequence: []map[string]string{{"workflow": "quiesce-all"}}},
+		)
+
+		workflowsPath := field.NewPath("spec", "workflows")
+		Expect(Recipe.ValidateRecipe(recipe)).To(Equal(field.ErrorList{
+			field.Invalid(workflowsPath.Index(1).Child("sequence").Index(0).Key("workflow"), "flush-all",
+				`workflow "quiesce-all" calls itself: quiesce-all -> flush-all -> quiesce-all`),
+			field.Invalid(workflowsPath.Index(2).Child("sequence").Index(0).Key("workflow"), "quiesce-all",
+				`workflow "flush-all" calls itself: flush-all -> quiesce-all -> flush-all`),
+		}))
+	})
+	It("rejects calls to workflows with group steps from the cleanup workflow", func() {
+		recipe := sequenceRecipe(map[string]string{"workflow": "flush-all"})
+		recipe.Spec.Workflows[0].Name = Recipe.CleanupWorkflowName
+		recipe.Spec.Workflows = append(recipe.Spec.Workflows,
+			&Recipe.Workflow{Name: "flush-all", Sequence: []map[string]string{{"workflow": "config"}}},
+			&Recipe.Workflow{Name: "config", Sequence: []map[string]string{{"group": "config"}}},
+		)
+
+		errs := Recipe.ValidateRecipe(recipe)
+		Expect(errs).To(HaveLen(1))
+		Expect(errs[0].Type).To(Equal(field.ErrorTypeForbidden))
+		Expect(errs[0].Field).To(Equal(stepPath(0).Key("workflow").String()))
+	})
+})
+
+var _ = Describe("BackupRef validation", func() {
+	var recipe *Recipe.Recipe
+
+	groupPath := func(i int) *field.Path { return field.NewPath("spec", "groups").Index(i) }
+
+	BeforeEach(func() {
+		recipe = sequenceRecipe(map[string]string{"group": "config"})
+		recipe.Spec.Groups = []*Recipe.Group{
+			{
+				Name:                    "config",
+				Type:                    "resource",
+				IncludedNamespaces:      []string{"app", "app-config"},
+				ExcludedNamespaces:      []string{"app-tmp"},
+				IncludedResourceTypes:   []string{"configmaps", "secrets"},
+				LabelSelector:           &metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}},
+				IncludeClusterResources: ptr.To(false),
+			},
+			{Name: "data", Type: "volume"},
+			{
+				Name:                  "config-subset",
+				Type:                  "resource",
+				BackupRef:             "config",
+				IncludedNamespaces:    []string{"app"},
+				IncludedResourceTypes: []string{"configmaps"},
+				LabelSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"app": "db", "tier": "backend"},
+				},
+			},
+		}
+	})
+
+	It("accepts restore groups selecting a subset of the backup", func() {
+		Expect(Recipe.ValidateRecipe(recipe)).To(BeEmpty())
+
+		recipe.Spec.Groups[0].IncludedNamespaces = nil
+		recipe.Spec.Groups[2].IncludedNamespaces = nil
+		recipe.Spec.Groups[2].IncludedResourceTypes = []string{"*"}
+		Expect(Recipe.ValidateRecipe(recipe)).To(BeEmpty())
+	})
+	It("applies groups including no namespaces to the namespace of the recipe", func() {
+		recipe.Spec.Groups[2].IncludedNamespaces = nil
+		Expect(Recipe.ValidateRecipe(recipe)).To(Equal(field.ErrorList{
+			field.Invalid(groupPath(2).Child("includedNamespaces"), []string(nil), `group applies to the namespace `+
+				`"test-ns" of the recipe, which is not included in the backup of group "config"`),
+		}))
+
+		recipe.Spec.Groups[0].IncludedNamespaces = nil
+		recipe.Spec.Groups[2].IncludedNamespaces = []string{"app"}
+		errs := Recipe.ValidateRecipe(recipe)
+		Expect(errs).To(HaveLen(1))
+		Expect(errs[0].Field).To(Equal(groupPath(2).Child("includedNamespaces").Index(0).String()))
+		Expect(errs[0].Detail).To(Equal(`namespace is not included in the backup of group "config", ` +
+			`which includes ["test-ns"]`))
+	})
+	It("warns about selections by label that cannot be verified", func() {
+		Expect(Recipe.RecipeWarnings(recipe)).To(BeEmpty())
+
+		recipe.Spec.Groups[0].IncludedNamespacesByLabel = &metav1.LabelSelector{
+			MatchLabels: map[string]string{"tier": "app"},
+		}
+		recipe.Spec.Groups[2].IncludedNamespaces = []string{"other"}
+		recipe.Spec.Groups[2].LabelSelector.MatchExpressions = []metav1.LabelSelectorRequirement{
+			{Key: "tier", Operator: metav1.LabelSelectorOpIn, Values: []string{"backend"}},
+		}
+		Expect(Recipe.ValidateRecipe(recipe)).To(BeEmpty())
+
+		warnings := Recipe.RecipeWarnings(recipe)
+		Expect(warnings).To(HaveLen(2))
+		Expect(warnings[0].Field).To(Equal(groupPath(2).Child("backupRef").String()))
+		Expect(warnings[0].Detail).To(ContainSubstring(`group "config" selects namespaces by label`))
+		Expect(warnings[1].Field).To(Equal(groupPath(2).Child("labelSelector", "matchExpressions").String()))
+	})
+	It("reports missing backup groups as unresolved", func() {
+		recipe.Spec.Groups[2].BackupRef = "unknown"
+		Expect(Recipe.ValidateRecipe(recipe)).To(Equal(field.ErrorList{
+			field.NotFound(groupPath(2).Child("backupRef"), "unknown"),
+		}))
+	})
+	It("rejects backup groups that cannot be restored from", func() {
+		for _, backupRef := range []string{"config-subset", "data"} {
+			recipe.Spec.Groups[2].BackupRef = backupRef
+
+			errs := Recipe.ValidateRecipe(recipe)
+			Expect(errs).To(HaveLen(1), "backupRef %s", backupRef)
+			Expect(errs[0].Type).To(Equal(field.ErrorTypeInvalid))
+			Expect(errs[0].Field).To(Equal(groupPath(2).Child("backupRef").String()))
+		}
+	})
+	It("rejects backup groups that no workflow backs up", func() {
+		recipe.Spec.Workflows[0].Sequence = []map[string]string{{"group": "data"}}
+
+		errs := Recipe.ValidateRecipe(recipe)
+		Expect(errs).To(HaveLen(1))
+		Expect(errs[0].Detail).To(ContainSubstring(`group "config" is not backed up by any workflow`))
+
+		recipe.Spec.Workflows = append(recipe.Spec.Workflows, &Recipe.Workflow{
+			Name:     Recipe.CaptureWorkflowName,
+			Sequence: []map[string]string{{"group": "config"}},
+		})
+		Expect(Recipe.ValidateRecipe(recipe)).To(BeEmpty())
+	})
+	It("uses the default backup workflow", func() {
+		recipe.Spec.Workflows = nil
+		Expect(Recipe.ValidateRecipe(recipe)).To(BeEmpty())
+	})
+	It("rejects restore selectors outside of the backup", func() {
+		restore := recipe.Spec.Groups[2]
+		restore.IncludedNamespaces = []string{"app", "other", "app-tmp"}
+		restore.IncludedResourceTypes = []string{"pods"}
+		restore.LabelSelector.MatchLabels["app"] = "cache"
+		restore.IncludeClusterResources = ptr.To(true)
+
+		errs := Recipe.ValidateRecipe(recipe)
+		fields := []string{}
+		for _, err := range errs {
+			Expect(err.Type).To(Equal(field.ErrorTypeInvalid))
+			fields = append(fields, err.Field)
+		}
+		Expect(fields).To(Equal([]string{
+			groupPath(2).Child("includedNamespaces").Index(1).String(),
+			groupPath(2).Child("includedNamespaces").Index(2).String(),
+			groupPath(2).Child("includedResourceTypes").Index(0).String(),
+			groupPath(2).Child("labelSelector", "matchLabels").Key("app").String(),
+			groupPath(2).Child("includeClusterResources").String(),
+		}))
+		Expect(errs[1].Detail).To(Equal(`namespace is excluded from the backup of group "config"`))
+	})
+})
+
+var _ = Describe("EffectiveWorkflow", func() {
+	var spec *Recipe.RecipeSpec
+
+	BeforeEach(func() {
+		spec = &Recipe.RecipeSpec{
+			Groups: []*Recipe.Group{
+				{Name: "config", Type: "resource"},
+				{Name: "data", Type: "volume"},
+				{Name: "config-subset", Type: "resource", BackupRef: "config"},
+			},
+		}
+	})
+
+	sequence := func(workflow *Recipe.Workflow) []map[string]string {
+		Expect(workflow).ToNot(BeNil())
+
+		return workflow.Sequence
+	}
+
+	It("returns the workflows of the recipe", func() {
+		spec.Workflows = []*Recipe.Workflow{{Name: "archive"}}
+		Expect(spec.EffectiveWorkflow("archive")).To(BeIdenticalTo(spec.Workflows[0]))
+		Expect(spec.EffectiveWorkflow("unknown")).To(BeNil())
+	})
+	It("backs up all groups except restore-only ones by default", func() {
+		expected := []map[string]string{{"group": "config"}, {"group": "data"}}
+		Expect(sequence(spec.EffectiveWorkflow(Recipe.BackupWorkflowName))).To(Equal(expected))
+		Expect(sequence(spec.EffectiveWorkflow(Recipe.CaptureWorkflowName))).To(Equal(expected))
+	})
+	It("restores groups through the restore-only groups referring to them by default", func() {
+		expected := []map[string]string{{"group": "data"}, {"group": "config-subset"}}
+		for _, name := range []string{
+			Recipe.RestoreWorkflowName, Recipe.RecoverWorkflowName, Recipe.FailoverWorkflowName,
+			Recipe.RelocateWorkflowName,
+		} {
+			workflow := spec.EffectiveWorkflow(name)
+			Expect(sequence(workflow)).To(Equal(expected), "workflow %s", name)
+			Expect(workflow.Name).To(Equal(name))
+		}
+	})
+	It("falls back to the workflows of the recipe", func() {
+		spec.Workflows = []*Recipe.Workflow{{
+			Name: Recipe.RecoverWorkflowName, FailOn: "essential-error", Sequence: []map[string]string{{"group": "data"}},
+		}}
+
+		workflow := spec.EffectiveWorkflow(Recipe.FailoverWorkflowName)
+		Expect(workflow.Name).To(Equal(Recipe.FailoverWorkflowName))
+		Expect(workflow.FailOn).To(Equal("essential-error"))
+		Expect(workflow.Sequence).To(Equal(spec.Workflows[0].Sequence))
+		Expect(spec.Workflows[0].Name).To(Equal(Recipe.RecoverWorkflowName))
+	})
+	It("does nothing in the cleanup workflow by default", func() {
+		Expect(sequence(spec.EffectiveWorkflow(Recipe.CleanupWorkflowName))).To(BeEmpty())
+	})
+	It("reports workflows with group steps, also in called workflows", func() {
+		spec.Workflows = []*Recipe.Workflow{
+			{Name: "archive", Sequence: []map[string]string{{"workflow": "copy"}}},
+			{Name: "copy", Parallel: []*Recipe.ParallelSteps{{
+				Name: "all", Steps: []map[string]string{{"group": "data"}},
+			}}},
+			{Name: "quiesce", Sequence: []map[string]string{{"hook": "db/quiesce"}}},
+		}
+
+		Expect(spec.HasGroupSteps(Recipe.BackupWorkflowName)).To(BeTrue())
+		Expect(spec.HasGroupSteps("archive")).To(BeTrue())
+		Expect(spec.HasGroupSteps("quiesce")).To(BeFalse())
+		Expect(spec.HasGroupSteps(Recipe.CleanupWorkflowName)).To(BeFalse())
+		Expect(spec.HasGroupSteps("unknown")).To(BeFalse())
+	})
+})
@@ -0,0 +1,170 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// log is for logging in this package.
+var recipelog = logf.Log.WithName("recipe-resource")
+
+// SetupWebhookWithManager registers the validating webhook for Recipes with the manager
+func (r *Recipe) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		WithValidator(&recipeValidator{client: mgr.GetClient()}).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-ramendr-openshift-io-v1alpha1-recipe,mutating=false,failurePolicy=fail,sideEffects=None,groups=ramendr.openshift.io,resources=recipes,verbs=create;update,versions=v1alpha1,name=vrecipe.kb.io,admissionReviewVersions=v1
+
+//+kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
+
+// recipeValidator rejects Recipes that fail ValidateRecipe, or import from recipes in other
+// namespaces that the requesting user cannot get
+type recipeValidator struct {
+	client client.Client
+}
+
+var _ webhook.CustomValidator = &recipeValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type
+func (v *recipeValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	recipe, ok := obj.(*Recipe)
+	if !ok {
+		return nil, fmt.Errorf("expected a Recipe but got a %T", obj)
+	}
+
+	recipelog.Info("validate create", "name", recipe.Name)
+
+	authErrs, err := v.authorizeImports(ctx, recipe, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return recipe.warnings(), recipe.validate(authErrs)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type
+func (v *recipeValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	recipe, ok := newObj.(*Recipe)
+	if !ok {
+		return nil, fmt.Errorf("expected a Recipe but got a %T", newObj)
+	}
+
+	recipelog.Info("validate update", "name", recipe.Name)
+
+	oldRecipe, ok := oldObj.(*Recipe)
+	if !ok {
+		return nil, fmt.Errorf("expected a Recipe but got a %T", oldObj)
+	}
+
+	authErrs, err := v.authorizeImports(ctx, recipe, oldRecipe)
+	if err != nil {
+		return nil, err
+	}
+
+	return recipe.warnings(), recipe.validate(authErrs)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type
+func (v *recipeValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// authorizeImports checks with SubjectAccessReviews that the requesting user may get the recipes
+// imported from other namespaces. Imports the old recipe already had are not checked again, so that
+// users who could not have added them can still update the recipe.
+func (v *recipeValidator) authorizeImports(ctx context.Context, recipe, oldRecipe *Recipe,
+) (field.ErrorList, error) {
+	allErrs := field.ErrorList{}
+
+	if v.client == nil {
+		return allErrs, nil
+	}
+
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	extra := map[string]authorizationv1.ExtraValue{}
+	for key, value := range req.UserInfo.Extra {
+		extra[key] = authorizationv1.ExtraValue(value)
+	}
+
+	for i, imp := range recipe.Spec.Imports {
+		if imp == nil || imp.ImportNamespace(recipe.Namespace) == recipe.Namespace {
+			continue
+		}
+
+		namespace := imp.ImportNamespace(recipe.Namespace)
+
+		if oldRecipe != nil && slices.ContainsFunc(oldRecipe.Spec.Imports, func(old *Import) bool {
+			return old != nil && old.Recipe == imp.Recipe && old.ImportNamespace(oldRecipe.Namespace) == namespace
+		}) {
+			continue
+		}
+
+		review := &authorizationv1.SubjectAccessReview{
+			Spec: authorizationv1.SubjectAccessReviewSpec{
+				ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Namespace: namespace,
+					Verb:      "get",
+					Group:     GroupVersion.Group,
+					Version:   GroupVersion.Version,
+					Resource:  "recipes",
+					Name:      imp.Recipe,
+				},
+				User:   req.UserInfo.Username,
+				Groups: req.UserInfo.Groups,
+				UID:    req.UserInfo.UID,
+				Extra:  extra,
+			},
+		}
+
+		if err := v.client.Create(ctx, review); err != nil {
+			return nil, err
+		}
+
+		if !review.Status.Allowed {
+			allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "imports").Index(i),
+				fmt.Sprintf("user %q cannot get recipe %s/%s", req.UserInfo.Username, namespace, imp.Recipe)))
+		}
+	}
+
+	return allErrs, nil
+}
+
+func (r *Recipe) validate(authErrs field.ErrorList) error {
+	allErrs := append(ValidateRecipe(r), authErrs...)
+	if len(allErrs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(GroupVersion.WithKind("Recipe").GroupKind(), r.Name, allErrs)
+}
+
+func (r *Recipe) warnings() admission.Warnings {
+	var warnings admission.Warnings
+
+	for _, warning := range RecipeWarnings(r) {
+		warnings = append(warnings, warning.Error())
+	}
+
+	return warnings
+}
@@ -0,0 +1,180 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RecipeRunSpec defines the workflow to run
+// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="spec is immutable"
+type RecipeRunSpec struct {
+	// Name of the Recipe, in the namespace of the RecipeRun
+	// +kubebuilder:validation:MinLength=1
+	Recipe string `json:"recipe"`
+	// Name of the workflow of the Recipe to run
+	// +kubebuilder:validation:MinLength=1
+	Workflow string `json:"workflow"`
+	// Values of the parameters of the Recipe, by parameter name
+	//+optional
+	Parameters map[string]string `json:"parameters,omitempty"`
+}
+
+// RecipeRunPhase is the state of a run
+// +kubebuilder:validation:Enum=Running;Succeeded;Failed
+type RecipeRunPhase string
+
+const (
+	// RecipeRunRunning is set while the workflow runs
+	RecipeRunRunning RecipeRunPhase = "Running"
+	// RecipeRunSucceeded is set when the workflow completed without failing under its FailOn
+	RecipeRunSucceeded RecipeRunPhase = "Succeeded"
+	// RecipeRunFailed is set when the workflow failed under its FailOn, or could not be run
+	RecipeRunFailed RecipeRunPhase = "Failed"
+)
+
+const (
+	// MaxRunOutputBytes is the amount of stdout and stderr of each target kept in the status
+	MaxRunOutputBytes = 1024
+	// MaxRunTargets is the number of targets of each step kept in the status
+	MaxRunTargets = 50
+)
+
+// RecipeRunStatus records the execution of the workflow
+type RecipeRunStatus struct {
+	// Phase of the run
+	//+optional
+	Phase RecipeRunPhase `json:"phase,omitempty"`
+	// Message explains why the run failed
+	//+optional
+	Message string `json:"message,omitempty"`
+	// Generation of the Recipe that was run
+	//+optional
+	RecipeGeneration int64 `json:"recipeGeneration,omitempty"`
+	// Generation of the RecipeTemplate the Recipe was rendered from, if it references one
+	//+optional
+	TemplateGeneration int64 `json:"templateGeneration,omitempty"`
+	// Effective FailOn of the workflow
+	//+optional
+	FailOn string `json:"failOn,omitempty"`
+	// Time the run started
+	//+optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// Time the run completed
+	//+optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// Steps that were run or skipped, in order. Steps after the one that stopped the workflow are not included.
+	//+optional
+	Steps []StepRecord `json:"steps,omitempty"`
+	// Inverse operations run after the workflow failed, in order
+	//+optional
+	Rollback []StepRecord `json:"rollback,omitempty"`
+}
+
+// StepRecord is the timeline entry of a step of a run
+type StepRecord struct {
+	// Index of the step in the sequence of the workflow. Steps of called workflows have the index of
+	// the step calling them.
+	Index int `json:"index"`
+	// Step in sequence notation, e.g. "hook: db/quiesce"
+	Step string `json:"step"`
+	// Name of the parallel set the step ran in, if any
+	//+optional
+	Parallel string `json:"parallel,omitempty"`
+	// Name of the called workflow the step ran in, if any, with the names of the workflows calling it
+	// separated by "/"
+	//+optional
+	Workflow string `json:"workflow,omitempty"`
+	// Whether the group or hook of the step is essential
+	Essential bool `json:"essential"`
+	// Outcome of the step: Succeeded, Failed, Ignored for failures of steps continuing on error, or
+	// Skipped for steps whose when condition does not hold
+	Outcome string `json:"outcome"`
+	// Error of failed and ignored steps, or the reason skipped steps were skipped
+	//+optional
+	Message string `json:"message,omitempty"`
+	// Time the step started
+	StartTime metav1.Time `json:"startTime"`
+	// Time the step completed
+	EndTime metav1.Time `json:"endTime"`
+	// Objects the hook operation or check of the step ran on, at most MaxRunTargets
+	//+optional
+	Targets []TargetRecord `json:"targets,omitempty"`
+	// Set if the step ran on more targets than recorded
+	//+optional
+	TargetsTruncated bool `json:"targetsTruncated,omitempty"`
+	// Attempts of the hook operation or check of the step, if its retry policy allows retries. The
+	// targets of the last attempt are the ones of the step.
+	//+optional
+	Attempts []AttemptRecord `json:"attempts,omitempty"`
+}
+
+// AttemptRecord is the timeline entry of an attempt of a hook operation or check
+type AttemptRecord struct {
+	// Time the attempt started
+	StartTime metav1.Time `json:"startTime"`
+	// Time the attempt completed
+	EndTime metav1.Time `json:"endTime"`
+	// Error of a failed attempt
+	//+optional
+	Message string `json:"message,omitempty"`
+}
+
+// TargetRecord records running a hook operation or check on one object
+type TargetRecord struct {
+	// Kind of the object, e.g. pod
+	Kind string `json:"kind"`
+	// Namespace of the object
+	Namespace string `json:"namespace"`
+	// Name of the object
+	Name string `json:"name"`
+	// Container the command ran in, for exec hooks
+	//+optional
+	Container string `json:"container,omitempty"`
+	// Exit code of the command, for exec hooks
+	//+optional
+	ExitCode *int `json:"exitCode,omitempty"`
+	// Stdout of the command, truncated to MaxRunOutputBytes
+	//+optional
+	Stdout string `json:"stdout,omitempty"`
+	// Stderr of the command, truncated to MaxRunOutputBytes
+	//+optional
+	Stderr string `json:"stderr,omitempty"`
+	// What was done to the object, for hooks other than exec hooks
+	//+optional
+	Message string `json:"message,omitempty"`
+	// Error if the operation or check failed on this object
+	//+optional
+	Error string `json:"error,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Recipe",type=string,JSONPath=`.spec.recipe`
+//+kubebuilder:printcolumn:name="Workflow",type=string,JSONPath=`.spec.workflow`
+//+kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// RecipeRun is the Schema for the reciperuns API. Creating a RecipeRun runs a workflow of a Recipe
+// once; its status is the record of the run.
+type RecipeRun struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RecipeRunSpec   `json:"spec,omitempty"`
+	Status RecipeRunStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// RecipeRunList contains a list of RecipeRun
+type RecipeRunList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RecipeRun `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&RecipeRun{}, &RecipeRunList{})
+}
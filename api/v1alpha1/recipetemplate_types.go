@@ -0,0 +1,47 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RecipeTemplateSpec is a recipe spec that recipes instantiate by referencing the template
+// +kubebuilder:validation:XValidation:rule="!has(self.template)",message="templates cannot reference templates"
+type RecipeTemplateSpec struct {
+	// Description of what the template protects and how, e.g. "quiesces PostgreSQL during backups"
+	//+optional
+	Description string `json:"description,omitempty"`
+
+	// The groups, hooks, workflows and parameters of the template. Parameters without default need
+	// values from the recipes referencing the template.
+	RecipeSpec `json:",inline"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:printcolumn:name="Description",type=string,JSONPath=`.spec.description`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// RecipeTemplate is the Schema for the recipetemplates API. Templates are published cluster-wide,
+// e.g. by a platform team, and instantiated by Recipes referencing them.
+type RecipeTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec RecipeTemplateSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// RecipeTemplateList contains a list of RecipeTemplate
+type RecipeTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RecipeTemplate `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&RecipeTemplate{}, &RecipeTemplateList{})
+}
@@ -0,0 +1,77 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+var recipetemplatelog = logf.Log.WithName("recipetemplate-resource")
+
+// SetupWebhookWithManager registers the validating webhook for RecipeTemplates with the manager
+func (r *RecipeTemplate) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		WithValidator(&recipeTemplateValidator{}).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-ramendr-openshift-io-v1alpha1-recipetemplate,mutating=false,failurePolicy=fail,sideEffects=None,groups=ramendr.openshift.io,resources=recipetemplates,verbs=create;update,versions=v1alpha1,name=vrecipetemplate.kb.io,admissionReviewVersions=v1
+
+// recipeTemplateValidator rejects RecipeTemplates whose spec fails ValidateRecipe
+type recipeTemplateValidator struct{}
+
+var _ webhook.CustomValidator = &recipeTemplateValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type
+func (v *recipeTemplateValidator) ValidateCreate(ctx context.Context, obj runtime.Object,
+) (admission.Warnings, error) {
+	template, ok := obj.(*RecipeTemplate)
+	if !ok {
+		return nil, fmt.Errorf("expected a RecipeTemplate but got a %T", obj)
+	}
+
+	recipetemplatelog.Info("validate create", "name", template.Name)
+
+	return template.validate()
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type
+func (v *recipeTemplateValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object,
+) (admission.Warnings, error) {
+	template, ok := newObj.(*RecipeTemplate)
+	if !ok {
+		return nil, fmt.Errorf("expected a RecipeTemplate but got a %T", newObj)
+	}
+
+	recipetemplatelog.Info("validate update", "name", template.Name)
+
+	return template.validate()
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type
+func (v *recipeTemplateValidator) ValidateDelete(ctx context.Context, obj runtime.Object,
+) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validate validates the spec of the template as the spec of a recipe, which has the same paths
+func (r *RecipeTemplate) validate() (admission.Warnings, error) {
+	recipe := &Recipe{ObjectMeta: r.ObjectMeta, Spec: r.Spec.RecipeSpec}
+
+	allErrs := ValidateRecipe(recipe)
+	if len(allErrs) == 0 {
+		return recipe.warnings(), nil
+	}
+
+	return recipe.warnings(), apierrors.NewInvalid(GroupVersion.WithKind("RecipeTemplate").GroupKind(), r.Name, allErrs)
+}
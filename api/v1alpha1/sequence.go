@@ -0,0 +1,302 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package v1alpha1
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// StepKindGroup refers to a group by name: "group: <group name>"
+	StepKindGroup string = "group"
+	// StepKindHook refers to an operation or check of a hook: "hook: <hook name>[/<op or check name>]"
+	StepKindHook string = "hook"
+	// StepKindParallel refers to a set of steps of the workflow running concurrently:
+	// "parallel: <name of the set>"
+	StepKindParallel string = "parallel"
+	// StepKindWorkflow calls another workflow of the recipe, running its steps as part of the calling
+	// workflow: "workflow: <workflow name>"
+	StepKindWorkflow string = "workflow"
+)
+
+const (
+	// StepKeyWhen is the optional key of a Workflow.Sequence entry holding the condition of the step:
+	// "when: <kind> [<namespace>/]<name>[: <condition>]". See ParseWhen.
+	StepKeyWhen string = "when"
+	// StepKeyTimeout is the optional key of a hook step overriding the timeout of its operation or
+	// check: "timeout: <seconds>"
+	StepKeyTimeout string = "timeout"
+	// StepKeyOnError is the optional key of a group or hook step overriding how its failure is
+	// handled: "onError: <fail|continue>"
+	StepKeyOnError string = "onError"
+)
+
+// StepOptionKeys lists the optional keys of a Workflow.Sequence entry besides the kind of the step
+var StepOptionKeys = []string{StepKeyWhen, StepKeyTimeout, StepKeyOnError}
+
+// StepOnErrorValues lists the values of the onError key of a step
+var StepOnErrorValues = []string{"fail", "continue"}
+
+// SupportedStepKinds lists the keys accepted in a Workflow.Sequence entry
+var SupportedStepKinds = []string{StepKindGroup, StepKindHook, StepKindParallel, StepKindWorkflow}
+
+// ParallelStepKinds lists the keys accepted in the steps of a parallel set
+var ParallelStepKinds = []string{StepKindGroup, StepKindHook}
+
+// Step is the parsed form of a single Workflow.Sequence entry
+// +kubebuilder:object:generate=false
+type Step struct {
+	// Kind of the step, one of SupportedStepKinds
+	Kind string
+	// Name of the referenced group, hook, parallel set or workflow
+	Name string
+	// Name of the referenced hook operation or check. Empty for groups, and for hooks referenced
+	// without an op.
+	Op string
+	// When is the condition of the step, if any. The step is skipped unless it holds.
+	When string
+	// Timeout of a hook step in seconds, overriding the one of its operation or check. 0 if not set.
+	Timeout int
+	// OnError of a group or hook step, overriding the one of its hook, operation or check. Empty if
+	// not set.
+	OnError string
+}
+
+// String returns the step in sequence notation, e.g. "hook: db/quiesce"
+func (s Step) String() string {
+	if s.Op == "" {
+		return fmt.Sprintf("%s: %s", s.Kind, s.Name)
+	}
+
+	return fmt.Sprintf("%s: %s/%s", s.Kind, s.Name, s.Op)
+}
+
+// Entry returns the step in the form of a Workflow.Sequence entry, the inverse of ParseStep
+func (s Step) Entry() map[string]string {
+	value := s.Name
+	if s.Op != "" {
+		value += "/" + s.Op
+	}
+
+	entry := map[string]string{s.Kind: value}
+
+	if s.When != "" {
+		entry[StepKeyWhen] = s.When
+	}
+
+	if s.Timeout != 0 {
+		entry[StepKeyTimeout] = strconv.Itoa(s.Timeout)
+	}
+
+	if s.OnError != "" {
+		entry[StepKeyOnError] = s.OnError
+	}
+
+	return entry
+}
+
+// ParseStep parses a Workflow.Sequence entry. The entry must hold exactly one key besides
+// StepOptionKeys, which is the kind of the step.
+func ParseStep(entry map[string]string) (Step, error) {
+	kind, err := stepKind(entry)
+	if err != nil {
+		return Step{}, err
+	}
+
+	step, err := parseStep(kind, entry[kind])
+	if err != nil {
+		return Step{}, err
+	}
+
+	for _, key := range StepOptionKeys {
+		if value, ok := entry[key]; ok {
+			if err := step.setOption(key, value); err != nil {
+				return Step{}, err
+			}
+		}
+	}
+
+	return step, nil
+}
+
+// stepKind returns the key of a Workflow.Sequence entry that is not one of StepOptionKeys
+func stepKind(entry map[string]string) (string, error) {
+	kinds := []string{}
+
+	for key := range entry {
+		if !slices.Contains(StepOptionKeys, key) {
+			kinds = append(kinds, key)
+		}
+	}
+
+	if len(kinds) != 1 {
+		return "", fmt.Errorf("must contain exactly one key of %q besides %q, found %d",
+			SupportedStepKinds, StepOptionKeys, len(kinds))
+	}
+
+	return kinds[0], nil
+}
+
+// setOption sets the option of the step that key of StepOptionKeys holds
+func (s *Step) setOption(key, value string) error {
+	switch key {
+	case StepKeyWhen:
+		if strings.TrimSpace(value) == "" {
+			return fmt.Errorf("%s must not be empty", StepKeyWhen)
+		}
+
+		s.When = value
+	case StepKeyTimeout:
+		if s.Kind != StepKindHook {
+			return fmt.Errorf("%s is only supported for %s steps", StepKeyTimeout, StepKindHook)
+		}
+
+		timeout, err := strconv.Atoi(value)
+		if err != nil || timeout <= 0 {
+			return fmt.Errorf("%s must be a positive number of seconds", StepKeyTimeout)
+		}
+
+		s.Timeout = timeout
+	case StepKeyOnError:
+		if s.Kind != StepKindHook && s.Kind != StepKindGroup {
+			return fmt.Errorf("%s is only supported for %s and %s steps", StepKeyOnError, StepKindGroup, StepKindHook)
+		}
+
+		if !slices.Contains(StepOnErrorValues, value) {
+			return fmt.Errorf("%s must be one of %q", StepKeyOnError, StepOnErrorValues)
+		}
+
+		s.OnError = value
+	}
+
+	return nil
+}
+
+func parseStep(kind, value string) (Step, error) {
+	switch kind {
+	case StepKindGroup, StepKindParallel, StepKindWorkflow:
+		if value == "" {
+			return Step{}, fmt.Errorf("%s name must not be empty", kind)
+		}
+
+		if strings.Contains(value, "/") {
+			return Step{}, fmt.Errorf("%s reference %q must not contain an op", kind, value)
+		}
+
+		return Step{Kind: kind, Name: value}, nil
+	case StepKindHook:
+		name, op, hasOp := strings.Cut(value, "/")
+		if name == "" {
+			return Step{}, fmt.Errorf("hook name must not be empty")
+		}
+
+		if hasOp && (op == "" || strings.Contains(op, "/")) {
+			return Step{}, fmt.Errorf("hook reference %q must have the format <hook name>[/<op name>]", value)
+		}
+
+		return Step{Kind: kind, Name: name, Op: op}, nil
+	default:
+		return Step{}, fmt.Errorf("unsupported step kind %q, must be one of %q", kind, SupportedStepKinds)
+	}
+}
+
+// FindGroup returns the group with the given name, or nil if there is none
+func (s *RecipeSpec) FindGroup(name string) *Group {
+	for _, group := range s.Groups {
+		if group != nil && group.Name == name {
+			return group
+		}
+	}
+
+	return nil
+}
+
+// FindHook returns the hook with the given name, or nil if there is none
+func (s *RecipeSpec) FindHook(name string) *Hook {
+	for _, hook := range s.Hooks {
+		if hook != nil && hook.Name == name {
+			return hook
+		}
+	}
+
+	return nil
+}
+
+// FindWorkflow returns the workflow with the given name, or nil if there is none
+func (s *RecipeSpec) FindWorkflow(name string) *Workflow {
+	for _, workflow := range s.Workflows {
+		if workflow != nil && workflow.Name == name {
+			return workflow
+		}
+	}
+
+	return nil
+}
+
+// FindParallel returns the parallel set with the given name, or nil if there is none
+func (w *Workflow) FindParallel(name string) *ParallelSteps {
+	for _, parallel := range w.Parallel {
+		if parallel != nil && parallel.Name == name {
+			return parallel
+		}
+	}
+
+	return nil
+}
+
+// EffectiveMaxParallel returns MaxParallel, defaulting to DefaultMaxParallel
+func (p *ParallelSteps) EffectiveMaxParallel() int {
+	if p.MaxParallel <= 0 {
+		return DefaultMaxParallel
+	}
+
+	return p.MaxParallel
+}
+
+// FindOp returns the operation with the given name, or nil if there is none
+func (h *Hook) FindOp(name string) *Operation {
+	for _, op := range h.Ops {
+		if op != nil && op.Name == name {
+			return op
+		}
+	}
+
+	return nil
+}
+
+// FindCheck returns the check with the given name, or nil if there is none
+func (h *Hook) FindCheck(name string) *Check {
+	for _, chk := range h.Chks {
+		if chk != nil && chk.Name == name {
+			return chk
+		}
+	}
+
+	return nil
+}
+
+// ResolveOp returns the name of the operation or check that a hook step invokes. A step without an
+// op is only valid for hooks that declare exactly one operation or check.
+func (h *Hook) ResolveOp(op string) (string, error) {
+	if op != "" {
+		if h.FindOp(op) == nil && h.FindCheck(op) == nil {
+			return "", fmt.Errorf("hook %q has no op or check named %q", h.Name, op)
+		}
+
+		return op, nil
+	}
+
+	switch {
+	case len(h.Ops) == 1 && len(h.Chks) == 0:
+		return h.Ops[0].Name, nil
+	case len(h.Ops) == 0 && len(h.Chks) == 1:
+		return h.Chks[0].Name, nil
+	default:
+		return "", fmt.Errorf("hook %q declares %d ops and %d checks, step must name one of them",
+			h.Name, len(h.Ops), len(h.Chks))
+	}
+}
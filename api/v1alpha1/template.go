@@ -0,0 +1,93 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package v1alpha1
+
+import (
+	"slices"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// RenderRecipe returns the recipe instantiating a template: a copy of the recipe with the spec of
+// the template, where the groups, hooks, workflows and parameters of the recipe are added to the
+// ones of the template, replacing the ones of the same name, and the volumes of the recipe replace
+// the ones of the template if set. The imports of the recipe are added to the ones of the template,
+// which import from the namespace of the recipe unless they name one. The values of the template
+// reference become the defaults of their parameters, so that parameters are expanded as in any
+// recipe. The copy references no template.
+//
+// The errors report values of parameters the rendered recipe does not declare, and values not
+// matching the type of their parameter.
+func RenderRecipe(recipe *Recipe, template *RecipeTemplate) (*Recipe, field.ErrorList) {
+	rendered := recipe.DeepCopy()
+	spec := template.Spec.RecipeSpec.DeepCopy()
+
+	spec.AppType = recipe.Spec.AppType
+	spec.Groups = mergeByName(spec.Groups, rendered.Spec.Groups, func(g *Group) string { return g.Name })
+	spec.Hooks = mergeByName(spec.Hooks, rendered.Spec.Hooks, func(h *Hook) string { return h.Name })
+	spec.Workflows = mergeByName(spec.Workflows, rendered.Spec.Workflows, func(w *Workflow) string { return w.Name })
+	spec.Parameters = mergeByName(spec.Parameters, rendered.Spec.Parameters, func(p *Parameter) string { return p.Name })
+
+	spec.Imports = append(spec.Imports, rendered.Spec.Imports...)
+
+	if rendered.Spec.Volumes != nil {
+		spec.Volumes = rendered.Spec.Volumes
+	}
+
+	allErrs := field.ErrorList{}
+
+	if recipe.Spec.Template != nil {
+		valuesPath := field.NewPath("spec", "template", "values")
+
+		names := make([]string, 0, len(recipe.Spec.Template.Values))
+		for name := range recipe.Spec.Template.Values {
+			names = append(names, name)
+		}
+
+		slices.Sort(names)
+
+		for _, name := range names {
+			value := recipe.Spec.Template.Values[name]
+
+			parameter := spec.FindParameter(name)
+			if parameter == nil {
+				allErrs = append(allErrs, field.Invalid(valuesPath.Key(name), value,
+					"the template and the recipe declare no parameter of this name"))
+
+				continue
+			}
+
+			if err := parameter.ValidateValue(value); err != nil {
+				allErrs = append(allErrs, field.Invalid(valuesPath.Key(name), value, err.Error()))
+
+				continue
+			}
+
+			parameter.Default = &value
+		}
+	}
+
+	spec.Template = nil
+	rendered.Spec = *spec
+
+	return rendered, allErrs
+}
+
+// mergeByName adds items to base, replacing the items of base with the same name
+func mergeByName[T any](base, items []*T, name func(*T) string) []*T {
+	for _, item := range items {
+		if item == nil {
+			continue
+		}
+
+		i := slices.IndexFunc(base, func(b *T) bool { return b != nil && name(b) == name(item) })
+		if i == -1 {
+			base = append(base, item)
+		} else {
+			base[i] = item
+		}
+	}
+
+	return base
+}
@@ -0,0 +1,129 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package v1alpha1_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
+
+	Recipe "github.com/ramendr/recipe/api/v1alpha1"
+)
+
+func recipeTemplate() *Recipe.RecipeTemplate {
+	return &Recipe.RecipeTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "postgres-quiesce"},
+		Spec: Recipe.RecipeTemplateSpec{
+			Description: "quiesces PostgreSQL during backups",
+			RecipeSpec:  parameterRecipe().Spec,
+		},
+	}
+}
+
+func templatedRecipe() *Recipe.Recipe {
+	return &Recipe.Recipe{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-recipe", Namespace: "test-ns"},
+		Spec: Recipe.RecipeSpec{
+			AppType: "postgres",
+			Template: &Recipe.TemplateReference{
+				Name:   "postgres-quiesce",
+				Values: map[string]string{"namespace": "tenant-1", "replicas": "3"},
+			},
+		},
+	}
+}
+
+var _ = Describe("RenderRecipe", func() {
+	It("copies the template and turns values into defaults", func() {
+		recipe := templatedRecipe()
+
+		rendered, errs := Recipe.RenderRecipe(recipe, recipeTemplate())
+		Expect(errs).To(BeEmpty())
+		Expect(rendered.Spec.Template).To(BeNil())
+		Expect(rendered.Spec.AppType).To(Equal("postgres"))
+		Expect(rendered.Spec.Groups).To(HaveLen(1))
+		Expect(rendered.Spec.Hooks).To(HaveLen(1))
+		Expect(rendered.Spec.FindParameter("namespace").Default).To(Equal(ptr.To("tenant-1")))
+		Expect(rendered.Spec.FindParameter("replicas").Default).To(Equal(ptr.To("3")))
+		Expect(Recipe.ValidateRecipe(rendered)).To(BeEmpty())
+
+		expanded, errs := Recipe.ExpandParameters(rendered, nil)
+		Expect(errs).To(BeEmpty())
+		Expect(expanded.Spec.Hooks[0].Namespace).To(Equal("tenant-1"))
+		Expect(expanded.Spec.Hooks[0].Ops[0].Command).To(ContainSubstring("--replicas 3"))
+
+		Expect(recipe).To(Equal(templatedRecipe()))
+	})
+	It("overrides items of the template with items of the recipe of the same name", func() {
+		recipe := templatedRecipe()
+		recipe.Spec.Groups = []*Recipe.Group{
+			{Name: "config", Type: "resource", IncludedNamespaces: []string{"other"}},
+			{Name: "secrets", Type: "resource", IncludedResourceTypes: []string{"secrets"}},
+		}
+		recipe.Spec.Volumes = &Recipe.Group{Name: "volumes", Type: "volume"}
+		recipe.Spec.Parameters = []*Recipe.Parameter{{Name: "app", Default: ptr.To("pg")}}
+
+		rendered, errs := Recipe.RenderRecipe(recipe, recipeTemplate())
+		Expect(errs).To(BeEmpty())
+
+		Expect(rendered.Spec.Groups).To(HaveLen(2))
+		Expect(rendered.Spec.Groups[0].IncludedNamespaces).To(Equal([]string{"other"}))
+		Expect(rendered.Spec.Groups[1].Name).To(Equal("secrets"))
+		Expect(rendered.Spec.Volumes).To(Equal(recipe.Spec.Volumes))
+		Expect(rendered.Spec.Parameters).To(HaveLen(4))
+		Expect(rendered.Spec.FindParameter("app").Default).To(Equal(ptr.To("pg")))
+	})
+	It("reports undeclared and mistyped values", func() {
+		recipe := templatedRecipe()
+		recipe.Spec.Template.Values["replicas"] = "many"
+		recipe.Spec.Template.Values["unknown"] = "x"
+
+		_, errs := Recipe.RenderRecipe(recipe, recipeTemplate())
+
+		valuesPath := field.NewPath("spec", "template", "values")
+		Expect(errs).To(HaveLen(2))
+		Expect(errs[0].Field).To(Equal(valuesPath.Key("replicas").String()))
+		Expect(errs[1].Field).To(Equal(valuesPath.Key("unknown").String()))
+	})
+	It("leaves references of templated recipes to the template to their rendering", func() {
+		recipe := templatedRecipe()
+		recipe.Spec.Workflows = []*Recipe.Workflow{{
+			Name:     "backup",
+			Sequence: []map[string]string{{"hook": "db/dump"}},
+		}}
+
+		Expect(Recipe.ValidateRecipe(recipe)).To(BeEmpty())
+
+		rendered, errs := Recipe.RenderRecipe(recipe, recipeTemplate())
+		Expect(errs).To(BeEmpty())
+		Expect(Recipe.ValidateRecipe(rendered)).To(BeEmpty())
+
+		recipe.Spec.Workflows[0].Sequence[0]["hook"] = "db/missing"
+		rendered, _ = Recipe.RenderRecipe(recipe, recipeTemplate())
+		Expect(Recipe.ValidateRecipe(rendered)).NotTo(BeEmpty())
+	})
+	It("validates the fields of templated recipes", func() {
+		recipe := templatedRecipe()
+		recipe.Spec.Parameters = []*Recipe.Parameter{
+			{Name: "timeout", Type: Recipe.ParameterTypeInteger, Default: ptr.To("soon")},
+		}
+		recipe.Spec.Hooks = []*Recipe.Hook{{
+			Name: "cache", Type: "exec", Namespace: "${namespace}",
+			Ops: []*Recipe.Operation{{Name: "flush", Command: "/bin/flush", InverseOp: "missing"}},
+		}}
+		recipe.Spec.Workflows = []*Recipe.Workflow{{
+			Name:     "backup",
+			Sequence: []map[string]string{{"hook": "cache/flush"}, {"group": "config"}, {"unknown": "x"}},
+		}}
+
+		errs := Recipe.ValidateRecipe(recipe)
+		Expect(errs).To(HaveLen(3))
+		Expect(errs[0].Field).To(Equal("spec.hooks[0].ops[0].inverseOp"))
+		Expect(errs[1].Field).To(Equal("spec.workflows[0].sequence[2]"))
+		Expect(errs[2].Field).To(Equal("spec.parameters[0].default"))
+	})
+})
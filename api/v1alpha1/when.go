@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package v1alpha1
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ramendr/recipe/api/condition"
+)
+
+// WhenKinds lists the kinds of objects the conditions of steps can refer to
+var WhenKinds = []string{"namespace", "pvc", "pod", "deployment", "statefulset", "replicaset"}
+
+// StepCondition is the parsed form of the when condition of a step. It holds if the object exists
+// and, if a condition is given, the condition is true for the object.
+// +kubebuilder:object:generate=false
+type StepCondition struct {
+	// Kind of the object, one of WhenKinds
+	Kind string
+	// Namespace of the object. Empty for namespaces, and for objects in the namespace of the recipe.
+	Namespace string
+	// Name of the object
+	Name string
+	// Condition on the object, in the expression language of Check.Condition. Empty if the object
+	// only has to exist.
+	Condition string
+}
+
+// Object returns the object the condition refers to as <kind> [<namespace>/]<name>
+func (c StepCondition) Object() string {
+	if c.Namespace == "" {
+		return c.Kind + " " + c.Name
+	}
+
+	return c.Kind + " " + c.Namespace + "/" + c.Name
+}
+
+// String returns the condition in sequence notation, e.g. "statefulset db/pg: {$.spec.replicas} > 1"
+func (c StepCondition) String() string {
+	if c.Condition == "" {
+		return c.Object()
+	}
+
+	return c.Object() + ": " + c.Condition
+}
+
+// ParseWhen parses the when condition of a step, which has the form
+// <kind> [<namespace>/]<name>[: <condition>], e.g. "namespace redis" or
+// "statefulset db/postgres: {$.spec.replicas} > 1". Objects without namespace are looked up in the
+// namespace of the recipe.
+func ParseWhen(text string) (StepCondition, error) {
+	object, expression, hasCondition := strings.Cut(text, ":")
+
+	fields := strings.Fields(object)
+	if len(fields) != 2 {
+		return StepCondition{}, fmt.Errorf("must have the format <kind> [<namespace>/]<name>[: <condition>]")
+	}
+
+	when := StepCondition{Kind: fields[0], Name: fields[1]}
+
+	if !slices.Contains(WhenKinds, when.Kind) {
+		return StepCondition{}, fmt.Errorf("unsupported kind %q, must be one of %q", when.Kind, WhenKinds)
+	}
+
+	if namespace, name, namespaced := strings.Cut(when.Name, "/"); namespaced {
+		switch {
+		case when.Kind == "namespace":
+			return StepCondition{}, fmt.Errorf("namespace %q must not have a namespace", when.Name)
+		case namespace == "" || name == "" || strings.Contains(name, "/"):
+			return StepCondition{}, fmt.Errorf("object %q must have the format [<namespace>/]<name>", when.Name)
+		}
+
+		when.Namespace, when.Name = namespace, name
+	}
+
+	if hasCondition {
+		when.Condition = strings.TrimSpace(expression)
+
+		if _, err := condition.Parse(when.Condition); err != nil {
+			return StepCondition{}, fmt.Errorf("invalid condition: %w", err)
+		}
+	}
+
+	return when, nil
+}
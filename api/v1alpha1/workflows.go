@@ -0,0 +1,123 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package v1alpha1
+
+// ReservedWorkflowNames lists the workflow names that have a defined behavior
+var ReservedWorkflowNames = []string{
+	BackupWorkflowName, RestoreWorkflowName, CaptureWorkflowName, RecoverWorkflowName,
+	FailoverWorkflowName, RelocateWorkflowName, CleanupWorkflowName,
+}
+
+// reservedWorkflow is the behavior of a reserved workflow name
+// +kubebuilder:object:generate=false
+type reservedWorkflow struct {
+	// restores is whether group steps restore groups, as opposed to backing them up
+	restores bool
+	// groups is whether the workflow may have group steps
+	groups bool
+	// fallback is the workflow run when the recipe omits this one. If empty, the default sequence
+	// of the workflow is run.
+	fallback string
+}
+
+var reservedWorkflows = map[string]reservedWorkflow{
+	BackupWorkflowName:   {groups: true},
+	RestoreWorkflowName:  {groups: true, restores: true},
+	CaptureWorkflowName:  {groups: true, fallback: BackupWorkflowName},
+	RecoverWorkflowName:  {groups: true, restores: true, fallback: RestoreWorkflowName},
+	FailoverWorkflowName: {groups: true, restores: true, fallback: RecoverWorkflowName},
+	RelocateWorkflowName: {groups: true, restores: true, fallback: RecoverWorkflowName},
+	CleanupWorkflowName:  {},
+}
+
+// IsReservedWorkflowName reports whether a workflow name is one of ReservedWorkflowNames
+func IsReservedWorkflowName(name string) bool {
+	_, ok := reservedWorkflows[name]
+
+	return ok
+}
+
+// IsRestoreWorkflow reports whether the group steps of the named workflow restore groups. Group
+// steps of all other workflows back up groups.
+func IsRestoreWorkflow(name string) bool {
+	return reservedWorkflows[name].restores
+}
+
+// allowsGroups reports whether the named workflow may have group steps. Workflows with names that
+// are not reserved may.
+func allowsGroups(name string) bool {
+	reserved, ok := reservedWorkflows[name]
+
+	return !ok || reserved.groups
+}
+
+// IsRestoreOnly reports whether a group is only used in restore workflows, restoring from the
+// backup of the group its BackupRef refers to
+func (g *Group) IsRestoreOnly() bool {
+	return g.BackupRef != ""
+}
+
+// EffectiveWorkflow returns the workflow that runs for a name: the workflow of that name in the
+// recipe, or the default of a reserved name the recipe omits. A default falls back to another
+// workflow, e.g. capture to backup, and is returned as a copy named as requested. Without a
+// workflow to fall back to, backup backs up all groups except restore-only ones, restore restores
+// all groups except the ones restored by restore-only groups, and cleanup does nothing, in each
+// case without hooks. Returns nil for names that are neither defined nor reserved.
+func (s *RecipeSpec) EffectiveWorkflow(name string) *Workflow {
+	if workflow := s.FindWorkflow(name); workflow != nil {
+		return workflow
+	}
+
+	reserved, ok := reservedWorkflows[name]
+	if !ok {
+		return nil
+	}
+
+	if reserved.fallback != "" {
+		workflow := s.EffectiveWorkflow(reserved.fallback).DeepCopy()
+		workflow.Name = name
+
+		return workflow
+	}
+
+	return &Workflow{Name: name, Sequence: s.defaultSequence(reserved)}
+}
+
+// HasGroupSteps reports whether the workflow that runs for a name, or a workflow it calls, backs up
+// or restores groups
+func (s *RecipeSpec) HasGroupSteps(name string) bool {
+	workflow := s.EffectiveWorkflow(name)
+
+	return workflow != nil && hasGroupSteps(s, workflow, map[string]bool{})
+}
+
+func (s *RecipeSpec) defaultSequence(reserved reservedWorkflow) []map[string]string {
+	sequence := []map[string]string{}
+
+	if !reserved.groups {
+		return sequence
+	}
+
+	restoredByRef := map[string]bool{}
+
+	for _, group := range s.Groups {
+		if group != nil && group.IsRestoreOnly() {
+			restoredByRef[group.BackupRef] = true
+		}
+	}
+
+	for _, group := range s.Groups {
+		if group == nil {
+			continue
+		}
+
+		if reserved.restores && restoredByRef[group.Name] || !reserved.restores && group.IsRestoreOnly() {
+			continue
+		}
+
+		sequence = append(sequence, map[string]string{StepKindGroup: group.Name})
+	}
+
+	return sequence
+}
@@ -0,0 +1,25 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+// Package v1alpha2 contains API Schema definitions for the ramendr v1alpha2 API group. It differs
+// from v1alpha1 in the steps of workflows, which are structured Steps rather than maps of the kind of
+// the step to the name it refers to. Recipes are stored as v1alpha1, and converted on access.
+// +kubebuilder:object:generate=true
+// +groupName=ramendr.openshift.io
+package v1alpha2
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/scheme"
+)
+
+var (
+	// GroupVersion is group version used to register these objects
+	GroupVersion = schema.GroupVersion{Group: "ramendr.openshift.io", Version: "v1alpha2"}
+
+	// SchemeBuilder is used to add go types to the GroupVersionKind scheme
+	SchemeBuilder = &scheme.Builder{GroupVersion: GroupVersion}
+
+	// AddToScheme adds the types in this group-version to the given scheme.
+	AddToScheme = SchemeBuilder.AddToScheme
+)
@@ -0,0 +1,229 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package v1alpha2
+
+import (
+	"fmt"
+	"maps"
+
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	ramendrv1alpha1 "github.com/ramendr/recipe/api/v1alpha1"
+)
+
+var _ conversion.Convertible = &Recipe{}
+
+// ConvertTo converts the recipe to the v1alpha1 Recipe hub, turning its steps into sequence entries
+func (r *Recipe) ConvertTo(dstRaw conversion.Hub) error {
+	dst, ok := dstRaw.(*ramendrv1alpha1.Recipe)
+	if !ok {
+		return fmt.Errorf("expected a v1alpha1 Recipe but got a %T", dstRaw)
+	}
+
+	dst.ObjectMeta = r.ObjectMeta
+	dst.Spec = *r.Spec.convertTo()
+	dst.Status = ramendrv1alpha1.RecipeStatus{
+		ObservedGeneration: r.Status.ObservedGeneration,
+		Conditions:         r.Status.Conditions,
+		Findings:           r.Status.Findings,
+		Template:           r.Status.Template,
+		Dependencies:       r.Status.Dependencies,
+		Selections:         r.Status.Selections,
+	}
+
+	if r.Status.EffectiveSpec != nil {
+		dst.Status.EffectiveSpec = r.Status.EffectiveSpec.convertTo()
+	}
+
+	return nil
+}
+
+// ConvertFrom converts the v1alpha1 Recipe hub to the recipe, parsing its sequence entries into
+// steps. Entries that ParseStep rejects are kept as the Entry of their step.
+func (r *Recipe) ConvertFrom(srcRaw conversion.Hub) error {
+	src, ok := srcRaw.(*ramendrv1alpha1.Recipe)
+	if !ok {
+		return fmt.Errorf("expected a v1alpha1 Recipe but got a %T", srcRaw)
+	}
+
+	r.ObjectMeta = src.ObjectMeta
+	r.Spec = *convertSpecFrom(&src.Spec)
+	r.Status = RecipeStatus{
+		ObservedGeneration: src.Status.ObservedGeneration,
+		Conditions:         src.Status.Conditions,
+		Findings:           src.Status.Findings,
+		Template:           src.Status.Template,
+		Dependencies:       src.Status.Dependencies,
+		Selections:         src.Status.Selections,
+	}
+
+	if src.Status.EffectiveSpec != nil {
+		r.Status.EffectiveSpec = convertSpecFrom(src.Status.EffectiveSpec)
+	}
+
+	return nil
+}
+
+func (s *RecipeSpec) convertTo() *ramendrv1alpha1.RecipeSpec {
+	dst := &ramendrv1alpha1.RecipeSpec{
+		AppType:    s.AppType,
+		Groups:     s.Groups,
+		Volumes:    s.Volumes,
+		Hooks:      s.Hooks,
+		Parameters: s.Parameters,
+		Template:   s.Template,
+		Imports:    s.Imports,
+	}
+
+	if s.Workflows == nil {
+		return dst
+	}
+
+	dst.Workflows = make([]*ramendrv1alpha1.Workflow, len(s.Workflows))
+
+	for i, workflow := range s.Workflows {
+		if workflow == nil {
+			continue
+		}
+
+		dst.Workflows[i] = &ramendrv1alpha1.Workflow{
+			Name:     workflow.Name,
+			Sequence: convertStepsTo(workflow.Sequence),
+			FailOn:   workflow.FailOn,
+		}
+
+		if workflow.Parallel == nil {
+			continue
+		}
+
+		dst.Workflows[i].Parallel = make([]*ramendrv1alpha1.ParallelSteps, len(workflow.Parallel))
+
+		for j, set := range workflow.Parallel {
+			if set != nil {
+				dst.Workflows[i].Parallel[j] = &ramendrv1alpha1.ParallelSteps{
+					Name:        set.Name,
+					Steps:       convertStepsTo(set.Steps),
+					MaxParallel: set.MaxParallel,
+				}
+			}
+		}
+	}
+
+	return dst
+}
+
+func convertSpecFrom(src *ramendrv1alpha1.RecipeSpec) *RecipeSpec {
+	spec := &RecipeSpec{
+		AppType:    src.AppType,
+		Groups:     src.Groups,
+		Volumes:    src.Volumes,
+		Hooks:      src.Hooks,
+		Parameters: src.Parameters,
+		Template:   src.Template,
+		Imports:    src.Imports,
+	}
+
+	if src.Workflows == nil {
+		return spec
+	}
+
+	spec.Workflows = make([]*Workflow, len(src.Workflows))
+
+	for i, workflow := range src.Workflows {
+		if workflow == nil {
+			continue
+		}
+
+		spec.Workflows[i] = &Workflow{
+			Name:     workflow.Name,
+			Sequence: convertStepsFrom(workflow.Sequence),
+			FailOn:   workflow.FailOn,
+		}
+
+		if workflow.Parallel == nil {
+			continue
+		}
+
+		spec.Workflows[i].Parallel = make([]*ParallelSteps, len(workflow.Parallel))
+
+		for j, set := range workflow.Parallel {
+			if set != nil {
+				spec.Workflows[i].Parallel[j] = &ParallelSteps{
+					Name:        set.Name,
+					Steps:       convertStepsFrom(set.Steps),
+					MaxParallel: set.MaxParallel,
+				}
+			}
+		}
+	}
+
+	return spec
+}
+
+// convertStepsTo returns the steps as sequence entries
+func convertStepsTo(steps []Step) []map[string]string {
+	if steps == nil {
+		return nil
+	}
+
+	entries := make([]map[string]string, len(steps))
+
+	for i, step := range steps {
+		if step.Entry != nil {
+			entries[i] = maps.Clone(step.Entry)
+
+			continue
+		}
+
+		name := step.Name
+		if step.Kind == StepKindParallel {
+			name = step.Parallel
+		}
+
+		entries[i] = ramendrv1alpha1.Step{
+			Kind:    step.Kind,
+			Name:    name,
+			Op:      step.Op,
+			When:    step.When,
+			Timeout: step.Timeout,
+			OnError: step.OnError,
+		}.Entry()
+	}
+
+	return entries
+}
+
+// convertStepsFrom parses sequence entries into steps, keeping the entries that are not valid steps
+// as they are
+func convertStepsFrom(entries []map[string]string) []Step {
+	if entries == nil {
+		return nil
+	}
+
+	steps := make([]Step, len(entries))
+
+	for i, entry := range entries {
+		parsed, err := ramendrv1alpha1.ParseStep(entry)
+		if err != nil {
+			steps[i] = Step{Entry: maps.Clone(entry)}
+
+			continue
+		}
+
+		steps[i] = Step{
+			Kind:    parsed.Kind,
+			Name:    parsed.Name,
+			Op:      parsed.Op,
+			When:    parsed.When,
+			Timeout: parsed.Timeout,
+			OnError: parsed.OnError,
+		}
+
+		if parsed.Kind == StepKindParallel {
+			steps[i].Name, steps[i].Parallel = "", parsed.Name
+		}
+	}
+
+	return steps
+}
@@ -0,0 +1,97 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package v1alpha2_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	Recipe "github.com/ramendr/recipe/api/v1alpha1"
+	RecipeV2 "github.com/ramendr/recipe/api/v1alpha2"
+)
+
+func hubRecipe() *Recipe.Recipe {
+	spec := Recipe.RecipeSpec{
+		AppType: "postgres",
+		Groups:  []*Recipe.Group{{Name: "data", Type: "volume"}},
+		Hooks: []*Recipe.Hook{{
+			Name: "db",
+			Type: "exec",
+			Ops:  []*Recipe.Operation{{Name: "quiesce", Command: "/bin/quiesce"}},
+		}},
+		Workflows: []*Recipe.Workflow{{
+			Name: Recipe.BackupWorkflowName,
+			Sequence: []map[string]string{
+				{"hook": "db/quiesce", "timeout": "120", "onError": "continue"},
+				{"parallel": "groups", "when": "namespace prod"},
+				{"workflow": "cleanup-all"},
+			},
+			FailOn: "essential-error",
+			Parallel: []*Recipe.ParallelSteps{{
+				Name:        "groups",
+				Steps:       []map[string]string{{"group": "data"}, {"hook": "db"}},
+				MaxParallel: 2,
+			}},
+		}},
+	}
+
+	return &Recipe.Recipe{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "prod"},
+		Spec:       spec,
+		Status:     Recipe.RecipeStatus{ObservedGeneration: 3, EffectiveSpec: spec.DeepCopy()},
+	}
+}
+
+var _ = Describe("Recipe conversion", func() {
+	It("converts sequence entries into steps", func() {
+		recipe := &RecipeV2.Recipe{}
+		Expect(recipe.ConvertFrom(hubRecipe())).To(Succeed())
+
+		workflow := recipe.Spec.Workflows[0]
+		Expect(workflow.Sequence).To(Equal([]RecipeV2.Step{
+			{Kind: RecipeV2.StepKindHook, Name: "db", Op: "quiesce", Timeout: 120, OnError: "continue"},
+			{Kind: RecipeV2.StepKindParallel, Parallel: "groups", When: "namespace prod"},
+			{Kind: RecipeV2.StepKindWorkflow, Name: "cleanup-all"},
+		}))
+		Expect(workflow.FailOn).To(Equal("essential-error"))
+		Expect(workflow.Parallel).To(Equal([]*RecipeV2.ParallelSteps{{
+			Name: "groups",
+			Steps: []RecipeV2.Step{
+				{Kind: RecipeV2.StepKindGroup, Name: "data"},
+				{Kind: RecipeV2.StepKindHook, Name: "db"},
+			},
+			MaxParallel: 2,
+		}}))
+		Expect(recipe.Spec.Hooks[0].Name).To(Equal("db"))
+		Expect(recipe.Status.ObservedGeneration).To(Equal(int64(3)))
+		Expect(recipe.Status.EffectiveSpec.Workflows[0].Sequence).To(Equal(workflow.Sequence))
+	})
+	It("converts steps back into the sequence entries they were converted from", func() {
+		recipe := &RecipeV2.Recipe{}
+		Expect(recipe.ConvertFrom(hubRecipe())).To(Succeed())
+
+		hub := &Recipe.Recipe{}
+		Expect(recipe.ConvertTo(hub)).To(Succeed())
+		Expect(hub).To(Equal(hubRecipe()))
+	})
+	It("keeps sequence entries that are not valid steps as they are", func() {
+		hub := hubRecipe()
+		hub.Spec.Workflows[0].Sequence[2] = map[string]string{"group": "data", "hook": "db"}
+		hub.Spec.Workflows[0].Parallel[0].Steps[0] = map[string]string{"group": "data", "timeout": "10"}
+
+		recipe := &RecipeV2.Recipe{}
+		Expect(recipe.ConvertFrom(hub)).To(Succeed())
+		Expect(recipe.Spec.Workflows[0].Sequence[2]).To(Equal(RecipeV2.Step{
+			Entry: map[string]string{"group": "data", "hook": "db"},
+		}))
+		Expect(recipe.Spec.Workflows[0].Parallel[0].Steps[0].Entry).To(HaveKeyWithValue("timeout", "10"))
+
+		converted := &Recipe.Recipe{}
+		Expect(recipe.ConvertTo(converted)).To(Succeed())
+		Expect(converted).To(Equal(hub))
+		Expect(Recipe.ValidateRecipe(converted)).NotTo(BeEmpty())
+	})
+})
@@ -0,0 +1,213 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package v1alpha2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ramendrv1alpha1 "github.com/ramendr/recipe/api/v1alpha1"
+)
+
+// Kinds of steps
+const (
+	// StepKindGroup backs up or restores a group
+	StepKindGroup string = "group"
+	// StepKindHook runs an operation or check of a hook
+	StepKindHook string = "hook"
+	// StepKindParallel runs a parallel set of the workflow
+	StepKindParallel string = "parallel"
+	// StepKindWorkflow runs the steps of another workflow of the recipe
+	StepKindWorkflow string = "workflow"
+)
+
+// RecipeSpec defines the desired state of Recipe
+type RecipeSpec struct {
+	// Type of application the recipe is designed for. (AppType is not used yet. For now, we will
+	// match the name of the app CR)
+	AppType string `json:"appType"`
+	// List of one or multiple groups
+	//+listType=map
+	//+listMapKey=name
+	//+optional
+	Groups []*ramendrv1alpha1.Group `json:"groups"`
+	// Volumes to protect from disaster
+	//+optional
+	Volumes *ramendrv1alpha1.Group `json:"volumes"`
+	// List of one or multiple hooks
+	//+listType=map
+	//+listMapKey=name
+	Hooks []*ramendrv1alpha1.Hook `json:"hooks,omitempty"`
+	// Workflow is the sequence of actions to take
+	//+listType=map
+	//+listMapKey=name
+	// +kubebuilder:validation:MaxItems=64
+	//+optional
+	Workflows []*Workflow `json:"workflows"`
+	// Parameters of the recipe, referenced as ${name} in the namespace, selectors and operations of
+	// hooks, in the included namespaces and selectors of groups, and in the when conditions of steps.
+	// References in commands that name no parameter, e.g. ${HOME}, are left to the shell.
+	//+listType=map
+	//+listMapKey=name
+	//+optional
+	Parameters []*ramendrv1alpha1.Parameter `json:"parameters,omitempty"`
+	// Template the recipe instantiates. The groups, hooks, workflows and parameters of the recipe are
+	// added to the ones of the template, replacing the ones of the same name.
+	//+optional
+	Template *ramendrv1alpha1.TemplateReference `json:"template,omitempty"`
+	// Groups and hooks imported from other recipes, e.g. the hooks shared by the applications using a
+	// database. Imported groups and hooks are used like the ones of the recipe, and must not have the
+	// name of one of them.
+	//+optional
+	Imports []*ramendrv1alpha1.Import `json:"imports,omitempty"`
+}
+
+// Workflow is the sequence of actions to take
+type Workflow struct {
+	// Name of the workflow. Names "backup", "restore", "capture", "recover", "failover", "relocate"
+	// and "cleanup" are reserved: they have a default behavior if the workflow is omitted, and
+	// restrict the steps of the workflow, e.g. restore-only groups cannot be backed up.
+	Name string `json:"name"`
+	// Steps of the workflow, in the order in which they run
+	// +kubebuilder:validation:MaxItems=256
+	Sequence []Step `json:"sequence"`
+	// Implies behaviour in case of failure: any-error (default), essential-error, full-error
+	// +kubebuilder:validation:Enum=any-error;essential-error;full-error
+	// +kubebuilder:default=any-error
+	FailOn string `json:"failOn,omitempty"`
+	// Sets of steps that run concurrently, run by steps of kind parallel
+	//+optional
+	//+listType=map
+	//+listMapKey=name
+	// +kubebuilder:validation:MaxItems=32
+	Parallel []*ParallelSteps `json:"parallel,omitempty"`
+}
+
+// ParallelSteps is a set of steps of a workflow that run concurrently. FailOn of the workflow applies
+// to each step of the set: a failure stopping the workflow stops starting further steps of the set,
+// and the workflow stops once the running steps completed.
+// +kubebuilder:validation:XValidation:rule="self.steps.all(s, !has(s.kind) || s.kind in ['group', 'hook'])",message="steps of parallel sets must be group or hook steps"
+type ParallelSteps struct {
+	// Name of the set, unique within the workflow
+	Name string `json:"name"`
+	// Steps of the set, group or hook steps
+	// +kubebuilder:validation:MaxItems=64
+	Steps []Step `json:"steps"`
+	// Maximum number of steps running at the same time. Defaults to 5.
+	// +kubebuilder:validation:Minimum=1
+	//+optional
+	MaxParallel int `json:"maxParallel,omitempty"`
+}
+
+// Step is a step of a workflow or of a parallel set, e.g. {kind: hook, name: db, op: quiesce}
+// +kubebuilder:validation:XValidation:rule="has(self.kind) != has(self.entry)",message="steps must have either a kind or an entry"
+// +kubebuilder:validation:XValidation:rule="!has(self.kind) || (self.kind == 'parallel' ? has(self.parallel) && !has(self.name) : has(self.name) && !has(self.parallel))",message="steps of kind parallel must have parallel and no name, other steps a name and no parallel"
+// +kubebuilder:validation:XValidation:rule="!has(self.op) || has(self.kind) && self.kind == 'hook'",message="op is only supported for hook steps"
+// +kubebuilder:validation:XValidation:rule="!has(self.timeout) || has(self.kind) && self.kind == 'hook'",message="timeout is only supported for hook steps"
+// +kubebuilder:validation:XValidation:rule="!has(self.onError) || has(self.kind) && self.kind in ['group', 'hook']",message="onError is only supported for group and hook steps"
+type Step struct {
+	// Kind of the step: group backs up or restores a group, hook runs an operation or check of a
+	// hook, parallel runs a parallel set of the workflow, and workflow runs the steps of another
+	// workflow of the recipe that is not reserved, with its own failure behavior, backing up or
+	// restoring groups as the calling workflow does. Workflows must not call themselves, directly or
+	// through other workflows. Required unless the step is an entry.
+	// +kubebuilder:validation:Enum=group;hook;parallel;workflow
+	//+optional
+	Kind string `json:"kind,omitempty"`
+	// Name of the group, hook or workflow the step runs
+	// +kubebuilder:validation:Pattern=`^[^/]+$`
+	//+optional
+	Name string `json:"name,omitempty"`
+	// Operation or check of the hook a hook step runs. Required unless the hook declares exactly one
+	// operation or check.
+	// +kubebuilder:validation:Pattern=`^[^/]+$`
+	//+optional
+	Op string `json:"op,omitempty"`
+	// Condition of the step, which is skipped unless it holds: <kind> [<namespace>/]<name>[: <condition>]
+	// It holds if the namespace, pvc, pod, deployment, statefulset or replicaset exists and the
+	// condition, in the language of the conditions of checks, is true for it. Objects without
+	// namespace are looked up in the namespace of the recipe.
+	//+optional
+	When string `json:"when,omitempty"`
+	// Timeout of a hook step in seconds, overriding the one of its operation or check
+	// +kubebuilder:validation:Minimum=1
+	//+optional
+	Timeout int `json:"timeout,omitempty"`
+	// How a failure of a group or hook step is handled, overriding the onError of its hook,
+	// operation or check: fail fails the step, continue records the failure and continues
+	// +kubebuilder:validation:Enum=fail;continue
+	//+optional
+	OnError string `json:"onError,omitempty"`
+	// Name of the parallel set of the workflow a parallel step runs
+	//+optional
+	Parallel string `json:"parallel,omitempty"`
+	// Entry is the v1alpha1 sequence entry of a step stored in a form that is not a valid step, e.g.
+	// with an unknown key. It is set instead of the other fields when converting such a recipe, so that
+	// the entry converts back unchanged, and is rejected by the validation of the recipe.
+	//+optional
+	Entry map[string]string `json:"entry,omitempty"`
+}
+
+// RecipeStatus defines the observed state of Recipe
+type RecipeStatus struct {
+	// Generation of the recipe that was last processed by the reconciler
+	//+optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions Valid, Resolved and Ready of the recipe
+	//+listType=map
+	//+listMapKey=type
+	//+optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// Findings of the last validation, errors first. Their paths refer to the v1alpha1 form of the
+	// recipe.
+	//+optional
+	Findings []ramendrv1alpha1.Finding `json:"findings,omitempty"`
+	// Template the recipe was rendered from, for recipes referencing a template
+	//+optional
+	Template *ramendrv1alpha1.RenderedTemplate `json:"template,omitempty"`
+	// EffectiveSpec is the spec rendered from the template and with the imported groups and hooks,
+	// for recipes referencing a template or importing. Findings and selections refer to it.
+	//+optional
+	EffectiveSpec *RecipeSpec `json:"effectiveSpec,omitempty"`
+	// Recipes the recipe imports from, directly or through other recipes. The imports of the recipe
+	// itself are its spec.imports.
+	//+listType=map
+	//+listMapKey=namespace
+	//+listMapKey=name
+	//+optional
+	Dependencies []ramendrv1alpha1.RecipeDependency `json:"dependencies,omitempty"`
+	// What the groups and hooks of the recipe currently select in the cluster
+	//+listType=map
+	//+listMapKey=kind
+	//+listMapKey=name
+	//+optional
+	Selections []ramendrv1alpha1.SelectionPreview `json:"selections,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Reason",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].reason`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// Recipe is the Schema for the recipes API
+type Recipe struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RecipeSpec   `json:"spec,omitempty"`
+	Status RecipeStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// RecipeList contains a list of Recipe
+type RecipeList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Recipe `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Recipe{}, &RecipeList{})
+}
@@ -0,0 +1,143 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ramendr/recipe/pkg/dryrun"
+)
+
+// dryRun simulates a workflow of recipes against a snapshot of cluster objects and prints what each
+// group would include and exclude and what each hook would run on
+func dryRun(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := newFlagSet("dry-run", "-workflow <name> -snapshot <directory or tarball> <file or directory>...",
+		stderr)
+	namespace := flags.String("n", "default", "namespace of recipes that do not set one")
+	name := flags.String("recipe", "", "name of the recipe to run, all recipes if empty")
+	workflowName := flags.String("workflow", "", "name of the workflow to run")
+	snapshotPath := flags.String("snapshot", "", "directory or tarball (.tar, .tar.gz or .tgz) of YAML or JSON "+
+		"files of cluster objects, e.g. from kubectl get -o yaml")
+	values := parameterValues{}
+	flags.Var(values, "p", "value of a parameter as name=value, repeatable")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *workflowName == "" {
+		return fmt.Errorf("-workflow is required")
+	}
+
+	if *snapshotPath == "" {
+		return fmt.Errorf("-snapshot is required")
+	}
+
+	in, err := readInputs(flags.Args(), *namespace, stdin)
+	if err != nil {
+		return err
+	}
+
+	recipes, err := in.selectRecipes(*name)
+	if err != nil {
+		return err
+	}
+
+	snapshot, err := readSnapshot(*snapshotPath)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "Snapshot %s: %d objects, %d of unknown kinds ignored\n", *snapshotPath, snapshot.Len(),
+		snapshot.Ignored())
+
+	reader := snapshot.Reader()
+
+	for _, recipe := range recipes {
+		effective, err := in.effectiveRecipe(recipe, values)
+		if err != nil {
+			return err
+		}
+
+		report, err := dryrun.Run(context.Background(), reader, effective, *workflowName)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(stdout)
+
+		if err := report.Write(stdout); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readSnapshot reads the objects of the YAML and JSON files of a directory or tarball, or of a
+// single file
+func readSnapshot(path string) (*dryrun.Snapshot, error) {
+	snapshot := dryrun.NewSnapshot()
+
+	add := func(source string, document []byte) error {
+		if err := snapshot.Add(document); err != nil {
+			return fmt.Errorf("%s: %w", source, err)
+		}
+
+		return nil
+	}
+
+	lower := strings.ToLower(path)
+	if !strings.HasSuffix(lower, ".tar") && !strings.HasSuffix(lower, ".tar.gz") && !strings.HasSuffix(lower, ".tgz") {
+		return snapshot, readDocuments([]string{path}, nil, add)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	var r io.Reader = f
+
+	if !strings.HasSuffix(lower, ".tar") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		defer gz.Close()
+
+		r = gz
+	}
+
+	archive := tar.NewReader(r)
+
+	for {
+		header, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			return snapshot, nil
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		if header.Typeflag != tar.TypeReg || !isManifest(header.Name) {
+			continue
+		}
+
+		if err := readStream(path+":"+header.Name, archive, add); err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,59 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/ramendr/recipe/pkg/explain"
+)
+
+// explainWorkflow prints a workflow of recipes as a numbered plan
+func explainWorkflow(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := newFlagSet("explain", "-workflow <name> <file or directory>...", stderr)
+	namespace := flags.String("n", "default", "namespace of recipes that do not set one")
+	name := flags.String("recipe", "", "name of the recipe to explain, all recipes if empty")
+	workflowName := flags.String("workflow", "", "name of the workflow to explain")
+	values := parameterValues{}
+	flags.Var(values, "p", "value of a parameter as name=value, repeatable")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *workflowName == "" {
+		return fmt.Errorf("-workflow is required")
+	}
+
+	in, err := readInputs(flags.Args(), *namespace, stdin)
+	if err != nil {
+		return err
+	}
+
+	recipes, err := in.selectRecipes(*name)
+	if err != nil {
+		return err
+	}
+
+	for i, recipe := range recipes {
+		effective, err := in.effectiveRecipe(recipe, values)
+		if err != nil {
+			return err
+		}
+
+		plan, err := explain.Workflow(effective, *workflowName)
+		if err != nil {
+			return err
+		}
+
+		if i > 0 {
+			fmt.Fprintln(stdout)
+		}
+
+		fmt.Fprint(stdout, plan)
+	}
+
+	return nil
+}
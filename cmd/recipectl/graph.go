@@ -0,0 +1,74 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ramendr/recipe/pkg/graph"
+)
+
+// graphRecipe prints recipes as graphs of their workflows, hooks and groups
+func graphRecipe(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := newFlagSet("graph", "<file or directory>...", stderr)
+	namespace := flags.String("n", "default", "namespace of recipes that do not set one")
+	name := flags.String("recipe", "", "name of the recipe to print, all recipes if empty")
+	format := flags.String("format", string(graph.FormatDOT), fmt.Sprintf("output format, one of %q",
+		graph.SupportedFormats))
+	workflows := workflowNames{}
+	flags.Var(&workflows, "workflow", "name of a workflow to print, repeatable, all workflows of the recipe if none")
+	values := parameterValues{}
+	flags.Var(values, "p", "value of a parameter as name=value, repeatable")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	in, err := readInputs(flags.Args(), *namespace, stdin)
+	if err != nil {
+		return err
+	}
+
+	recipes, err := in.selectRecipes(*name)
+	if err != nil {
+		return err
+	}
+
+	for i, recipe := range recipes {
+		effective, err := in.effectiveRecipe(recipe, values)
+		if err != nil {
+			return err
+		}
+
+		g, err := graph.New(effective, workflows...)
+		if err != nil {
+			return err
+		}
+
+		if i > 0 {
+			fmt.Fprintln(stdout)
+		}
+
+		if err := graph.Write(stdout, g, graph.Format(*format)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// workflowNames are the names given as repeated -workflow flags
+type workflowNames []string
+
+func (w *workflowNames) String() string {
+	return strings.Join(*w, ",")
+}
+
+func (w *workflowNames) Set(value string) error {
+	*w = append(*w, value)
+
+	return nil
+}
@@ -0,0 +1,241 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+
+	ramendrv1alpha1 "github.com/ramendr/recipe/api/v1alpha1"
+	ramendrv1alpha2 "github.com/ramendr/recipe/api/v1alpha2"
+)
+
+// inputs are the Recipes and RecipeTemplates read from the files given to a command
+type inputs struct {
+	recipes   []*ramendrv1alpha1.Recipe
+	templates []*ramendrv1alpha1.RecipeTemplate
+	// files the recipes and templates were read from
+	sources map[any]string
+	// errors of documents that are not valid Recipes or RecipeTemplates, e.g. with unknown fields
+	errs []error
+}
+
+// readInputs reads the Recipes and RecipeTemplates of files and directories, setting the namespace
+// of recipes without one. v1alpha2 Recipes are converted to v1alpha1. Documents of other kinds are
+// ignored.
+func readInputs(paths []string, namespace string, stdin io.Reader) (*inputs, error) {
+	in := &inputs{sources: map[any]string{}}
+
+	err := readDocuments(paths, stdin, func(source string, document []byte) error {
+		if err := in.add(source, document, namespace); err != nil {
+			in.errs = append(in.errs, fmt.Errorf("%s: %w", source, err))
+		}
+
+		return nil
+	})
+
+	return in, err
+}
+
+func (in *inputs) add(source string, document []byte, namespace string) error {
+	typeMeta := &metav1.TypeMeta{}
+	if err := yaml.Unmarshal(document, typeMeta); err != nil {
+		return err
+	}
+
+	switch {
+	case typeMeta.Kind == "List":
+		list := &struct {
+			Items []json.RawMessage `json:"items"`
+		}{}
+		if err := yaml.Unmarshal(document, list); err != nil {
+			return err
+		}
+
+		for _, item := range list.Items {
+			if err := in.add(source, item, namespace); err != nil {
+				return err
+			}
+		}
+	case typeMeta.APIVersion == ramendrv1alpha2.GroupVersion.String() && typeMeta.Kind == "Recipe":
+		spoke := &ramendrv1alpha2.Recipe{}
+		if err := yaml.UnmarshalStrict(document, spoke); err != nil {
+			return fmt.Errorf("invalid Recipe: %w", err)
+		}
+
+		recipe := &ramendrv1alpha1.Recipe{}
+		if err := spoke.ConvertTo(recipe); err != nil {
+			return fmt.Errorf("invalid Recipe: %w", err)
+		}
+
+		recipe.TypeMeta = metav1.TypeMeta{APIVersion: ramendrv1alpha1.GroupVersion.String(), Kind: "Recipe"}
+		in.addRecipe(source, recipe, namespace)
+	case typeMeta.APIVersion != ramendrv1alpha1.GroupVersion.String():
+	case typeMeta.Kind == "Recipe":
+		recipe := &ramendrv1alpha1.Recipe{}
+		if err := yaml.UnmarshalStrict(document, recipe); err != nil {
+			return fmt.Errorf("invalid Recipe: %w", err)
+		}
+
+		in.addRecipe(source, recipe, namespace)
+	case typeMeta.Kind == "RecipeTemplate":
+		template := &ramendrv1alpha1.RecipeTemplate{}
+		if err := yaml.UnmarshalStrict(document, template); err != nil {
+			return fmt.Errorf("invalid RecipeTemplate: %w", err)
+		}
+
+		in.templates = append(in.templates, template)
+		in.sources[template] = source
+	}
+
+	return nil
+}
+
+func (in *inputs) addRecipe(source string, recipe *ramendrv1alpha1.Recipe, namespace string) {
+	if recipe.Namespace == "" {
+		recipe.Namespace = namespace
+	}
+
+	in.recipes = append(in.recipes, recipe)
+	in.sources[recipe] = source
+}
+
+// findRecipe returns the recipe of the given namespace and name, or nil if there is none
+func (in *inputs) findRecipe(namespace, name string) *ramendrv1alpha1.Recipe {
+	for _, recipe := range in.recipes {
+		if recipe.Namespace == namespace && recipe.Name == name {
+			return recipe
+		}
+	}
+
+	return nil
+}
+
+// findTemplate returns the template of the given name, or nil if there is none
+func (in *inputs) findTemplate(name string) *ramendrv1alpha1.RecipeTemplate {
+	for _, template := range in.templates {
+		if template.Name == name {
+			return template
+		}
+	}
+
+	return nil
+}
+
+// selectRecipes returns the recipes named by the -recipe flag of a command, or all recipes if name
+// is empty
+func (in *inputs) selectRecipes(name string) ([]*ramendrv1alpha1.Recipe, error) {
+	if len(in.errs) != 0 {
+		return nil, errors.Join(in.errs...)
+	}
+
+	if name == "" {
+		if len(in.recipes) == 0 {
+			return nil, errors.New("no recipes found")
+		}
+
+		return in.recipes, nil
+	}
+
+	selected := []*ramendrv1alpha1.Recipe{}
+
+	for _, recipe := range in.recipes {
+		if recipe.Name == name {
+			selected = append(selected, recipe)
+		}
+	}
+
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("recipe %q not found", name)
+	}
+
+	return selected, nil
+}
+
+// readDocuments calls read with each YAML or JSON document of the files, of the YAML and JSON files
+// of the directories, recursively, and of stdin for "-"
+func readDocuments(paths []string, stdin io.Reader, read func(source string, document []byte) error) error {
+	if len(paths) == 0 {
+		return errors.New("no files given")
+	}
+
+	for _, path := range paths {
+		if path == "-" {
+			if err := readStream("<stdin>", stdin, read); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		err := filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if entry.IsDir() || (file != path && !isManifest(file)) {
+				return nil
+			}
+
+			f, err := os.Open(file)
+			if err != nil {
+				return err
+			}
+
+			defer f.Close()
+
+			return readStream(file, f, read)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func isManifest(file string) bool {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	default:
+		return false
+	}
+}
+
+// readStream calls read with each document of a stream of YAML documents separated by "---"
+func readStream(source string, r io.Reader, read func(source string, document []byte) error) error {
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(r))
+
+	for {
+		document, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return fmt.Errorf("%s: %w", source, err)
+		}
+
+		if len(bytes.TrimSpace(document)) == 0 {
+			continue
+		}
+
+		if err := read(source, document); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,120 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	ramendrv1alpha1 "github.com/ramendr/recipe/api/v1alpha1"
+)
+
+// lint validates the recipes and templates of the inputs with the checks of the admission webhook,
+// and recipes referencing templates or importing from other recipes with the checks the reconciler
+// applies once they are resolved. Templates and recipes missing from the inputs are reported as
+// warnings, since they may be published separately.
+func lint(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := newFlagSet("lint", "<file or directory>...", stderr)
+	namespace := flags.String("n", "default", "namespace of recipes that do not set one")
+	strict := flags.Bool("strict", false, "fail on warnings too")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	in, err := readInputs(flags.Args(), *namespace, stdin)
+	if err != nil {
+		return err
+	}
+
+	l := &linter{out: stdout}
+
+	for _, err := range in.errs {
+		fmt.Fprintf(stdout, "error: %v\n", err)
+		l.errors++
+	}
+
+	for _, template := range in.templates {
+		recipe := &ramendrv1alpha1.Recipe{ObjectMeta: template.ObjectMeta, Spec: template.Spec.RecipeSpec}
+
+		l.report(in.sources[template], "RecipeTemplate "+template.Name, ramendrv1alpha1.ValidateRecipe(recipe),
+			ramendrv1alpha1.RecipeWarnings(recipe))
+	}
+
+	for _, recipe := range in.recipes {
+		errs, warnings := in.lintRecipe(recipe)
+
+		l.report(in.sources[recipe], fmt.Sprintf("Recipe %s/%s", recipe.Namespace, recipe.Name), errs, warnings)
+	}
+
+	fmt.Fprintf(stdout, "%d recipes and %d templates checked: %d errors, %d warnings\n", len(in.recipes),
+		len(in.templates), l.errors, l.warnings)
+
+	if l.errors != 0 || *strict && l.warnings != 0 {
+		return errFailed
+	}
+
+	return nil
+}
+
+// lintRecipe returns the errors and warnings of a recipe
+func (in *inputs) lintRecipe(recipe *ramendrv1alpha1.Recipe) (field.ErrorList, field.ErrorList) {
+	errs := ramendrv1alpha1.ValidateRecipe(recipe)
+	warnings := ramendrv1alpha1.RecipeWarnings(recipe)
+
+	if recipe.Spec.Template != nil || len(recipe.Spec.Imports) != 0 {
+		resolved, resolveErrs := in.resolve(recipe)
+
+		for _, err := range resolveErrs {
+			if isMissingInput(err) {
+				warning := *err
+				warning.Detail = "not among the inputs, the recipe is only checked as the webhook does"
+				warnings = append(warnings, &warning)
+			} else {
+				errs = append(errs, err)
+			}
+		}
+
+		if len(resolveErrs) != 0 {
+			return errs, warnings
+		}
+
+		// the resolved recipe has the fields of the recipe, at other paths if rendered from a template
+		recipe = resolved
+		errs = ramendrv1alpha1.ValidateRecipe(recipe)
+		warnings = ramendrv1alpha1.RecipeWarnings(recipe)
+	}
+
+	// as in the reconciler, parameters without default only get values when the recipe is run
+	_, expandErrs := ramendrv1alpha1.ExpandParameters(recipe, nil)
+	for _, err := range expandErrs {
+		if err.Type == field.ErrorTypeRequired {
+			warnings = append(warnings, err)
+		}
+	}
+
+	return errs, warnings
+}
+
+// linter prints findings and counts them
+type linter struct {
+	out      io.Writer
+	errors   int
+	warnings int
+}
+
+func (l *linter) report(source, object string, errs, warnings field.ErrorList) {
+	for _, err := range errs {
+		fmt.Fprintf(l.out, "%s: %s: error: %v\n", source, object, err)
+	}
+
+	for _, warning := range warnings {
+		fmt.Fprintf(l.out, "%s: %s: warning: %v\n", source, object, warning)
+	}
+
+	l.errors += len(errs)
+	l.warnings += len(warnings)
+}
@@ -0,0 +1,97 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+// Command recipectl checks and inspects Recipes offline, e.g. in the CI of a GitOps repository,
+// with the validation of the admission webhook and the rendering of the reconciler.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// command is a subcommand of recipectl
+type command struct {
+	name    string
+	summary string
+	run     func(args []string, stdin io.Reader, stdout, stderr io.Writer) error
+}
+
+var commands = []command{
+	{"lint", "validate Recipes and RecipeTemplates like the admission webhook and the reconciler", lint},
+	{"render", "print Recipes rendered from their templates, with their imports and parameters expanded", render},
+	{"explain", "print a workflow of a Recipe as a numbered plan", explainWorkflow},
+	{"graph", "print Recipes as Graphviz DOT or Mermaid graphs of their workflows, hooks and groups", graphRecipe},
+	{"dry-run", "simulate a workflow of Recipes against a snapshot of cluster objects", dryRun},
+}
+
+// errFailed is returned by commands that reported why they failed themselves
+var errFailed = errors.New("failed")
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run runs the command named by the first argument and returns the exit code
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "-help" || args[0] == "help" {
+		usage(stderr)
+
+		return 2
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != args[0] {
+			continue
+		}
+
+		err := cmd.run(args[1:], stdin, stdout, stderr)
+
+		switch {
+		case err == nil:
+			return 0
+		case errors.Is(err, flag.ErrHelp):
+			return 2
+		case !errors.Is(err, errFailed):
+			fmt.Fprintf(stderr, "recipectl %s: %v\n", cmd.name, err)
+		}
+
+		return 1
+	}
+
+	fmt.Fprintf(stderr, "recipectl: unknown command %q\n", args[0])
+	usage(stderr)
+
+	return 2
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: recipectl <command> [flags] <file or directory>...")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Files hold YAML or JSON documents; documents of other kinds than Recipe and RecipeTemplate are")
+	fmt.Fprintln(w, "ignored, and \"-\" reads standard input. Directories are read recursively.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-9s %s\n", cmd.name, cmd.summary)
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run recipectl <command> -h for the flags of a command.")
+}
+
+// newFlagSet returns the flag set of a command, printing its usage to stderr
+func newFlagSet(name, args string, stderr io.Writer) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: recipectl %s [flags] %s\n\nFlags:\n", name, args)
+		flags.PrintDefaults()
+	}
+
+	return flags
+}
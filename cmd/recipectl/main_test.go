@@ -0,0 +1,187 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"sigs.k8s.io/yaml"
+
+	ramendrv1alpha1 "github.com/ramendr/recipe/api/v1alpha1"
+)
+
+// runRecipectl runs recipectl with the arguments and returns its exit code and output
+func runRecipectl(stdin string, args ...string) (int, string, string) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+	code := run(args, strings.NewReader(stdin), stdout, stderr)
+
+	return code, stdout.String(), stderr.String()
+}
+
+// writeTarball writes the files of a directory to a gzipped tarball
+func writeTarball(path, dir string) {
+	f, err := os.Create(path)
+	Expect(err).NotTo(HaveOccurred())
+
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	archive := tar.NewWriter(gz)
+
+	entries, err := os.ReadDir(dir)
+	Expect(err).NotTo(HaveOccurred())
+
+	for _, entry := range entries {
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(archive.WriteHeader(&tar.Header{
+			Name:     "snapshot/" + entry.Name(),
+			Mode:     0o644,
+			Size:     int64(len(data)),
+			Typeflag: tar.TypeReg,
+		})).To(Succeed())
+		_, err = archive.Write(data)
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	Expect(archive.Close()).To(Succeed())
+	Expect(gz.Close()).To(Succeed())
+}
+
+var _ = Describe("recipectl", func() {
+	It("lints templates and resolved recipes", func() {
+		code, stdout, _ := runRecipectl("", "lint", "testdata/recipes.yaml")
+		Expect(stdout).To(Equal("2 recipes and 1 templates checked: 0 errors, 0 warnings\n"))
+		Expect(code).To(Equal(0))
+	})
+	It("reports errors, warnings and unknown fields", func() {
+		code, stdout, _ := runRecipectl("", "lint", "testdata/invalid.yaml")
+		Expect(code).To(Equal(1))
+		Expect(strings.Split(stdout, "\n")).To(Equal([]string{
+			`error: testdata/invalid.yaml: invalid Recipe: error unmarshaling JSON: while decoding JSON: ` +
+				`json: unknown field "hook"`,
+			`testdata/invalid.yaml: Recipe apps/invalid: error: spec.workflows[0].sequence[0][hook]: ` +
+				`Not found: "db/dump"`,
+			`testdata/invalid.yaml: Recipe apps/invalid: warning: spec.hooks[0].nameSelector: Invalid value: ` +
+				`"regex:^DB$": names must contain 'D', which is not allowed in DNS-1123 names: lower case ` +
+				`alphanumeric characters, '-' and '.'`,
+			"1 recipes and 0 templates checked: 2 errors, 1 warnings",
+			"",
+		}))
+	})
+	It("warns about templates and recipes missing from the inputs", func() {
+		recipes, err := os.ReadFile("testdata/recipes.yaml")
+		Expect(err).NotTo(HaveOccurred())
+
+		orders := string(recipes[bytes.LastIndex(recipes, []byte("---")):])
+
+		code, stdout, _ := runRecipectl(orders, "lint", "-n", "orders", "-")
+		Expect(stdout).To(ContainSubstring(`<stdin>: Recipe orders/orders: warning: spec.template.name: ` +
+			`Not found: "postgres": not among the inputs`))
+		Expect(code).To(Equal(0))
+
+		code, _, _ = runRecipectl(orders, "lint", "-strict", "-")
+		Expect(code).To(Equal(1))
+	})
+	It("renders recipes with their template, imports and parameters", func() {
+		code, stdout, stderr := runRecipectl("", "render", "-recipe", "orders", "-p", "app=pg", "testdata/recipes.yaml")
+		Expect(stderr).To(BeEmpty())
+		Expect(code).To(Equal(0))
+
+		recipe := &ramendrv1alpha1.Recipe{}
+		Expect(yaml.UnmarshalStrict([]byte(stdout), recipe)).To(Succeed())
+		Expect(recipe.Namespace).To(Equal("default"))
+		Expect(recipe.Spec.Template).To(BeNil())
+		Expect(recipe.Spec.Imports).To(BeNil())
+		Expect(recipe.Spec.Hooks).To(HaveLen(2))
+		Expect(recipe.Spec.Hooks[0].Namespace).To(Equal("orders"))
+		Expect(recipe.Spec.Hooks[0].LabelSelector.MatchLabels).To(Equal(map[string]string{"app": "pg"}))
+		Expect(recipe.Spec.Hooks[1].Name).To(Equal("kafka"))
+	})
+	It("fails to render recipes with invalid values", func() {
+		code, _, stderr := runRecipectl("", "render", "-recipe", "orders", "-p", "replicas=2", "testdata/recipes.yaml")
+		Expect(code).To(Equal(1))
+		Expect(stderr).To(Equal(`recipectl render: failed to expand the parameters of recipe default/orders: ` +
+			`spec.parameters: Not found: "replicas"` + "\n"))
+	})
+	It("explains workflows", func() {
+		code, stdout, _ := runRecipectl("", "explain", "-workflow", "backup", "-recipe", "orders",
+			"testdata/recipes.yaml")
+		Expect(code).To(Equal(0))
+		Expect(stdout).To(Equal(`Workflow backup of recipe default/orders, stopping at the first failing step:
+1. run hook db/quiesce on pods matching app=postgres in ns orders, timeout 30s, essential, reverted by db/unquiesce ` +
+			`if the workflow fails
+2. run hook kafka/flush on all pods in ns kafka, timeout 60s, essential
+3. back up volume group data: PVCs matching app=postgres in ns orders, essential
+4. run hook db/unquiesce on pods matching app=postgres in ns orders, timeout 30s, essential
+`))
+	})
+	It("reads v1alpha2 recipes", func() {
+		code, stdout, _ := runRecipectl("", "lint", "testdata/v1alpha2.yaml")
+		Expect(stdout).To(Equal("1 recipes and 0 templates checked: 0 errors, 0 warnings\n"))
+		Expect(code).To(Equal(0))
+
+		code, stdout, _ = runRecipectl("", "explain", "-workflow", "backup", "testdata/v1alpha2.yaml")
+		Expect(code).To(Equal(0))
+		Expect(stdout).To(Equal(`Workflow backup of recipe default/cache, stopping at the first failing step:
+1. run in parallel, at most 5 at a time:
+   1a. run hook redis/save on all pods in ns cache, timeout 120s, essential, errors ignored
+   1b. if namespace cache exists: back up volume group data: PVCs matching app=redis in ns default, essential
+`))
+	})
+	It("prints graphs of recipes", func() {
+		code, stdout, _ := runRecipectl("", "graph", "-format", "mermaid", "-workflow", "backup", "-recipe", "orders",
+			"testdata/recipes.yaml")
+		Expect(code).To(Equal(0))
+		Expect(stdout).To(HavePrefix("---\ntitle: recipe default/orders\n---\nflowchart TB\n"))
+		Expect(stdout).To(ContainSubstring(`n5["2. hook: kafka/flush"]`))
+		Expect(stdout).To(ContainSubstring(`n1 -.->|"inverse"| n2`))
+
+		code, _, stderr := runRecipectl("", "graph", "-format", "png", "testdata/recipes.yaml")
+		Expect(code).To(Equal(1))
+		Expect(stderr).To(ContainSubstring(`unsupported format "png"`))
+	})
+	It("simulates workflows against snapshots in directories and tarballs", func() {
+		tarball := filepath.Join(GinkgoT().TempDir(), "snapshot.tar.gz")
+		writeTarball(tarball, "testdata/snapshot")
+
+		for _, snapshot := range []string{"testdata/snapshot", tarball} {
+			code, stdout, stderr := runRecipectl("", "dry-run", "-workflow", "backup", "-recipe", "orders",
+				"-snapshot", snapshot, "testdata/recipes.yaml")
+			Expect(stderr).To(BeEmpty())
+			Expect(code).To(Equal(0))
+			Expect(stdout).To(HavePrefix("Snapshot " + snapshot + ": 5 objects, 0 of unknown kinds ignored\n"))
+			Expect(stdout).To(ContainSubstring(`
+2. hook: kafka/flush
+   exec into pod kafka/kafka-0, container kafka
+3. group: data (backup)
+   include namespace orders
+   include pvc orders/data-postgres-0
+   exclude pvc orders/wal-archive: not selected
+`))
+		}
+
+		code, _, stderr := runRecipectl("", "dry-run", "-workflow", "backup", "testdata/recipes.yaml")
+		Expect(code).To(Equal(1))
+		Expect(stderr).To(Equal("recipectl dry-run: -snapshot is required\n"))
+	})
+	It("prints usage", func() {
+		code, _, stderr := runRecipectl("", "frobnicate")
+		Expect(code).To(Equal(2))
+		Expect(stderr).To(ContainSubstring("unknown command \"frobnicate\""))
+
+		code, _, stderr = runRecipectl("", "explain", "testdata")
+		Expect(code).To(Equal(1))
+		Expect(stderr).To(Equal("recipectl explain: -workflow is required\n"))
+	})
+})
@@ -0,0 +1,57 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"sigs.k8s.io/yaml"
+)
+
+// render prints the effective spec of recipes: rendered from their template, with their imports
+// resolved and their parameters expanded
+func render(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := newFlagSet("render", "<file or directory>...", stderr)
+	namespace := flags.String("n", "default", "namespace of recipes that do not set one")
+	name := flags.String("recipe", "", "name of the recipe to render, all recipes if empty")
+	values := parameterValues{}
+	flags.Var(values, "p", "value of a parameter as name=value, repeatable")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	in, err := readInputs(flags.Args(), *namespace, stdin)
+	if err != nil {
+		return err
+	}
+
+	recipes, err := in.selectRecipes(*name)
+	if err != nil {
+		return err
+	}
+
+	for i, recipe := range recipes {
+		effective, err := in.effectiveRecipe(recipe, values)
+		if err != nil {
+			return err
+		}
+
+		data, err := yaml.Marshal(effective)
+		if err != nil {
+			return err
+		}
+
+		if i > 0 {
+			fmt.Fprintln(stdout, "---")
+		}
+
+		if _, err := stdout.Write(data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	ramendrv1alpha1 "github.com/ramendr/recipe/api/v1alpha1"
+)
+
+// resolve renders a recipe from its template and resolves its imports with the templates and recipes
+// of the inputs, as the reconciler does with the ones of the cluster
+func (in *inputs) resolve(recipe *ramendrv1alpha1.Recipe) (*ramendrv1alpha1.Recipe, field.ErrorList) {
+	rendered, errs := in.render(recipe)
+	if len(errs) != 0 {
+		return nil, errs
+	}
+
+	// getRecipe fails only with NotFound, which ResolveImports reports as field error
+	resolved, _, errs, _ := ramendrv1alpha1.ResolveImports(rendered, in.getRecipe)
+
+	return resolved, errs
+}
+
+func (in *inputs) render(recipe *ramendrv1alpha1.Recipe) (*ramendrv1alpha1.Recipe, field.ErrorList) {
+	if recipe.Spec.Template == nil {
+		return recipe, nil
+	}
+
+	template := in.findTemplate(recipe.Spec.Template.Name)
+	if template == nil {
+		return nil, field.ErrorList{field.NotFound(field.NewPath("spec", "template", "name"), recipe.Spec.Template.Name)}
+	}
+
+	return ramendrv1alpha1.RenderRecipe(recipe, template)
+}
+
+// getRecipe is the RecipeGetter of the recipes of the inputs
+func (in *inputs) getRecipe(namespace, name string) (*ramendrv1alpha1.Recipe, field.ErrorList, error) {
+	recipe := in.findRecipe(namespace, name)
+	if recipe == nil {
+		return nil, nil, apierrors.NewNotFound(ramendrv1alpha1.GroupVersion.WithResource("recipes").GroupResource(),
+			name)
+	}
+
+	rendered, errs := in.render(recipe)
+	if rendered == nil {
+		return recipe, errs, nil
+	}
+
+	return rendered, errs, nil
+}
+
+// isMissingInput reports whether an error of resolve is a template or imported recipe that is not
+// among the inputs
+func isMissingInput(err *field.Error) bool {
+	return err.Type == field.ErrorTypeNotFound &&
+		(err.Field == "spec.template.name" || strings.HasPrefix(err.Field, "spec.imports[") &&
+			strings.HasSuffix(err.Field, "].recipe"))
+}
+
+// effectiveRecipe returns the recipe the reconciler would run with the given parameter values: the
+// recipe rendered from its template, with its imports resolved and its parameters expanded. The
+// recipe must be valid.
+func (in *inputs) effectiveRecipe(recipe *ramendrv1alpha1.Recipe, values map[string]string,
+) (*ramendrv1alpha1.Recipe, error) {
+	resolved, errs := in.resolve(recipe)
+	if len(errs) != 0 {
+		return nil, fmt.Errorf("recipe %s/%s cannot be resolved: %w", recipe.Namespace, recipe.Name,
+			errs.ToAggregate())
+	}
+
+	expanded, errs := ramendrv1alpha1.ExpandParameters(resolved, values)
+	if len(errs) != 0 {
+		return nil, fmt.Errorf("failed to expand the parameters of recipe %s/%s: %w", recipe.Namespace, recipe.Name,
+			errs.ToAggregate())
+	}
+
+	if errs := ramendrv1alpha1.ValidateRecipe(expanded); len(errs) != 0 {
+		return nil, fmt.Errorf("recipe %s/%s is invalid: %w", recipe.Namespace, recipe.Name, errs.ToAggregate())
+	}
+
+	expanded.Status = ramendrv1alpha1.RecipeStatus{}
+
+	return expanded, nil
+}
+
+// parameterValues are the values of parameters given as -p name=value flags
+type parameterValues map[string]string
+
+func (p parameterValues) String() string {
+	values := make([]string, 0, len(p))
+	for name, value := range p {
+		values = append(values, name+"="+value)
+	}
+
+	return strings.Join(values, ",")
+}
+
+func (p parameterValues) Set(value string) error {
+	name, value, ok := strings.Cut(value, "=")
+	if !ok || name == "" {
+		return fmt.Errorf("%q is not of the form name=value", value)
+	}
+
+	p[name] = value
+
+	return nil
+}
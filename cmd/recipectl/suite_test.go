@@ -0,0 +1,17 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package main
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestRecipectl(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "Recipectl Suite")
+}
@@ -18,11 +18,21 @@ package controllers
 
 import (
 	"context"
+	"slices"
 
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	ramendrv1alpha1 "github.com/ramendr/recipe/api/v1alpha1"
 )
@@ -36,27 +46,172 @@ type RecipeReconciler struct {
 //+kubebuilder:rbac:groups=ramendr.openshift.io,resources=recipes,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=ramendr.openshift.io,resources=recipes/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=ramendr.openshift.io,resources=recipes/finalizers,verbs=update
+//+kubebuilder:rbac:groups=ramendr.openshift.io,resources=recipetemplates,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=namespaces;persistentvolumeclaims;pods,verbs=get;list;watch
+//+kubebuilder:rbac:groups=apps,resources=deployments;statefulsets;replicasets,verbs=get;list;watch
 
-// Reconcile is part of the main kubernetes reconciliation loop which aims to
-// move the current state of the cluster closer to the desired state.
-// TODO(user): Modify the Reconcile function to compare the state specified by
-// the Recipe object against the actual cluster state, and then
-// perform operations to make the cluster state reflect the state specified by
-// the user.
+// Reconcile validates the recipe and publishes the result as conditions and findings in its status,
+// together with a preview of what its groups and hooks currently select. A recipe referencing a
+// template is rendered from the template first, and the groups and hooks it imports are added to its
+// own. The resulting spec, the generation of the template and the recipes imported from are recorded
+// in the status.
 //
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.13.0/pkg/reconcile
 func (r *RecipeReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	_ = log.FromContext(ctx)
+	logger := log.FromContext(ctx)
 
-	// TODO(user): your logic here
+	recipe := &ramendrv1alpha1.Recipe{}
+	if err := r.Get(ctx, req.NamespacedName, recipe); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	oldStatus := recipe.Status.DeepCopy()
+
+	rendered, template, errs, err := renderRecipe(ctx, r.Client, recipe)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	var dependencies []ramendrv1alpha1.RecipeDependency
+
+	if len(errs) == 0 {
+		rendered, dependencies, errs, err = resolveImports(ctx, r.Client, rendered)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	recipe.Status.Template = nil
+	recipe.Status.EffectiveSpec = nil
+	recipe.Status.Dependencies = dependencies
+
+	if template != nil {
+		recipe.Status.Template = &ramendrv1alpha1.RenderedTemplate{Name: template.Name, Generation: template.Generation}
+	}
+
+	if rendered != nil && (template != nil || len(dependencies) != 0) {
+		recipe.Status.EffectiveSpec = rendered.Spec.DeepCopy()
+	}
+
+	if len(errs) != 0 {
+		recipe.Status.Selections = nil
+		setRecipeStatus(recipe, errs, nil)
+	} else {
+		r.validate(ctx, recipe, rendered)
+	}
+
+	if equality.Semantic.DeepEqual(oldStatus, &recipe.Status) {
+		return ctrl.Result{}, nil
+	}
+
+	if err := r.Status().Update(ctx, recipe); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	logger.Info("updated status", "generation", recipe.Generation,
+		"ready", meta.IsStatusConditionTrue(recipe.Status.Conditions, ramendrv1alpha1.RecipeConditionReady))
 
 	return ctrl.Result{}, nil
 }
 
-// SetupWithManager sets up the controller with the Manager.
+// validate records the findings of validating the rendered recipe and the preview of its selections
+// in the status of the recipe
+func (r *RecipeReconciler) validate(ctx context.Context, recipe, rendered *ramendrv1alpha1.Recipe) {
+	// selections are previewed with the defaults of the parameters, references to parameters without
+	// default are left in place and fail to resolve
+	expanded, expandErrs := ramendrv1alpha1.ExpandParameters(rendered, nil)
+	selections, previewWarnings := r.previewSelections(ctx, expanded)
+	recipe.Status.Selections = selections
+
+	// other expansion errors are validation errors
+	warnings := ramendrv1alpha1.RecipeWarnings(rendered)
+	for _, err := range expandErrs {
+		if err.Type == field.ErrorTypeRequired {
+			warnings = append(warnings, err)
+		}
+	}
+
+	warnings = append(warnings, previewWarnings...)
+
+	setRecipeStatus(recipe, ramendrv1alpha1.ValidateRecipe(rendered), warnings)
+}
+
+// SetupWithManager sets up the controller with the Manager. Recipes are reconciled again when the
+// labels of objects their groups and hooks may select change, and when their template or recipes
+// they import from change.
 func (r *RecipeReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	selectable := handler.EnqueueRequestsFromMapFunc(r.recipesForObject)
+	labelsChanged := builder.WithPredicates(predicate.LabelChangedPredicate{})
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&ramendrv1alpha1.Recipe{}).
+		Watches(&ramendrv1alpha1.RecipeTemplate{}, handler.EnqueueRequestsFromMapFunc(r.recipesForTemplate),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&ramendrv1alpha1.Recipe{}, handler.EnqueueRequestsFromMapFunc(r.recipesForImported)).
+		Watches(&corev1.Namespace{}, selectable, labelsChanged).
+		Watches(&corev1.PersistentVolumeClaim{}, selectable, labelsChanged).
+		Watches(&corev1.Pod{}, selectable, labelsChanged).
+		Watches(&appsv1.Deployment{}, selectable, labelsChanged).
+		Watches(&appsv1.StatefulSet{}, selectable, labelsChanged).
 		Complete(r)
 }
+
+// recipesForObject maps an object to the recipes whose groups or hooks may select it
+func (r *RecipeReconciler) recipesForObject(ctx context.Context, obj client.Object) []reconcile.Request {
+	recipeList := &ramendrv1alpha1.RecipeList{}
+	if err := r.List(ctx, recipeList); err != nil {
+		log.FromContext(ctx).Error(err, "failed to list recipes")
+
+		return nil
+	}
+
+	namespace := obj.GetNamespace()
+	if namespace == "" {
+		// namespaces are the only cluster scoped kind watched
+		namespace = obj.GetName()
+	}
+
+	requests := []reconcile.Request{}
+
+	for i := range recipeList.Items {
+		if recipeMaySelect(&recipeList.Items[i], namespace) {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&recipeList.Items[i])})
+		}
+	}
+
+	return requests
+}
+
+// recipeMaySelect reports whether groups or hooks of the recipe may select objects in a namespace,
+// with the defaults of its parameters and as last rendered from its template with its imports
+func recipeMaySelect(recipe *ramendrv1alpha1.Recipe, namespace string) bool {
+	if recipe.Namespace == namespace {
+		return true
+	}
+
+	if recipe.Status.EffectiveSpec != nil {
+		recipe = &ramendrv1alpha1.Recipe{ObjectMeta: recipe.ObjectMeta, Spec: *recipe.Status.EffectiveSpec}
+	}
+
+	recipe, _ = ramendrv1alpha1.ExpandParameters(recipe, nil)
+
+	groups := slices.Clone(recipe.Spec.Groups)
+	if recipe.Spec.Volumes != nil {
+		groups = append(groups, recipe.Spec.Volumes)
+	}
+
+	for _, group := range groups {
+		if group != nil && (group.IncludedNamespacesByLabel != nil || slices.Contains(group.IncludedNamespaces, namespace)) {
+			return true
+		}
+	}
+
+	for _, hook := range recipe.Spec.Hooks {
+		if hook != nil && hook.Namespace == namespace {
+			return true
+		}
+	}
+
+	return false
+}
@@ -12,6 +12,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	validationErrors "k8s.io/kube-openapi/pkg/validation/errors"
+	"k8s.io/utils/ptr"
 
 	Recipe "github.com/ramendr/recipe/api/v1alpha1"
 )
@@ -84,6 +85,80 @@ var _ = Describe("RecipeController", func() {
 
 			Expect(err).ToNot(BeNil())
 		})
+		It("allow volume selection fields on volume groups", func() {
+			recipe := &Recipe.Recipe{
+				TypeMeta:   metav1.TypeMeta{Kind: "Recipe", APIVersion: "ramendr.openshift.io/v1alpha1"},
+				ObjectMeta: metav1.ObjectMeta{Name: "test-recipe", Namespace: testNamespace.Name},
+				Spec: Recipe.RecipeSpec{
+					Groups: []*Recipe.Group{
+						{
+							Name:           "group-1",
+							Type:           "volume",
+							NameSelector:   "glob:data-*",
+							SelectResource: "pod",
+						},
+					},
+					Hooks: []*Recipe.Hook{},
+				},
+			}
+
+			err := k8sClient.Create(context.TODO(), recipe)
+
+			Expect(err).To(BeNil())
+		})
+		It("error on fields of the other group type", func() {
+			for _, group := range []*Recipe.Group{
+				{Name: "group-1", Type: "resource", NameSelector: "glob:data-*"},
+				{Name: "group-1", Type: "resource", SelectResource: "pod"},
+				{Name: "group-1", Type: "volume", RestoreStatus: &Recipe.GroupRestoreStatus{}},
+				{Name: "group-1", Type: "volume", RestoreOverwriteResources: ptr.To(true)},
+			} {
+				recipe := &Recipe.Recipe{
+					TypeMeta:   metav1.TypeMeta{Kind: "Recipe", APIVersion: "ramendr.openshift.io/v1alpha1"},
+					ObjectMeta: metav1.ObjectMeta{Name: "test-recipe", Namespace: testNamespace.Name},
+					Spec: Recipe.RecipeSpec{
+						Groups: []*Recipe.Group{group},
+						Hooks:  []*Recipe.Hook{},
+					},
+				}
+
+				err := k8sClient.Create(context.TODO(), recipe)
+
+				Expect(err).To(MatchError(ContainSubstring("is valid for")), "group %+v", group)
+			}
+		})
+	})
+
+	Context("Imports", func() {
+		It("allow imports of groups or hooks", func() {
+			recipe := &Recipe.Recipe{
+				TypeMeta:   metav1.TypeMeta{Kind: "Recipe", APIVersion: "ramendr.openshift.io/v1alpha1"},
+				ObjectMeta: metav1.ObjectMeta{Name: "test-recipe", Namespace: testNamespace.Name},
+				Spec: Recipe.RecipeSpec{
+					Imports: []*Recipe.Import{
+						{Recipe: "kafka-hooks", Namespace: "shared", Hooks: []string{"kafka"}},
+						{Recipe: "config", Groups: []string{"config"}},
+					},
+				},
+			}
+
+			err := k8sClient.Create(context.TODO(), recipe)
+
+			Expect(err).To(BeNil())
+		})
+		It("error on imports naming nothing", func() {
+			recipe := &Recipe.Recipe{
+				TypeMeta:   metav1.TypeMeta{Kind: "Recipe", APIVersion: "ramendr.openshift.io/v1alpha1"},
+				ObjectMeta: metav1.ObjectMeta{Name: "test-recipe", Namespace: testNamespace.Name},
+				Spec: Recipe.RecipeSpec{
+					Imports: []*Recipe.Import{{Recipe: "kafka-hooks", Hooks: []string{}}},
+				},
+			}
+
+			err := k8sClient.Create(context.TODO(), recipe)
+
+			Expect(err).To(MatchError(ContainSubstring("an import must name groups or hooks")))
+		})
 	})
 
 	Context("Hooks", func() {
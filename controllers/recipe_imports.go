@@ -0,0 +1,75 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package controllers
+
+import (
+	"context"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	ramendrv1alpha1 "github.com/ramendr/recipe/api/v1alpha1"
+)
+
+// resolveImports returns the recipe with its imports resolved, rendering the recipes it imports
+// from from their templates, and the recipes it depends on
+func resolveImports(ctx context.Context, reader client.Reader, recipe *ramendrv1alpha1.Recipe,
+) (*ramendrv1alpha1.Recipe, []ramendrv1alpha1.RecipeDependency, field.ErrorList, error) {
+	return ramendrv1alpha1.ResolveImports(recipe, func(namespace, name string,
+	) (*ramendrv1alpha1.Recipe, field.ErrorList, error) {
+		imported := &ramendrv1alpha1.Recipe{}
+		if err := reader.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, imported); err != nil {
+			return nil, nil, err
+		}
+
+		rendered, _, errs, err := renderRecipe(ctx, reader, imported)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if rendered == nil {
+			return imported, errs, nil
+		}
+
+		return rendered, errs, nil
+	})
+}
+
+// recipesForImported maps a Recipe to the recipes importing from it, directly or through other
+// recipes
+func (r *RecipeReconciler) recipesForImported(ctx context.Context, obj client.Object) []reconcile.Request {
+	recipeList := &ramendrv1alpha1.RecipeList{}
+	if err := r.List(ctx, recipeList); err != nil {
+		log.FromContext(ctx).Error(err, "failed to list recipes")
+
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+
+	for i := range recipeList.Items {
+		if recipeImports(&recipeList.Items[i], obj.GetNamespace(), obj.GetName()) {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&recipeList.Items[i])})
+		}
+	}
+
+	return requests
+}
+
+// recipeImports reports whether the recipe imports from a recipe, as of its spec or its last
+// resolved dependencies
+func recipeImports(recipe *ramendrv1alpha1.Recipe, namespace, name string) bool {
+	for _, imp := range recipe.Spec.Imports {
+		if imp != nil && imp.Recipe == name && imp.ImportNamespace(recipe.Namespace) == namespace {
+			return true
+		}
+	}
+
+	return slices.ContainsFunc(recipe.Status.Dependencies, func(dependency ramendrv1alpha1.RecipeDependency) bool {
+		return dependency.Namespace == namespace && dependency.Name == name
+	})
+}
@@ -0,0 +1,108 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	ramendrv1alpha1 "github.com/ramendr/recipe/api/v1alpha1"
+	"github.com/ramendr/recipe/pkg/selector"
+)
+
+const selectionKindVolumes = "volumes"
+
+// previewSelections resolves the groups and hooks of the recipe against the cluster. Groups and
+// hooks that select nothing are returned as warnings.
+func (r *RecipeReconciler) previewSelections(ctx context.Context, recipe *ramendrv1alpha1.Recipe,
+) ([]ramendrv1alpha1.SelectionPreview, field.ErrorList) {
+	resolver := &selector.Resolver{Reader: r.Client, DefaultNamespace: recipe.Namespace}
+	specPath := field.NewPath("spec")
+	previews := []ramendrv1alpha1.SelectionPreview{}
+	warnings := field.ErrorList{}
+
+	previewGroup := func(kind string, group *ramendrv1alpha1.Group, path *field.Path) {
+		selection, err := resolver.ResolveGroup(ctx, group)
+		previews = append(previews, newSelectionPreview(kind, group.Name, selection, err))
+
+		if err != nil {
+			return
+		}
+
+		if group.Type == "volume" && selection.Count(selector.KindPVC) == 0 {
+			warnings = append(warnings, field.Invalid(path, group.Name,
+				fmt.Sprintf("selects no PVCs in namespaces %q", selection.Namespaces)))
+		}
+	}
+
+	for i, group := range recipe.Spec.Groups {
+		if group != nil {
+			previewGroup(ramendrv1alpha1.StepKindGroup, group, specPath.Child("groups").Index(i))
+		}
+	}
+
+	if recipe.Spec.Volumes != nil {
+		previewGroup(selectionKindVolumes, recipe.Spec.Volumes, specPath.Child("volumes"))
+	}
+
+	for i, hook := range recipe.Spec.Hooks {
+		if hook == nil {
+			continue
+		}
+
+		selection, err := resolver.ResolveHook(ctx, hook)
+		previews = append(previews, newSelectionPreview(ramendrv1alpha1.StepKindHook, hook.Name, selection, err))
+
+		// scale hooks act on workloads, which have no pods while scaled down
+		kind := selector.KindPod
+		if hook.Type == ramendrv1alpha1.HookTypeScale && hook.SelectResource != "" {
+			kind = hook.SelectResource
+		}
+
+		if err == nil && selection.Count(kind) == 0 {
+			warnings = append(warnings, field.Invalid(specPath.Child("hooks").Index(i), hook.Name,
+				fmt.Sprintf("selects no %ss in namespace %q", kind, resolver.HookNamespace(hook))))
+		}
+	}
+
+	return previews, warnings
+}
+
+func newSelectionPreview(kind, name string, selection *selector.Selection, err error,
+) ramendrv1alpha1.SelectionPreview {
+	preview := ramendrv1alpha1.SelectionPreview{Kind: kind, Name: name}
+
+	if err != nil {
+		preview.Error = err.Error()
+
+		return preview
+	}
+
+	preview.Namespaces = selection.Namespaces
+	preview.Counts = ramendrv1alpha1.SelectionCounts{
+		Namespaces:   int32(selection.Count(selector.KindNamespace)),
+		PVCs:         int32(selection.Count(selector.KindPVC)),
+		Pods:         int32(selection.Count(selector.KindPod)),
+		Deployments:  int32(selection.Count(selector.KindDeployment)),
+		StatefulSets: int32(selection.Count(selector.KindStatefulSet)),
+	}
+
+	for _, obj := range selection.Objects {
+		if obj.Kind == selector.KindNamespace {
+			continue
+		}
+
+		if len(preview.Objects) == ramendrv1alpha1.MaxSelectionPreviewObjects {
+			preview.Truncated = true
+
+			break
+		}
+
+		preview.Objects = append(preview.Objects, obj.String())
+	}
+
+	return preview
+}
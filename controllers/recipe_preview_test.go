@@ -0,0 +1,111 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	ramendrv1alpha1 "github.com/ramendr/recipe/api/v1alpha1"
+)
+
+func TestPreviewSelections(t *testing.T) {
+	g := NewWithT(t)
+	web := map[string]string{"app": "web"}
+
+	objs := []client.Object{}
+	for i := range ramendrv1alpha1.MaxSelectionPreviewObjects + 5 {
+		objs = append(objs, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Namespace: "app", Name: fmt.Sprintf("web-%02d", i), Labels: web,
+		}})
+	}
+
+	reconciler := &RecipeReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(objs...).Build(),
+	}
+	recipe := &ramendrv1alpha1.Recipe{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "app"},
+		Spec: ramendrv1alpha1.RecipeSpec{
+			Groups: []*ramendrv1alpha1.Group{{Name: "data", Type: "volume"}},
+			Hooks: []*ramendrv1alpha1.Hook{
+				{Name: "web", Type: "exec", LabelSelector: &metav1.LabelSelector{MatchLabels: web}},
+				{Name: "cache", Type: "exec", NameSelector: "cache"},
+			},
+		},
+	}
+
+	previews, warnings := reconciler.previewSelections(context.TODO(), recipe)
+	g.Expect(previews).To(HaveLen(3))
+
+	hook := previews[1]
+	g.Expect(hook.Name).To(Equal("web"))
+	g.Expect(hook.Counts.Pods).To(Equal(int32(ramendrv1alpha1.MaxSelectionPreviewObjects + 5)))
+	g.Expect(hook.Objects).To(HaveLen(ramendrv1alpha1.MaxSelectionPreviewObjects))
+	g.Expect(hook.Objects[0]).To(ContainSubstring("web-00"))
+	g.Expect(hook.Truncated).To(BeTrue())
+
+	g.Expect(previews[2].Objects).To(BeEmpty())
+	g.Expect(previews[2].Truncated).To(BeFalse())
+
+	fields := []string{}
+	for _, warning := range warnings {
+		fields = append(fields, warning.Field)
+	}
+
+	g.Expect(fields).To(Equal([]string{
+		field.NewPath("spec", "groups").Index(0).String(),
+		field.NewPath("spec", "hooks").Index(1).String(),
+	}))
+}
+
+func TestRecipeMaySelect(t *testing.T) {
+	g := NewWithT(t)
+
+	recipe := &ramendrv1alpha1.Recipe{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "app"},
+		Spec: ramendrv1alpha1.RecipeSpec{
+			Parameters: []*ramendrv1alpha1.Parameter{{Name: "DB_NAMESPACE", Default: ptr.To("db")}},
+			Groups: []*ramendrv1alpha1.Group{
+				{Name: "config", Type: "resource", IncludedNamespaces: []string{"config"}},
+				{Name: "db", Type: "volume", IncludedNamespaces: []string{"${DB_NAMESPACE}"}},
+			},
+			Hooks: []*ramendrv1alpha1.Hook{{Name: "cache", Type: "exec", Namespace: "cache"}},
+		},
+	}
+
+	for namespace, selects := range map[string]bool{
+		"app":    true,
+		"config": true,
+		"db":     true,
+		"cache":  true,
+		"other":  false,
+	} {
+		g.Expect(recipeMaySelect(recipe, namespace)).To(Equal(selects), "namespace %s", namespace)
+	}
+
+	// the effective spec replaces the spec, e.g. for recipes rendered from a template
+	recipe.Status.EffectiveSpec = &ramendrv1alpha1.RecipeSpec{
+		Volumes: &ramendrv1alpha1.Group{Name: "volumes", Type: "volume", IncludedNamespaces: []string{"volumes"}},
+	}
+	g.Expect(recipeMaySelect(recipe, "volumes")).To(BeTrue())
+	g.Expect(recipeMaySelect(recipe, "config")).To(BeFalse())
+	g.Expect(recipeMaySelect(recipe, "app")).To(BeTrue())
+
+	// namespaces selected by label may be any namespace
+	recipe.Status.EffectiveSpec.Groups = []*ramendrv1alpha1.Group{{
+		Name: "labeled", Type: "resource", IncludedNamespacesByLabel: &metav1.LabelSelector{},
+	}}
+	g.Expect(recipeMaySelect(recipe, "other")).To(BeTrue())
+}
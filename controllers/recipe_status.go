@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package controllers
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	ramendrv1alpha1 "github.com/ramendr/recipe/api/v1alpha1"
+)
+
+// setRecipeStatus records the result of validating the current generation of the recipe. Errors
+// about references that cannot be resolved turn Resolved false, all others turn Valid false.
+// Warnings are only reported as findings.
+func setRecipeStatus(recipe *ramendrv1alpha1.Recipe, errs, warnings field.ErrorList) {
+	var invalid, unresolved field.ErrorList
+
+	for _, err := range errs {
+		if ramendrv1alpha1.IsUnresolved(err) {
+			unresolved = append(unresolved, err)
+		} else {
+			invalid = append(invalid, err)
+		}
+	}
+
+	recipe.Status.ObservedGeneration = recipe.Generation
+	recipe.Status.Findings = append(
+		ramendrv1alpha1.Findings(errs, ramendrv1alpha1.FindingSeverityError),
+		ramendrv1alpha1.Findings(warnings, ramendrv1alpha1.FindingSeverityWarning)...)
+
+	setRecipeCondition(recipe, ramendrv1alpha1.RecipeConditionValid, invalid,
+		ramendrv1alpha1.RecipeReasonValid, ramendrv1alpha1.RecipeReasonValidationFailed)
+	setRecipeCondition(recipe, ramendrv1alpha1.RecipeConditionResolved, unresolved,
+		ramendrv1alpha1.RecipeReasonResolved, ramendrv1alpha1.RecipeReasonUnresolved)
+
+	ready := metav1.Condition{
+		Type:               ramendrv1alpha1.RecipeConditionReady,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: recipe.Generation,
+		Reason:             ramendrv1alpha1.RecipeReasonReady,
+		Message:            "recipe is ready to be used",
+	}
+
+	for _, conditionType := range []string{
+		ramendrv1alpha1.RecipeConditionValid,
+		ramendrv1alpha1.RecipeConditionResolved,
+	} {
+		condition := meta.FindStatusCondition(recipe.Status.Conditions, conditionType)
+		if condition.Status != metav1.ConditionTrue {
+			ready.Status = metav1.ConditionFalse
+			ready.Reason = condition.Reason
+			ready.Message = condition.Message
+
+			break
+		}
+	}
+
+	meta.SetStatusCondition(&recipe.Status.Conditions, ready)
+}
+
+func setRecipeCondition(recipe *ramendrv1alpha1.Recipe, conditionType string, errs field.ErrorList,
+	trueReason, falseReason string,
+) {
+	condition := metav1.Condition{
+		Type:               conditionType,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: recipe.Generation,
+		Reason:             trueReason,
+	}
+
+	switch len(errs) {
+	case 0:
+	case 1:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = falseReason
+		condition.Message = errs[0].Error()
+	default:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = falseReason
+		condition.Message = fmt.Sprintf("%s (and %d more, see status.findings)", errs[0].Error(), len(errs)-1)
+	}
+
+	meta.SetStatusCondition(&recipe.Status.Conditions, condition)
+}
@@ -0,0 +1,140 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package controllers
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	ramendrv1alpha1 "github.com/ramendr/recipe/api/v1alpha1"
+)
+
+// conditions returns the status, reason and message of the Valid, Resolved and Ready conditions
+func conditions(recipe *ramendrv1alpha1.Recipe) map[string][3]string {
+	result := map[string][3]string{}
+
+	for _, conditionType := range []string{
+		ramendrv1alpha1.RecipeConditionValid,
+		ramendrv1alpha1.RecipeConditionResolved,
+		ramendrv1alpha1.RecipeConditionReady,
+	} {
+		condition := meta.FindStatusCondition(recipe.Status.Conditions, conditionType)
+		if condition != nil {
+			result[conditionType] = [3]string{string(condition.Status), condition.Reason, condition.Message}
+		}
+	}
+
+	return result
+}
+
+func TestSetRecipeStatus(t *testing.T) {
+	groupsPath := field.NewPath("spec", "groups")
+	invalid := field.Invalid(groupsPath.Index(0).Child("backupRef"), "config", "must name another group")
+	unresolved := field.NotFound(field.NewPath("spec", "workflows").Index(0).Child("sequence").Index(0).
+		Key("group"), "confg")
+	warning := field.Invalid(groupsPath.Index(1).Child("nameSelector"), "Db", "can never match")
+
+	newRecipe := func() *ramendrv1alpha1.Recipe {
+		return &ramendrv1alpha1.Recipe{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "app", Generation: 3}}
+	}
+
+	t.Run("errors only", func(t *testing.T) {
+		g := NewWithT(t)
+		recipe := newRecipe()
+
+		setRecipeStatus(recipe, field.ErrorList{invalid}, nil)
+
+		g.Expect(recipe.Status.ObservedGeneration).To(Equal(int64(3)))
+		g.Expect(recipe.Status.Findings).To(Equal(ramendrv1alpha1.Findings(field.ErrorList{invalid},
+			ramendrv1alpha1.FindingSeverityError)))
+		g.Expect(conditions(recipe)).To(Equal(map[string][3]string{
+			ramendrv1alpha1.RecipeConditionValid: {
+				"False", ramendrv1alpha1.RecipeReasonValidationFailed, invalid.Error(),
+			},
+			ramendrv1alpha1.RecipeConditionResolved: {"True", ramendrv1alpha1.RecipeReasonResolved, ""},
+			ramendrv1alpha1.RecipeConditionReady: {
+				"False", ramendrv1alpha1.RecipeReasonValidationFailed, invalid.Error(),
+			},
+		}))
+
+		for _, condition := range recipe.Status.Conditions {
+			g.Expect(condition.ObservedGeneration).To(Equal(int64(3)))
+		}
+	})
+	t.Run("warnings only", func(t *testing.T) {
+		g := NewWithT(t)
+		recipe := newRecipe()
+
+		setRecipeStatus(recipe, nil, field.ErrorList{warning})
+
+		g.Expect(recipe.Status.Findings).To(Equal([]ramendrv1alpha1.Finding{{
+			Severity: ramendrv1alpha1.FindingSeverityWarning,
+			Path:     warning.Field,
+			Type:     string(warning.Type),
+			Message:  warning.ErrorBody(),
+		}}))
+		g.Expect(conditions(recipe)).To(Equal(map[string][3]string{
+			ramendrv1alpha1.RecipeConditionValid:    {"True", ramendrv1alpha1.RecipeReasonValid, ""},
+			ramendrv1alpha1.RecipeConditionResolved: {"True", ramendrv1alpha1.RecipeReasonResolved, ""},
+			ramendrv1alpha1.RecipeConditionReady: {
+				"True", ramendrv1alpha1.RecipeReasonReady, "recipe is ready to be used",
+			},
+		}))
+	})
+	t.Run("unresolved references", func(t *testing.T) {
+		g := NewWithT(t)
+		recipe := newRecipe()
+
+		setRecipeStatus(recipe, field.ErrorList{unresolved}, field.ErrorList{warning})
+
+		g.Expect(recipe.Status.Findings).To(HaveLen(2))
+		g.Expect(recipe.Status.Findings[0].Severity).To(Equal(ramendrv1alpha1.FindingSeverityError))
+		g.Expect(recipe.Status.Findings[1].Severity).To(Equal(ramendrv1alpha1.FindingSeverityWarning))
+		g.Expect(conditions(recipe)).To(Equal(map[string][3]string{
+			ramendrv1alpha1.RecipeConditionValid: {"True", ramendrv1alpha1.RecipeReasonValid, ""},
+			ramendrv1alpha1.RecipeConditionResolved: {
+				"False", ramendrv1alpha1.RecipeReasonUnresolved, unresolved.Error(),
+			},
+			ramendrv1alpha1.RecipeConditionReady: {
+				"False", ramendrv1alpha1.RecipeReasonUnresolved, unresolved.Error(),
+			},
+		}))
+	})
+	t.Run("more findings than the message reports", func(t *testing.T) {
+		g := NewWithT(t)
+		recipe := newRecipe()
+
+		setRecipeStatus(recipe, field.ErrorList{invalid, unresolved, invalid, invalid}, nil)
+
+		message := invalid.Error() + " (and 2 more, see status.findings)"
+		g.Expect(recipe.Status.Findings).To(HaveLen(4))
+		g.Expect(conditions(recipe)).To(Equal(map[string][3]string{
+			ramendrv1alpha1.RecipeConditionValid: {"False", ramendrv1alpha1.RecipeReasonValidationFailed, message},
+			ramendrv1alpha1.RecipeConditionResolved: {
+				"False", ramendrv1alpha1.RecipeReasonUnresolved, unresolved.Error(),
+			},
+			ramendrv1alpha1.RecipeConditionReady: {"False", ramendrv1alpha1.RecipeReasonValidationFailed, message},
+		}))
+	})
+	t.Run("findings cleared by a later generation", func(t *testing.T) {
+		g := NewWithT(t)
+		recipe := newRecipe()
+
+		setRecipeStatus(recipe, field.ErrorList{invalid, unresolved}, field.ErrorList{warning})
+
+		recipe.Generation = 4
+		setRecipeStatus(recipe, nil, nil)
+
+		g.Expect(recipe.Status.ObservedGeneration).To(Equal(int64(4)))
+		g.Expect(recipe.Status.Findings).To(BeEmpty())
+		g.Expect(recipe.Status.Conditions).To(HaveLen(3))
+		g.Expect(meta.IsStatusConditionTrue(recipe.Status.Conditions, ramendrv1alpha1.RecipeConditionReady)).
+			To(BeTrue())
+	})
+}
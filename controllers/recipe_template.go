@@ -0,0 +1,62 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package controllers
+
+import (
+	"context"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	ramendrv1alpha1 "github.com/ramendr/recipe/api/v1alpha1"
+)
+
+// renderRecipe returns the recipe rendered from the template it references together with the
+// template, or the recipe itself if it references none. A missing template and invalid values are
+// returned as field errors, other errors of reading the template as error.
+func renderRecipe(ctx context.Context, reader client.Reader, recipe *ramendrv1alpha1.Recipe,
+) (*ramendrv1alpha1.Recipe, *ramendrv1alpha1.RecipeTemplate, field.ErrorList, error) {
+	if recipe.Spec.Template == nil {
+		return recipe, nil, nil, nil
+	}
+
+	template := &ramendrv1alpha1.RecipeTemplate{}
+	if err := reader.Get(ctx, client.ObjectKey{Name: recipe.Spec.Template.Name}, template); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil, nil, field.ErrorList{
+				field.NotFound(field.NewPath("spec", "template", "name"), recipe.Spec.Template.Name),
+			}, nil
+		}
+
+		return nil, nil, nil, err
+	}
+
+	rendered, errs := ramendrv1alpha1.RenderRecipe(recipe, template)
+
+	return rendered, template, errs, nil
+}
+
+// recipesForTemplate maps a RecipeTemplate to the recipes referencing it
+func (r *RecipeReconciler) recipesForTemplate(ctx context.Context, obj client.Object) []reconcile.Request {
+	recipeList := &ramendrv1alpha1.RecipeList{}
+	if err := r.List(ctx, recipeList); err != nil {
+		log.FromContext(ctx).Error(err, "failed to list recipes")
+
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+
+	for i := range recipeList.Items {
+		template := recipeList.Items[i].Spec.Template
+		if template != nil && template.Name == obj.GetName() {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&recipeList.Items[i])})
+		}
+	}
+
+	return requests
+}
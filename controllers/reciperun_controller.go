@@ -0,0 +1,181 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	ramendrv1alpha1 "github.com/ramendr/recipe/api/v1alpha1"
+	"github.com/ramendr/recipe/pkg/workflow"
+)
+
+// maxConcurrentRuns is the number of RecipeRuns executing at the same time. Each run occupies a
+// worker of the controller until its workflow completed, so further runs wait for a free worker.
+const maxConcurrentRuns = 4
+
+// RecipeRunReconciler runs the workflow of each RecipeRun once and records the run in its status
+type RecipeRunReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	// Engine runs the workflows. Runs of workflows with group steps are failed before they start
+	// unless it has a GroupExecutor.
+	Engine *workflow.Engine
+}
+
+//+kubebuilder:rbac:groups=ramendr.openshift.io,resources=reciperuns,verbs=get;list;watch
+//+kubebuilder:rbac:groups=ramendr.openshift.io,resources=reciperuns/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=ramendr.openshift.io,resources=reciperuns/finalizers,verbs=update
+//+kubebuilder:rbac:groups=ramendr.openshift.io,resources=recipes,verbs=get;list;watch
+//+kubebuilder:rbac:groups=ramendr.openshift.io,resources=recipetemplates,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=pods/exec,verbs=create
+//+kubebuilder:rbac:groups=apps,resources=deployments;statefulsets;replicasets,verbs=get;list;watch;update;patch
+
+// Reconcile runs the workflow of a new RecipeRun, with the recipe rendered from its template if it
+// references one, its imports resolved, and its parameters expanded with the values of the run. The
+// run is recorded as Running before the workflow starts, and with its outcome and the timeline of
+// its steps when it completed. Runs of workflows backing up or restoring groups fail without
+// starting if the Engine has no GroupExecutor. A run found Running was interrupted, e.g. by a restart of the
+// operator, and is failed since workflows cannot be resumed safely.
+//
+// The workflow runs synchronously: Reconcile returns only when it completed, which takes as long as
+// its hooks, bounded by their timeouts and retries. A run therefore blocks a worker of the
+// controller meanwhile, and at most maxConcurrentRuns run at the same time. A RecipeRun is never
+// reconciled by two workers at once, so the events of its own status updates are handled once its
+// run completed.
+func (r *RecipeRunReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	run := &ramendrv1alpha1.RecipeRun{}
+	if err := r.Get(ctx, req.NamespacedName, run); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	switch run.Status.Phase {
+	case ramendrv1alpha1.RecipeRunSucceeded, ramendrv1alpha1.RecipeRunFailed:
+		return ctrl.Result{}, nil
+	case ramendrv1alpha1.RecipeRunRunning:
+		return ctrl.Result{}, r.complete(ctx, run, nil,
+			errors.New("run was interrupted before it completed, workflows are not resumed"))
+	}
+
+	recipe := &ramendrv1alpha1.Recipe{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: run.Namespace, Name: run.Spec.Recipe}, recipe); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return ctrl.Result{}, r.complete(ctx, run, nil, fmt.Errorf("recipe %q not found", run.Spec.Recipe))
+		}
+
+		return ctrl.Result{}, err
+	}
+
+	recipe, template, errs, err := renderRecipe(ctx, r.Client, recipe)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if len(errs) != 0 {
+		return ctrl.Result{}, r.complete(ctx, run, nil,
+			fmt.Errorf("failed to render recipe %q from its template: %w", run.Spec.Recipe, errs.ToAggregate()))
+	}
+
+	recipe, _, errs, err = resolveImports(ctx, r.Client, recipe)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if len(errs) != 0 {
+		return ctrl.Result{}, r.complete(ctx, run, nil,
+			fmt.Errorf("failed to resolve the imports of recipe %q: %w", run.Spec.Recipe, errs.ToAggregate()))
+	}
+
+	recipe, errs = ramendrv1alpha1.ExpandParameters(recipe, run.Spec.Parameters)
+	if len(errs) != 0 {
+		return ctrl.Result{}, r.complete(ctx, run, nil,
+			fmt.Errorf("failed to expand the parameters of recipe %q: %w", run.Spec.Recipe, errs.ToAggregate()))
+	}
+
+	if errs := ramendrv1alpha1.ValidateRecipe(recipe); len(errs) != 0 {
+		return ctrl.Result{}, r.complete(ctx, run, nil,
+			fmt.Errorf("recipe %q is invalid: %w", recipe.Name, errs.ToAggregate()))
+	}
+
+	if r.Engine.Groups == nil && recipe.Spec.HasGroupSteps(run.Spec.Workflow) {
+		return ctrl.Result{}, r.complete(ctx, run, nil, fmt.Errorf(
+			"workflow %q of recipe %q backs up or restores groups, which is not supported by RecipeRuns",
+			run.Spec.Workflow, recipe.Name))
+	}
+
+	now := metav1.Now()
+	run.Status = ramendrv1alpha1.RecipeRunStatus{
+		Phase:            ramendrv1alpha1.RecipeRunRunning,
+		RecipeGeneration: recipe.Generation,
+		StartTime:        &now,
+	}
+
+	if template != nil {
+		run.Status.TemplateGeneration = template.Generation
+	}
+
+	if err := r.Status().Update(ctx, run); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	logger.Info("running workflow", "recipe", recipe.Name, "workflow", run.Spec.Workflow,
+		"recipeGeneration", recipe.Generation)
+
+	result, err := r.Engine.Run(ctx, recipe, run.Spec.Workflow)
+
+	return ctrl.Result{}, r.complete(ctx, run, result, err)
+}
+
+// complete records the outcome of a run. The status is written again on conflicts, so that the
+// record is not lost when the RecipeRun was modified while the workflow ran. A run found completed
+// meanwhile is left as is, as its stale copy in the cache was taken for an interrupted run.
+func (r *RecipeRunReconciler) complete(ctx context.Context, run *ramendrv1alpha1.RecipeRun,
+	result *workflow.Result, runErr error,
+) error {
+	status := newRecipeRunStatus(run.Status, result, runErr)
+	key := client.ObjectKeyFromObject(run)
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := r.Get(ctx, key, run); err != nil {
+			return err
+		}
+
+		if run.Status.Phase == ramendrv1alpha1.RecipeRunSucceeded || run.Status.Phase == ramendrv1alpha1.RecipeRunFailed {
+			status = run.Status
+
+			return nil
+		}
+
+		run.Status = status
+
+		return r.Status().Update(ctx, run)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record the outcome of the run: %w", err)
+	}
+
+	log.FromContext(ctx).Info("run completed", "phase", status.Phase, "message", status.Message)
+
+	return nil
+}
+
+// SetupWithManager sets up the controller with the Manager
+func (r *RecipeRunReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&ramendrv1alpha1.RecipeRun{}).
+		WithOptions(controller.Options{MaxConcurrentReconciles: maxConcurrentRuns}).
+		Complete(r)
+}
@@ -0,0 +1,167 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package controllers_test
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	Recipe "github.com/ramendr/recipe/api/v1alpha1"
+	"github.com/ramendr/recipe/controllers"
+	"github.com/ramendr/recipe/pkg/workflow/fake"
+)
+
+var _ = Describe("RecipeRunController", func() {
+	var (
+		testCtx   context.Context
+		cancel    context.CancelFunc
+		namespace *corev1.Namespace
+		executor  *fake.Executor
+	)
+
+	BeforeEach(func() {
+		testCtx, cancel = context.WithCancel(context.TODO())
+		namespace = createUniqueNamespace(testCtx)
+		executor = fake.NewExecutor()
+
+		recipe := &Recipe.Recipe{
+			ObjectMeta: metav1.ObjectMeta{Name: "recipe", Namespace: namespace.Name},
+			Spec: Recipe.RecipeSpec{
+				Groups: []*Recipe.Group{{Name: "config", Type: "resource"}},
+				Hooks: []*Recipe.Hook{{
+					Name: "db",
+					Type: "exec",
+					Ops: []*Recipe.Operation{
+						{Name: "quiesce", Command: "/bin/quiesce", InverseOp: "unquiesce"},
+						{Name: "unquiesce", Command: "/bin/unquiesce"},
+					},
+				}},
+				Workflows: []*Recipe.Workflow{{
+					Name:     Recipe.BackupWorkflowName,
+					Sequence: []map[string]string{{"hook": "db/quiesce"}, {"group": "config"}, {"hook": "db/unquiesce"}},
+				}},
+			},
+		}
+		Expect(k8sClient.Create(testCtx, recipe)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(k8sClient.Delete(testCtx, namespace)).To(Succeed())
+
+		cancel()
+	})
+
+	reconcile := func(name string) *Recipe.RecipeRun {
+		reconciler := &controllers.RecipeRunReconciler{
+			Client: k8sClient,
+			Scheme: scheme.Scheme,
+			Engine: executor.Engine(),
+		}
+		key := client.ObjectKey{Namespace: namespace.Name, Name: name}
+
+		_, err := reconciler.Reconcile(testCtx, ctrl.Request{NamespacedName: key})
+		Expect(err).ToNot(HaveOccurred())
+
+		run := &Recipe.RecipeRun{}
+		Expect(k8sClient.Get(testCtx, key, run)).To(Succeed())
+
+		return run
+	}
+
+	create := func(name, recipe, workflowName string) {
+		run := &Recipe.RecipeRun{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace.Name},
+			Spec:       Recipe.RecipeRunSpec{Recipe: recipe, Workflow: workflowName},
+		}
+		Expect(k8sClient.Create(testCtx, run)).To(Succeed())
+	}
+
+	It("runs the workflow and records the timeline", func() {
+		create("run", "recipe", Recipe.BackupWorkflowName)
+
+		run := reconcile("run")
+		Expect(run.Status.Phase).To(Equal(Recipe.RecipeRunSucceeded))
+		Expect(run.Status.FailOn).To(Equal("any-error"))
+		Expect(run.Status.StartTime).ToNot(BeNil())
+		Expect(run.Status.CompletionTime).ToNot(BeNil())
+		Expect(run.Status.Steps).To(HaveLen(3))
+		Expect(run.Status.Steps[0].Step).To(Equal("hook: db/quiesce"))
+		Expect(run.Status.Steps[0].Outcome).To(Equal("Succeeded"))
+	})
+	It("records failures and the rollback", func() {
+		executor.Fail("backup: config", errors.New("backup failed"))
+		create("run", "recipe", Recipe.BackupWorkflowName)
+
+		run := reconcile("run")
+		Expect(run.Status.Phase).To(Equal(Recipe.RecipeRunFailed))
+		Expect(run.Status.Message).To(ContainSubstring("backup failed"))
+		Expect(run.Status.Steps).To(HaveLen(2))
+		Expect(run.Status.Rollback).To(HaveLen(1))
+		Expect(run.Status.Rollback[0].Step).To(Equal("hook: db/unquiesce"))
+	})
+	It("fails runs of unknown recipes", func() {
+		create("run", "unknown", Recipe.BackupWorkflowName)
+
+		run := reconcile("run")
+		Expect(run.Status.Phase).To(Equal(Recipe.RecipeRunFailed))
+		Expect(run.Status.Message).To(ContainSubstring(`recipe "unknown" not found`))
+	})
+	It("fails runs without values of required parameters", func() {
+		recipe := &Recipe.Recipe{}
+		Expect(k8sClient.Get(testCtx, client.ObjectKey{Namespace: namespace.Name, Name: "recipe"}, recipe)).To(Succeed())
+
+		recipe.Spec.Parameters = []*Recipe.Parameter{{Name: "namespace"}}
+		recipe.Spec.Hooks[0].Namespace = "${namespace}"
+		Expect(k8sClient.Update(testCtx, recipe)).To(Succeed())
+
+		create("run", "recipe", Recipe.BackupWorkflowName)
+
+		run := reconcile("run")
+		Expect(run.Status.Phase).To(Equal(Recipe.RecipeRunFailed))
+		Expect(run.Status.Message).To(ContainSubstring(`parameter "namespace" has no default`))
+		Expect(executor.Calls()).To(BeEmpty())
+	})
+	It("fails runs of workflows with group steps without a group executor", func() {
+		create("run", "recipe", Recipe.BackupWorkflowName)
+
+		reconciler := &controllers.RecipeRunReconciler{Client: k8sClient, Scheme: scheme.Scheme, Engine: executor.Engine()}
+		reconciler.Engine.Groups = nil
+		key := client.ObjectKey{Namespace: namespace.Name, Name: "run"}
+
+		_, err := reconciler.Reconcile(testCtx, ctrl.Request{NamespacedName: key})
+		Expect(err).ToNot(HaveOccurred())
+
+		run := &Recipe.RecipeRun{}
+		Expect(k8sClient.Get(testCtx, key, run)).To(Succeed())
+		Expect(run.Status.Phase).To(Equal(Recipe.RecipeRunFailed))
+		Expect(run.Status.Message).To(ContainSubstring("backs up or restores groups"))
+		Expect(run.Status.StartTime).To(BeNil())
+		Expect(executor.Calls()).To(BeEmpty())
+	})
+	It("runs a workflow once", func() {
+		create("run", "recipe", Recipe.BackupWorkflowName)
+
+		reconcile("run")
+		reconcile("run")
+		Expect(executor.Calls()).To(HaveLen(3))
+	})
+	It("rejects changes of the spec", func() {
+		create("run", "recipe", Recipe.BackupWorkflowName)
+
+		run := &Recipe.RecipeRun{}
+		Expect(k8sClient.Get(testCtx, client.ObjectKey{Namespace: namespace.Name, Name: "run"}, run)).To(Succeed())
+
+		run.Spec.Workflow = Recipe.RestoreWorkflowName
+		Expect(k8sClient.Update(testCtx, run)).To(MatchError(ContainSubstring("spec is immutable")))
+	})
+})
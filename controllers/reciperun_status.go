@@ -0,0 +1,130 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package controllers
+
+import (
+	"errors"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	ramendrv1alpha1 "github.com/ramendr/recipe/api/v1alpha1"
+	"github.com/ramendr/recipe/pkg/selector"
+	"github.com/ramendr/recipe/pkg/workflow"
+)
+
+// newRecipeRunStatus returns the status of a completed run, from the status recorded when it
+// started and the record of the workflow engine, which is nil if the workflow did not start
+func newRecipeRunStatus(started ramendrv1alpha1.RecipeRunStatus, result *workflow.Result, runErr error,
+) ramendrv1alpha1.RecipeRunStatus {
+	status := *started.DeepCopy()
+	status.Phase = ramendrv1alpha1.RecipeRunSucceeded
+	status.CompletionTime = ptr.To(metav1.Now())
+
+	if result != nil {
+		status.FailOn = result.FailOn
+		status.Steps = newStepRecords(result.Steps)
+		status.Rollback = newStepRecords(result.Rollback)
+		runErr = errors.Join(runErr, result.RollbackErr)
+	}
+
+	if runErr != nil {
+		status.Phase = ramendrv1alpha1.RecipeRunFailed
+		status.Message = runErr.Error()
+	}
+
+	return status
+}
+
+func newStepRecords(steps []workflow.StepResult) []ramendrv1alpha1.StepRecord {
+	if len(steps) == 0 {
+		return nil
+	}
+
+	records := make([]ramendrv1alpha1.StepRecord, 0, len(steps))
+
+	for i := range steps {
+		step := &steps[i]
+		record := ramendrv1alpha1.StepRecord{
+			Index:     step.Index,
+			Step:      step.Step.String(),
+			Parallel:  step.Parallel,
+			Workflow:  step.Workflow,
+			Essential: step.Essential,
+			Outcome:   string(step.Outcome),
+			StartTime: metav1.NewTime(step.Start),
+			EndTime:   metav1.NewTime(step.End),
+		}
+
+		if step.Err != nil {
+			record.Message = step.Err.Error()
+		} else if step.Outcome == workflow.OutcomeSkipped {
+			record.Message = step.Reason
+		}
+
+		for j := range step.Targets {
+			if j == ramendrv1alpha1.MaxRunTargets {
+				record.TargetsTruncated = true
+
+				break
+			}
+
+			record.Targets = append(record.Targets, newTargetRecord(&step.Targets[j]))
+		}
+
+		for j := range step.Attempts {
+			attempt := ramendrv1alpha1.AttemptRecord{
+				StartTime: metav1.NewTime(step.Attempts[j].Start),
+				EndTime:   metav1.NewTime(step.Attempts[j].End),
+			}
+
+			if step.Attempts[j].Err != nil {
+				attempt.Message = step.Attempts[j].Err.Error()
+			}
+
+			record.Attempts = append(record.Attempts, attempt)
+		}
+
+		records = append(records, record)
+	}
+
+	return records
+}
+
+func newTargetRecord(target *workflow.Target) ramendrv1alpha1.TargetRecord {
+	record := ramendrv1alpha1.TargetRecord{
+		Kind:      target.Kind,
+		Namespace: target.Namespace,
+		Name:      target.Name,
+		Container: target.Container,
+		Stdout:    truncateOutput(target.Stdout),
+		Stderr:    truncateOutput(target.Stderr),
+		Message:   target.Message,
+	}
+
+	// only commands run in pods have an exit code
+	if target.Kind == selector.KindPod && target.Container != "" {
+		record.ExitCode = ptr.To(target.ExitCode)
+	}
+
+	if target.Err != nil {
+		record.Error = target.Err.Error()
+	}
+
+	return record
+}
+
+// truncateOutput keeps the end of the output, where errors are usually reported
+func truncateOutput(output string) string {
+	if len(output) <= ramendrv1alpha1.MaxRunOutputBytes {
+		return output
+	}
+
+	const marker = "[truncated]\n"
+
+	kept := output[len(output)-(ramendrv1alpha1.MaxRunOutputBytes-len(marker)):]
+
+	return fmt.Sprintf("%s%s", marker, kept)
+}
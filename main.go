@@ -32,7 +32,10 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	ramendrv1alpha1 "github.com/ramendr/recipe/api/v1alpha1"
+	ramendrv1alpha2 "github.com/ramendr/recipe/api/v1alpha2"
 	"github.com/ramendr/recipe/controllers"
+	"github.com/ramendr/recipe/pkg/hooks"
+	"github.com/ramendr/recipe/pkg/workflow"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	//+kubebuilder:scaffold:imports
 )
@@ -46,6 +49,7 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
 	utilruntime.Must(ramendrv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(ramendrv1alpha2.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
@@ -98,6 +102,34 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "Recipe")
 		os.Exit(1)
 	}
+	remote, err := hooks.NewPodRemoteExecutor(mgr.GetConfig())
+	if err != nil {
+		setupLog.Error(err, "unable to create pod executor")
+		os.Exit(1)
+	}
+	if err = (&controllers.RecipeRunReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+		// groups are backed up and restored by the disaster recovery orchestrator, so runs of
+		// workflows with group steps are rejected
+		Engine: &workflow.Engine{
+			Hooks:      hooks.NewExecutors(mgr.GetClient(), remote),
+			Conditions: &hooks.WhenEvaluator{Reader: mgr.GetClient()},
+		},
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "RecipeRun")
+		os.Exit(1)
+	}
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = (&ramendrv1alpha1.Recipe{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Recipe")
+			os.Exit(1)
+		}
+		if err = (&ramendrv1alpha1.RecipeTemplate{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "RecipeTemplate")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -0,0 +1,306 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+// Package dryrun simulates workflows of a recipe against a snapshot of cluster objects. It runs the
+// workflow engine with executors that select objects with the same selector and namespace logic as
+// the real ones, but change nothing: groups report the objects they would include and exclude, exec
+// hooks the pods and containers they would exec into, scale hooks the workloads they would scale,
+// and checks are evaluated once against the snapshot.
+package dryrun
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ramendrv1alpha1 "github.com/ramendr/recipe/api/v1alpha1"
+	"github.com/ramendr/recipe/pkg/hooks"
+	"github.com/ramendr/recipe/pkg/selector"
+	"github.com/ramendr/recipe/pkg/workflow"
+)
+
+// Reasons of excluded objects
+const (
+	// ReasonExcludedNamespace is given for namespaces of ExcludedNamespaces and their objects
+	ReasonExcludedNamespace = "excluded namespace"
+	// ReasonNotSelected is given for objects the selectors of the group do not match
+	ReasonNotSelected = "not selected"
+)
+
+// Exclusion is an object a group does not include
+type Exclusion struct {
+	selector.Object
+	Reason string
+}
+
+// GroupScope is what a group would back up or restore
+type GroupScope struct {
+	Action workflow.GroupAction
+	// Included objects, sorted by kind, namespace and name
+	Included []selector.Object
+	// Excluded objects of the kinds the group could include, in the namespaces it applies to or
+	// excludes, sorted by kind, namespace and name
+	Excluded []Exclusion
+}
+
+// Report is the record of a dry run
+type Report struct {
+	// Recipe is the namespace and name of the recipe
+	Recipe string
+	// Result of the workflow as the engine recorded it. Hook steps have the targets they would run
+	// on; checks that are not met in the snapshot have targets with Err set, but do not fail.
+	Result *workflow.Result
+	// Groups by name
+	Groups map[string]*GroupScope
+
+	workflow *ramendrv1alpha1.Workflow
+}
+
+// Run simulates the named workflow of the recipe against the objects reader reads, e.g. from a
+// Snapshot. It fails only if the workflow cannot be started; a workflow that would fail is recorded
+// in Report.Result. The recipe is expected to be valid and to have its parameters expanded.
+func Run(ctx context.Context, reader client.Reader, recipe *ramendrv1alpha1.Recipe, workflowName string,
+) (*Report, error) {
+	e := &executor{reader: reader, groups: map[string]*GroupScope{}}
+	engine := &workflow.Engine{
+		Groups: e,
+		Hooks: map[string]workflow.HookExecutor{
+			ramendrv1alpha1.HookTypeExec: &hookExecutor{
+				reader: reader,
+				ops:    &hooks.ExecExecutor{Reader: reader, Remote: noopRemote{}},
+			},
+			ramendrv1alpha1.HookTypeScale: &hookExecutor{reader: reader, ops: &scaleExecutor{reader: reader}},
+			ramendrv1alpha1.HookTypeCheck: &hookExecutor{reader: reader, ops: &hooks.CheckExecutor{Reader: reader}},
+		},
+		Conditions: &hooks.WhenEvaluator{Reader: reader},
+	}
+
+	result, err := engine.Run(ctx, recipe, workflowName)
+	if result == nil {
+		return nil, err
+	}
+
+	return &Report{
+		Recipe:   recipe.Namespace + "/" + recipe.Name,
+		Result:   result,
+		Groups:   e.groups,
+		workflow: recipe.Spec.EffectiveWorkflow(workflowName),
+	}, nil
+}
+
+// executor is the GroupExecutor of dry runs, recording the scope of groups
+type executor struct {
+	reader client.Reader
+
+	mu     sync.Mutex
+	groups map[string]*GroupScope
+}
+
+var _ workflow.GroupExecutor = &executor{}
+
+func (e *executor) BackupGroup(ctx context.Context, recipe *ramendrv1alpha1.Recipe,
+	group *ramendrv1alpha1.Group,
+) error {
+	return e.scope(ctx, recipe, group, workflow.GroupActionBackup)
+}
+
+func (e *executor) RestoreGroup(ctx context.Context, recipe *ramendrv1alpha1.Recipe,
+	group *ramendrv1alpha1.Group,
+) error {
+	return e.scope(ctx, recipe, group, workflow.GroupActionRestore)
+}
+
+// scope records the objects a group selects, and the objects of the kinds it could select that it
+// does not select in the namespaces it applies to or excludes
+func (e *executor) scope(ctx context.Context, recipe *ramendrv1alpha1.Recipe, group *ramendrv1alpha1.Group,
+	action workflow.GroupAction,
+) error {
+	resolver := &selector.Resolver{Reader: e.reader, DefaultNamespace: recipe.Namespace}
+
+	selection, err := resolver.ResolveGroup(ctx, group)
+	if err != nil {
+		return fmt.Errorf("failed to select the objects of group %q: %w", group.Name, err)
+	}
+
+	unfiltered := group.DeepCopy()
+	unfiltered.ExcludedNamespaces = nil
+
+	namespaces, err := resolver.GroupNamespaces(ctx, unfiltered)
+	if err != nil {
+		return fmt.Errorf("failed to select the namespaces of group %q: %w", group.Name, err)
+	}
+
+	scope := &GroupScope{Action: action, Included: selection.Objects}
+	included := sets.New(selection.Objects...)
+
+	for _, namespace := range namespaces {
+		reason := ReasonNotSelected
+
+		if !slices.Contains(selection.Namespaces, namespace) {
+			reason = ReasonExcludedNamespace
+			scope.Excluded = append(scope.Excluded, Exclusion{
+				Object: selector.Object{Kind: selector.KindNamespace, Name: namespace},
+				Reason: reason,
+			})
+		}
+
+		objs, err := e.candidates(ctx, namespace, group)
+		if err != nil {
+			return err
+		}
+
+		for _, obj := range objs {
+			if !included.Has(obj) {
+				scope.Excluded = append(scope.Excluded, Exclusion{Object: obj, Reason: reason})
+			}
+		}
+	}
+
+	slices.SortFunc(scope.Excluded, func(a, b Exclusion) int {
+		return strings.Compare(a.String(), b.String())
+	})
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.groups[group.Name] = scope
+
+	return nil
+}
+
+// candidates returns the objects of a namespace of the kinds a group could include: PVCs and the
+// kind of SelectResource for volume groups, and PVCs, pods, deployments and statefulsets for
+// resource groups
+func (e *executor) candidates(ctx context.Context, namespace string, group *ramendrv1alpha1.Group,
+) ([]selector.Object, error) {
+	kinds := []string{selector.KindPVC, selector.KindPod, selector.KindDeployment, selector.KindStatefulSet}
+	if group.Type == "volume" {
+		kinds = []string{selector.KindPVC}
+
+		if group.SelectResource != "" && group.SelectResource != selector.KindPVC {
+			kinds = append(kinds, group.SelectResource)
+		}
+	}
+
+	objs := []selector.Object{}
+
+	for _, kind := range kinds {
+		var list client.ObjectList
+
+		switch kind {
+		case selector.KindPVC:
+			list = &corev1.PersistentVolumeClaimList{}
+		case selector.KindPod:
+			list = &corev1.PodList{}
+		case selector.KindDeployment:
+			list = &appsv1.DeploymentList{}
+		case selector.KindStatefulSet:
+			list = &appsv1.StatefulSetList{}
+		default:
+			continue
+		}
+
+		if err := e.reader.List(ctx, list, client.InNamespace(namespace)); err != nil {
+			return nil, fmt.Errorf("failed to list %T in namespace %s: %w", list, namespace, err)
+		}
+
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range items {
+			obj := item.(client.Object)
+			objs = append(objs, selector.Object{Kind: kind, Namespace: obj.GetNamespace(), Name: obj.GetName()})
+		}
+	}
+
+	return objs, nil
+}
+
+// hookExecutor runs the operations of hooks with ops and evaluates their checks once, recording
+// checks that are not met in the targets without failing, since the snapshot does not reflect the
+// effects of the preceding steps
+type hookExecutor struct {
+	reader client.Reader
+	ops    opExecutor
+}
+
+// opExecutor is the part of a workflow.HookExecutor running operations
+type opExecutor interface {
+	ExecuteOp(ctx context.Context, recipe *ramendrv1alpha1.Recipe, hook *ramendrv1alpha1.Hook,
+		op *ramendrv1alpha1.Operation) ([]workflow.Target, error)
+}
+
+var _ workflow.HookExecutor = &hookExecutor{}
+
+func (e *hookExecutor) ExecuteOp(ctx context.Context, recipe *ramendrv1alpha1.Recipe, hook *ramendrv1alpha1.Hook,
+	op *ramendrv1alpha1.Operation,
+) ([]workflow.Target, error) {
+	return e.ops.ExecuteOp(ctx, recipe, hook, op)
+}
+
+func (e *hookExecutor) ExecuteCheck(ctx context.Context, recipe *ramendrv1alpha1.Recipe, hook *ramendrv1alpha1.Hook,
+	chk *ramendrv1alpha1.Check,
+) ([]workflow.Target, error) {
+	return hooks.EvaluateCheck(ctx, e.reader, recipe, hook, chk)
+}
+
+// noopRemote is the RemoteExecutor of dry runs, which runs nothing and succeeds
+type noopRemote struct{}
+
+func (noopRemote) Exec(ctx context.Context, namespace, pod, container string, command []string,
+	stdout, stderr io.Writer,
+) (int, error) {
+	return 0, nil
+}
+
+// scaleExecutor selects the workloads of scale hooks as hooks.ScaleExecutor does, without scaling
+// them
+type scaleExecutor struct {
+	reader client.Reader
+}
+
+func (e *scaleExecutor) ExecuteOp(ctx context.Context, recipe *ramendrv1alpha1.Recipe, hook *ramendrv1alpha1.Hook,
+	op *ramendrv1alpha1.Operation,
+) ([]workflow.Target, error) {
+	command := strings.TrimSpace(op.Command)
+	if command != ramendrv1alpha1.ScaleDown && command != ramendrv1alpha1.ScaleUp {
+		return nil, fmt.Errorf("op %q of hook %q: unsupported scale command %q, must be one of %q",
+			op.Name, hook.Name, command, ramendrv1alpha1.ScaleCommands)
+	}
+
+	resolver := &selector.Resolver{Reader: e.reader, DefaultNamespace: recipe.Namespace}
+
+	workloads, err := resolver.HookWorkloads(ctx, hook)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select workloads of hook %q: %w", hook.Name, err)
+	}
+
+	if len(workloads) == 0 {
+		return nil, fmt.Errorf("hook %q selects no %ss in namespace %s", hook.Name, hook.SelectResource,
+			resolver.HookNamespace(hook))
+	}
+
+	targets := make([]workflow.Target, len(workloads))
+
+	for i := range workloads {
+		targets[i] = workflow.Target{
+			Kind:      workloads[i].Kind,
+			Namespace: workloads[i].GetNamespace(),
+			Name:      workloads[i].GetName(),
+			Message:   "would scale " + command,
+		}
+	}
+
+	return targets, nil
+}
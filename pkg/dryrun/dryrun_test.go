@@ -0,0 +1,280 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package dryrun_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	Recipe "github.com/ramendr/recipe/api/v1alpha1"
+	"github.com/ramendr/recipe/pkg/dryrun"
+	"github.com/ramendr/recipe/pkg/selector"
+	"github.com/ramendr/recipe/pkg/workflow"
+)
+
+const snapshotYAML = `
+apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: Namespace
+  metadata:
+    name: prod
+    labels:
+      team: db
+- apiVersion: v1
+  kind: Namespace
+  metadata:
+    name: prod-test
+    labels:
+      team: db
+- apiVersion: v1
+  kind: Pod
+  metadata:
+    name: db-0
+    namespace: prod
+    labels:
+      app: db
+    annotations:
+      kubectl.kubernetes.io/default-container: postgres
+    resourceVersion: "42"
+  spec:
+    containers:
+    - name: sidecar
+      image: sidecar
+    - name: postgres
+      image: postgres
+  status:
+    phase: Running
+- apiVersion: v1
+  kind: Pod
+  metadata:
+    name: db-1
+    namespace: prod
+    labels:
+      app: db
+  spec:
+    containers:
+    - name: postgres
+      image: postgres
+  status:
+    phase: Pending
+- apiVersion: v1
+  kind: PersistentVolumeClaim
+  metadata:
+    name: data-db-0
+    namespace: prod
+    labels:
+      app: db
+- apiVersion: v1
+  kind: PersistentVolumeClaim
+  metadata:
+    name: scratch
+    namespace: prod
+- apiVersion: v1
+  kind: PersistentVolumeClaim
+  metadata:
+    name: data-db-0
+    namespace: prod-test
+    labels:
+      app: db
+`
+
+const recipeCR = `
+apiVersion: ramendr.openshift.io/v1alpha1
+kind: Recipe
+metadata:
+  name: db
+  namespace: prod
+`
+
+func dryRunRecipe() *Recipe.Recipe {
+	return &Recipe.Recipe{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "prod"},
+		Spec: Recipe.RecipeSpec{
+			Groups: []*Recipe.Group{
+				{
+					Name:                      "data",
+					Type:                      "volume",
+					IncludedNamespacesByLabel: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "db"}},
+					ExcludedNamespaces:        []string{"prod-test"},
+					LabelSelector:             &metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}},
+				},
+			},
+			Hooks: []*Recipe.Hook{
+				{
+					Name:          "db",
+					Type:          "exec",
+					LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}},
+					Ops: []*Recipe.Operation{
+						{Name: "quiesce", Command: "/bin/quiesce", InverseOp: "unquiesce"},
+						{Name: "unquiesce", Command: "/bin/unquiesce"},
+					},
+					Chks: []*Recipe.Check{{Name: "running", Condition: "{$.status.phase} == 'Running'"}},
+				},
+				{
+					Name:      "cache",
+					Type:      "exec",
+					Namespace: "cache",
+					Ops:       []*Recipe.Operation{{Name: "flush", Command: "/bin/flush"}},
+				},
+			},
+			Workflows: []*Recipe.Workflow{
+				{
+					Name: "backup",
+					Sequence: []map[string]string{
+						{"hook": "db/quiesce"},
+						{"hook": "db/running"},
+						{"group": "data"},
+						{"hook": "db/unquiesce"},
+					},
+				},
+			},
+		},
+	}
+}
+
+func newSnapshot() *dryrun.Snapshot {
+	snapshot := dryrun.NewSnapshot()
+	Expect(snapshot.Add([]byte(snapshotYAML))).To(Succeed())
+	Expect(snapshot.Add([]byte(recipeCR))).To(Succeed())
+
+	return snapshot
+}
+
+var _ = Describe("Snapshot", func() {
+	It("adds the items of lists and ignores unknown kinds", func() {
+		snapshot := newSnapshot()
+		Expect(snapshot.Len()).To(Equal(7))
+		Expect(snapshot.Ignored()).To(Equal(1))
+	})
+	It("rejects objects added twice", func() {
+		snapshot := newSnapshot()
+		Expect(snapshot.Add([]byte(snapshotYAML))).To(MatchError(ContainSubstring("Namespace /prod")))
+	})
+})
+
+var _ = Describe("Run", func() {
+	ctx := context.TODO()
+
+	It("reports the scope of groups and the targets of hooks", func() {
+		report, err := dryrun.Run(ctx, newSnapshot().Reader(), dryRunRecipe(), "backup")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.Result.Failed()).To(BeFalse())
+
+		scope := report.Groups["data"]
+		Expect(scope.Action).To(Equal(workflow.GroupActionBackup))
+		Expect(scope.Included).To(Equal([]selector.Object{
+			{Kind: "namespace", Name: "prod"},
+			{Kind: "pvc", Namespace: "prod", Name: "data-db-0"},
+		}))
+		Expect(scope.Excluded).To(Equal([]dryrun.Exclusion{
+			{Object: selector.Object{Kind: "namespace", Name: "prod-test"}, Reason: dryrun.ReasonExcludedNamespace},
+			{
+				Object: selector.Object{Kind: "pvc", Namespace: "prod-test", Name: "data-db-0"},
+				Reason: dryrun.ReasonExcludedNamespace,
+			},
+			{Object: selector.Object{Kind: "pvc", Namespace: "prod", Name: "scratch"}, Reason: dryrun.ReasonNotSelected},
+		}))
+
+		out := &bytes.Buffer{}
+		Expect(report.Write(out)).To(Succeed())
+		Expect(out.String()).To(Equal(`Dry run of workflow backup of recipe prod/db:
+1. hook: db/quiesce
+   exec into pod prod/db-0, container postgres
+2. hook: db/running
+   pod prod/db-0: condition met
+   pod prod/db-1: condition "{$.status.phase} == 'Running'" not met
+3. group: data (backup)
+   include namespace prod
+   include pvc prod/data-db-0
+   exclude namespace prod-test: excluded namespace
+   exclude pvc prod-test/data-db-0: excluded namespace
+   exclude pvc prod/scratch: not selected
+4. hook: db/unquiesce
+   exec into pod prod/db-0, container postgres
+The workflow would succeed.
+`))
+	})
+	It("reports where the workflow would fail and what would be reverted", func() {
+		recipe := dryRunRecipe()
+		recipe.Spec.Workflows[0].Sequence = []map[string]string{{"hook": "db/quiesce"}, {"hook": "cache/flush"}}
+
+		report, err := dryrun.Run(ctx, newSnapshot().Reader(), recipe, "backup")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.Result.Failed()).To(BeTrue())
+
+		out := &bytes.Buffer{}
+		Expect(report.Write(out)).To(Succeed())
+		Expect(strings.Split(out.String(), "\n")[3:]).To(Equal([]string{
+			"2. hook: cache/flush",
+			`   fails: hook "cache" selects no running pods in namespace cache`,
+			`The workflow would fail: workflow "backup" failed on any-error: step 1 (hook: cache/flush): hook "cache" ` +
+				"selects no running pods in namespace cache",
+			"Inverse operations run after the failure:",
+			"- hook: db/unquiesce, reverting step 1",
+			"   exec into pod prod/db-0, container postgres",
+			"",
+		}))
+	})
+	It("skips steps whose when condition does not hold in the snapshot", func() {
+		recipe := dryRunRecipe()
+		recipe.Spec.Workflows[0].Sequence = []map[string]string{
+			{"hook": "db/quiesce", "when": "pod db-0: {$.status.phase} == 'Running'"},
+			{"hook": "cache/flush", "when": "namespace cache"},
+		}
+
+		report, err := dryrun.Run(ctx, newSnapshot().Reader(), recipe, "backup")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.Result.Failed()).To(BeFalse())
+
+		out := &bytes.Buffer{}
+		Expect(report.Write(out)).To(Succeed())
+		Expect(strings.Split(out.String(), "\n")[1:]).To(Equal([]string{
+			"1. hook: db/quiesce",
+			"   exec into pod prod/db-0, container postgres",
+			"2. hook: cache/flush",
+			"   skipped: namespace cache not found",
+			"The workflow would succeed.",
+			"",
+		}))
+	})
+	It("reports the steps of called workflows", func() {
+		recipe := dryRunRecipe()
+		recipe.Spec.Workflows = append(recipe.Spec.Workflows, &Recipe.Workflow{
+			Name:     "quiesce-all",
+			Sequence: []map[string]string{{"hook": "db/quiesce"}, {"hook": "db/running"}},
+		})
+		recipe.Spec.Workflows[0].Sequence = []map[string]string{{"workflow": "quiesce-all"}, {"hook": "db/unquiesce"}}
+
+		report, err := dryrun.Run(ctx, newSnapshot().Reader(), recipe, "backup")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.Result.Failed()).To(BeFalse())
+
+		out := &bytes.Buffer{}
+		Expect(report.Write(out)).To(Succeed())
+		Expect(strings.Split(out.String(), "\n")[1:]).To(Equal([]string{
+			"1. workflow: quiesce-all, hook: db/quiesce",
+			"   exec into pod prod/db-0, container postgres",
+			"1. workflow: quiesce-all, hook: db/running",
+			"   pod prod/db-0: condition met",
+			`   pod prod/db-1: condition "{$.status.phase} == 'Running'" not met`,
+			"1. workflow: quiesce-all",
+			"2. hook: db/unquiesce",
+			"   exec into pod prod/db-0, container postgres",
+			"The workflow would succeed.",
+			"",
+		}))
+	})
+	It("fails for workflows the recipe does not define", func() {
+		_, err := dryrun.Run(ctx, newSnapshot().Reader(), dryRunRecipe(), "migrate")
+		Expect(err).To(MatchError(workflow.ErrWorkflowNotFound))
+	})
+})
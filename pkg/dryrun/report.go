@@ -0,0 +1,146 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package dryrun
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+
+	ramendrv1alpha1 "github.com/ramendr/recipe/api/v1alpha1"
+	"github.com/ramendr/recipe/pkg/selector"
+	"github.com/ramendr/recipe/pkg/workflow"
+)
+
+// Write writes the report as text: the steps that would run in the order of the sequence, each with
+// the objects its group would include and exclude or the objects its hook would run on, then the
+// outcome of the workflow and the inverse operations that would run if it failed
+func (r *Report) Write(w io.Writer) error {
+	out := bufio.NewWriter(w)
+
+	fmt.Fprintf(out, "Dry run of workflow %s of recipe %s:\n", r.Result.Workflow, r.Recipe)
+
+	for _, step := range r.steps() {
+		title := step.Step.String()
+		if step.Parallel != "" {
+			title = fmt.Sprintf("parallel: %s, %s", step.Parallel, title)
+		}
+
+		if step.Workflow != "" {
+			title = fmt.Sprintf("workflow: %s, %s", step.Workflow, title)
+		}
+
+		if scope := r.Groups[step.Step.Name]; step.Step.Kind == ramendrv1alpha1.StepKindGroup && scope != nil {
+			title += fmt.Sprintf(" (%s)", scope.Action)
+		}
+
+		fmt.Fprintf(out, "%d. %s\n", step.Index+1, title)
+
+		r.writeStep(out, &step)
+	}
+
+	if r.Result.Failed() {
+		fmt.Fprintf(out, "The workflow would fail: %v\n", r.Result.Err)
+	} else {
+		fmt.Fprintln(out, "The workflow would succeed.")
+	}
+
+	if len(r.Result.Rollback) != 0 {
+		fmt.Fprintln(out, "Inverse operations run after the failure:")
+	}
+
+	for _, step := range r.Result.Rollback {
+		fmt.Fprintf(out, "- %s, reverting step %d\n", step.Step, step.Index+1)
+		r.writeStep(out, &step)
+	}
+
+	return out.Flush()
+}
+
+// steps returns the steps of the result in the order of the sequence, the steps of parallel sets in
+// the order of their set rather than the order they completed. Steps of called workflows stay in the
+// order they completed, before the step calling them.
+func (r *Report) steps() []workflow.StepResult {
+	steps := slices.Clone(r.Result.Steps)
+
+	position := func(step *workflow.StepResult) int {
+		if step.Parallel == "" || step.Workflow != "" || r.workflow == nil {
+			return 0
+		}
+
+		set := r.workflow.FindParallel(step.Parallel)
+		if set == nil {
+			return 0
+		}
+
+		return slices.IndexFunc(set.Steps, func(entry map[string]string) bool {
+			parsed, err := ramendrv1alpha1.ParseStep(entry)
+
+			return err == nil && parsed == step.Step
+		})
+	}
+
+	slices.SortStableFunc(steps, func(a, b workflow.StepResult) int {
+		if a.Index != b.Index {
+			return a.Index - b.Index
+		}
+
+		return position(&a) - position(&b)
+	})
+
+	return steps
+}
+
+func (r *Report) writeStep(out io.Writer, step *workflow.StepResult) {
+	if scope := r.Groups[step.Step.Name]; step.Step.Kind == ramendrv1alpha1.StepKindGroup && scope != nil {
+		for _, obj := range scope.Included {
+			fmt.Fprintf(out, "   include %s\n", objectName(obj))
+		}
+
+		if len(scope.Included) == 0 {
+			fmt.Fprintln(out, "   include nothing")
+		}
+
+		for _, exclusion := range scope.Excluded {
+			fmt.Fprintf(out, "   exclude %s: %s\n", objectName(exclusion.Object), exclusion.Reason)
+		}
+	}
+
+	for _, target := range step.Targets {
+		obj := objectName(selector.Object{Kind: target.Kind, Namespace: target.Namespace, Name: target.Name})
+
+		switch {
+		case target.Err != nil:
+			fmt.Fprintf(out, "   %v\n", target.Err)
+		case target.Container != "":
+			fmt.Fprintf(out, "   exec into %s, container %s\n", obj, target.Container)
+		default:
+			fmt.Fprintf(out, "   %s: %s\n", obj, target.Message)
+		}
+	}
+
+	if step.Step.Kind == ramendrv1alpha1.StepKindHook && len(step.Targets) == 0 && step.Err == nil &&
+		step.Outcome != workflow.OutcomeSkipped {
+		fmt.Fprintln(out, "   selects nothing")
+	}
+
+	switch step.Outcome {
+	case workflow.OutcomeFailed:
+		fmt.Fprintf(out, "   fails: %v\n", step.Err)
+	case workflow.OutcomeIgnored:
+		fmt.Fprintf(out, "   fails, ignored: %v\n", step.Err)
+	case workflow.OutcomeSkipped:
+		fmt.Fprintf(out, "   skipped: %s\n", step.Reason)
+	}
+}
+
+// objectName returns an object as "<kind> <namespace>/<name>", or "namespace <name>"
+func objectName(obj selector.Object) string {
+	if obj.Namespace == "" {
+		return obj.Kind + " " + obj.Name
+	}
+
+	return obj.Kind + " " + obj.Namespace + "/" + obj.Name
+}
@@ -0,0 +1,104 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package dryrun
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/yaml"
+)
+
+// Snapshot holds objects exported from a cluster, e.g. with kubectl get -o yaml, for selectors to be
+// evaluated against instead of the cluster. Only built-in kinds are kept, e.g. namespaces, pods,
+// PVCs and deployments.
+type Snapshot struct {
+	tracker testing.ObjectTracker
+	decoder runtime.Decoder
+	// objects is the number of objects added
+	objects int
+	// ignored is the number of objects of unknown kinds
+	ignored int
+}
+
+// NewSnapshot returns an empty snapshot
+func NewSnapshot() *Snapshot {
+	return &Snapshot{
+		tracker: testing.NewObjectTracker(scheme.Scheme, scheme.Codecs.UniversalDecoder()),
+		decoder: serializer.NewCodecFactory(scheme.Scheme).UniversalDeserializer(),
+	}
+}
+
+// Add adds the object of a YAML or JSON document, or the items of a document of kind List.
+// Documents of kinds the snapshot does not know, e.g. of custom resources, are ignored.
+func (s *Snapshot) Add(document []byte) error {
+	typeMeta := &metav1.TypeMeta{}
+	if err := yaml.Unmarshal(document, typeMeta); err != nil {
+		return err
+	}
+
+	if typeMeta.Kind == "List" {
+		list := &struct {
+			Items []json.RawMessage `json:"items"`
+		}{}
+		if err := yaml.Unmarshal(document, list); err != nil {
+			return err
+		}
+
+		for _, item := range list.Items {
+			if err := s.Add(item); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	obj, gvk, err := s.decoder.Decode(document, nil, nil)
+	if runtime.IsNotRegisteredError(err) {
+		s.ignored++
+
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return fmt.Errorf("%s: %w", gvk.Kind, err)
+	}
+
+	if err := s.tracker.Add(obj); err != nil {
+		return fmt.Errorf("%s %s/%s: %w", gvk.Kind, accessor.GetNamespace(), accessor.GetName(), err)
+	}
+
+	s.objects++
+
+	return nil
+}
+
+// Len returns the number of objects of the snapshot
+func (s *Snapshot) Len() int {
+	return s.objects
+}
+
+// Ignored returns the number of objects of unknown kinds that were not added
+func (s *Snapshot) Ignored() int {
+	return s.ignored
+}
+
+// Reader returns a client reading the objects of the snapshot
+func (s *Snapshot) Reader() client.Reader {
+	return fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjectTracker(s.tracker).Build()
+}
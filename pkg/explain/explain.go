@@ -0,0 +1,431 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+// Package explain describes the workflows of a recipe as numbered plans for humans, e.g. "1. run
+// hook db/quiesce on pods matching app=db in ns prod, timeout 60s, essential".
+package explain
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ramendrv1alpha1 "github.com/ramendr/recipe/api/v1alpha1"
+	"github.com/ramendr/recipe/pkg/hooks"
+	"github.com/ramendr/recipe/pkg/workflow"
+)
+
+// Workflow returns the plan of the named workflow of the recipe, or of the default of a reserved
+// workflow the recipe omits: a header line, then one numbered line per step of the sequence, where
+// the steps of a parallel set and of a called workflow are numbered below the step of the set or
+// the call. The recipe is expected to be valid and to have its parameters expanded.
+func Workflow(recipe *ramendrv1alpha1.Recipe, workflowName string) (string, error) {
+	wf := recipe.Spec.EffectiveWorkflow(workflowName)
+	if wf == nil {
+		return "", fmt.Errorf("%w: recipe %s/%s has no workflow %q",
+			workflow.ErrWorkflowNotFound, recipe.Namespace, recipe.Name, workflowName)
+	}
+
+	e := &explainer{recipe: recipe, action: workflow.GroupActionFor(workflowName)}
+
+	var plan strings.Builder
+
+	fmt.Fprintf(&plan, "Workflow %s of recipe %s/%s, %s:\n", workflowName, recipe.Namespace, recipe.Name,
+		failOnText(wf.FailOn))
+
+	if err := e.sequence(&plan, wf, "", "", []string{workflowName}); err != nil {
+		return "", err
+	}
+
+	return plan.String(), nil
+}
+
+// sequence writes the numbered steps of a workflow to the plan, numbering them after prefix and
+// indenting them with indent. calls lists the workflows calling the workflow, itself included.
+func (e *explainer) sequence(plan *strings.Builder, wf *ramendrv1alpha1.Workflow, prefix, indent string,
+	calls []string,
+) error {
+	if len(wf.Sequence) == 0 {
+		plan.WriteString(indent + "(no steps)\n")
+	}
+
+	for i, entry := range wf.Sequence {
+		number := fmt.Sprintf("%s%d", prefix, i+1)
+
+		step, err := ramendrv1alpha1.ParseStep(entry)
+		if err != nil {
+			return fmt.Errorf("workflow %q step %d: %w", wf.Name, i, err)
+		}
+
+		switch step.Kind {
+		case ramendrv1alpha1.StepKindParallel:
+			if err := e.parallel(plan, wf, step, number, indent); err != nil {
+				return fmt.Errorf("workflow %q step %d: %w", wf.Name, i, err)
+			}
+		case ramendrv1alpha1.StepKindWorkflow:
+			called := e.recipe.Spec.FindWorkflow(step.Name)
+			if called == nil {
+				return fmt.Errorf("workflow %q step %d: workflow %q not found", wf.Name, i, step.Name)
+			}
+
+			if slices.Contains(calls, step.Name) {
+				return fmt.Errorf("workflow %q step %d: workflow %q calls itself: %s", wf.Name, i, step.Name,
+					strings.Join(append(slices.Clone(calls), step.Name), " -> "))
+			}
+
+			fmt.Fprintf(plan, "%s%s. %srun workflow %s, %s:\n", indent, number, e.when(step), step.Name,
+				failOnText(called.FailOn))
+
+			if err := e.sequence(plan, called, number+".", indent+"   ",
+				append(slices.Clone(calls), step.Name)); err != nil {
+				return err
+			}
+		default:
+			text, err := e.step(step)
+			if err != nil {
+				return fmt.Errorf("workflow %q step %d: %w", wf.Name, i, err)
+			}
+
+			fmt.Fprintf(plan, "%s%s. %s%s\n", indent, number, e.when(step), text)
+		}
+	}
+
+	return nil
+}
+
+// parallel writes a parallel set step of a workflow and the steps of the set to the plan
+func (e *explainer) parallel(plan *strings.Builder, wf *ramendrv1alpha1.Workflow, step ramendrv1alpha1.Step,
+	number, indent string,
+) error {
+	set := wf.FindParallel(step.Name)
+	if set == nil {
+		return fmt.Errorf("parallel set %q not found", step.Name)
+	}
+
+	fmt.Fprintf(plan, "%s%s. %srun in parallel, at most %d at a time:\n", indent, number, e.when(step),
+		set.EffectiveMaxParallel())
+
+	for j, entry := range set.Steps {
+		parallelStep, err := ramendrv1alpha1.ParseStep(entry)
+		if err != nil {
+			return fmt.Errorf("parallel set %q step %d: %w", set.Name, j, err)
+		}
+
+		text, err := e.step(parallelStep)
+		if err != nil {
+			return fmt.Errorf("parallel set %q step %d: %w", set.Name, j, err)
+		}
+
+		fmt.Fprintf(plan, "%s   %s%s. %s%s\n", indent, number, subStepLetter(j), e.when(parallelStep), text)
+	}
+
+	return nil
+}
+
+// explainer describes the steps of a workflow
+type explainer struct {
+	recipe *ramendrv1alpha1.Recipe
+	action workflow.GroupAction
+}
+
+func (e *explainer) step(step ramendrv1alpha1.Step) (string, error) {
+	switch step.Kind {
+	case ramendrv1alpha1.StepKindGroup:
+		group := e.recipe.Spec.FindGroup(step.Name)
+		if group == nil {
+			return "", fmt.Errorf("group %q not found", step.Name)
+		}
+
+		text := e.group(group)
+		if step.OnError == workflow.OnErrorContinue {
+			text += ", errors ignored"
+		}
+
+		return text, nil
+	case ramendrv1alpha1.StepKindHook:
+		hook := e.recipe.Spec.FindHook(step.Name)
+		if hook == nil {
+			return "", fmt.Errorf("hook %q not found", step.Name)
+		}
+
+		opName, err := hook.ResolveOp(step.Op)
+		if err != nil {
+			return "", err
+		}
+
+		return e.hook(hook, opName, step), nil
+	default:
+		return "", fmt.Errorf("unsupported step %s", step)
+	}
+}
+
+// when describes the when condition of a step as prefix of the step, e.g. "if statefulset prod/db
+// exists and {$.spec.replicas} > 1: ", or returns "" for steps without condition
+func (e *explainer) when(step ramendrv1alpha1.Step) string {
+	if step.When == "" {
+		return ""
+	}
+
+	when, err := ramendrv1alpha1.ParseWhen(step.When)
+	if err != nil {
+		return fmt.Sprintf("if %s: ", step.When)
+	}
+
+	if when.Namespace == "" && when.Kind != "namespace" {
+		when.Namespace = e.recipe.Namespace
+	}
+
+	if when.Condition == "" {
+		return fmt.Sprintf("if %s exists: ", when.Object())
+	}
+
+	return fmt.Sprintf("if %s exists and %s: ", when.Object(), when.Condition)
+}
+
+// group describes backing up or restoring a group, e.g. "back up volume group data: PVCs matching
+// app=db in ns prod, essential"
+func (e *explainer) group(group *ramendrv1alpha1.Group) string {
+	verb := "back up"
+	if e.action == workflow.GroupActionRestore {
+		verb = "restore"
+	}
+
+	var text strings.Builder
+
+	fmt.Fprintf(&text, "%s %s group %s", verb, group.Type, group.Name)
+
+	if group.IsRestoreOnly() {
+		fmt.Fprintf(&text, " from the backup of group %s", group.BackupRef)
+	}
+
+	text.WriteString(": ")
+
+	if group.Type == "volume" {
+		text.WriteString(volumesText(group))
+	} else {
+		text.WriteString(resourcesText(group))
+	}
+
+	fmt.Fprintf(&text, " %s, %s", e.groupNamespacesText(group), essentialText(group.Essential))
+
+	return text.String()
+}
+
+// hook describes running an operation or check of a hook with the timeout and onError of its step,
+// e.g. "run hook db/quiesce on pods matching app=db in ns prod, timeout 60s, essential"
+func (e *explainer) hook(hook *ramendrv1alpha1.Hook, opName string, step ramendrv1alpha1.Step) string {
+	namespace := hook.Namespace
+	if namespace == "" {
+		namespace = e.recipe.Namespace
+	}
+
+	var text strings.Builder
+
+	var timeout int
+
+	var onError, inverseOp string
+
+	var policy ramendrv1alpha1.RetryPolicy
+
+	if op := hook.FindOp(opName); op != nil {
+		fmt.Fprintf(&text, "run hook %s/%s", hook.Name, op.Name)
+
+		if hook.Type == ramendrv1alpha1.HookTypeScale {
+			fmt.Fprintf(&text, " (scale %s)", strings.TrimSpace(op.Command))
+		}
+
+		fmt.Fprintf(&text, " on %s in ns %s", hookTargetsText(hook, hookTargetKind(hook)), namespace)
+
+		if op.Container != "" {
+			fmt.Fprintf(&text, ", container %s", op.Container)
+		}
+
+		timeout, onError, inverseOp, policy = op.Timeout, op.OnError, op.InverseOp, op.RetryPolicy
+	} else {
+		chk := hook.FindCheck(opName)
+
+		kind := hookTargetKind(hook)
+		if hook.Type == ramendrv1alpha1.HookTypeExec {
+			// checks of exec hooks are evaluated against pods, also when selecting them by workload
+			kind = "pod"
+		}
+
+		fmt.Fprintf(&text, "wait until check %s/%s holds for %s in ns %s: %s", hook.Name, chk.Name,
+			hookTargetsText(hook, kind), namespace, chk.Condition)
+
+		timeout, onError, policy = chk.Timeout, chk.OnError, chk.RetryPolicy
+	}
+
+	if step.Timeout != 0 {
+		timeout = step.Timeout
+	}
+
+	if step.OnError != "" {
+		onError = step.OnError
+	}
+
+	fmt.Fprintf(&text, ", timeout %ds", int(hooks.Timeout(timeout, hook).Seconds()))
+	text.WriteString(retryText(hook.EffectiveRetryPolicy(policy)))
+	fmt.Fprintf(&text, ", %s", essentialText(hook.Essential))
+
+	if onError == "" {
+		onError = hook.OnError
+	}
+
+	if onError == workflow.OnErrorContinue {
+		text.WriteString(", errors ignored")
+	}
+
+	if inverseOp != "" {
+		fmt.Fprintf(&text, ", reverted by %s/%s if the workflow fails", hook.Name, inverseOp)
+	}
+
+	return text.String()
+}
+
+func hookTargetKind(hook *ramendrv1alpha1.Hook) string {
+	if hook.SelectResource == "" {
+		return "pod"
+	}
+
+	return hook.SelectResource
+}
+
+// hookTargetsText describes the objects a hook runs on, e.g. "pods of deployments matching app=db"
+func hookTargetsText(hook *ramendrv1alpha1.Hook, kind string) string {
+	objects := kind + "s"
+	if hook.Type == ramendrv1alpha1.HookTypeExec && kind != "pod" {
+		objects = "pods of " + objects
+	}
+
+	switch {
+	case hook.SinglePodOnly && hook.Type == ramendrv1alpha1.HookTypeExec:
+		objects = "one of the " + objects
+	case hook.LabelSelector == nil && hook.NameSelector == "":
+		objects = "all " + objects
+	}
+
+	return objects + selectorText(hook.LabelSelector, hook.NameSelector)
+}
+
+func volumesText(group *ramendrv1alpha1.Group) string {
+	switch group.SelectResource {
+	case "", "pvc":
+		return "PVCs" + selectorText(group.LabelSelector, group.NameSelector)
+	default:
+		return "PVCs of " + group.SelectResource + "s" + selectorText(group.LabelSelector, group.NameSelector)
+	}
+}
+
+func resourcesText(group *ramendrv1alpha1.Group) string {
+	text := "all resource types"
+	if len(group.IncludedResourceTypes) != 0 && !slices.Contains(group.IncludedResourceTypes, "*") {
+		text = "resources of types " + strings.Join(group.IncludedResourceTypes, ", ")
+	}
+
+	if len(group.ExcludedResourceTypes) != 0 {
+		text += " except " + strings.Join(group.ExcludedResourceTypes, ", ")
+	}
+
+	return text + selectorText(group.LabelSelector, "")
+}
+
+// groupNamespacesText describes the namespaces of a group as selector.Resolver resolves them
+func (e *explainer) groupNamespacesText(group *ramendrv1alpha1.Group) string {
+	var text string
+
+	switch {
+	case group.IncludedNamespacesByLabel != nil && len(group.IncludedNamespaces) != 0:
+		text = fmt.Sprintf("in ns %s and namespaces labeled %s", strings.Join(group.IncludedNamespaces, ", "),
+			metav1.FormatLabelSelector(group.IncludedNamespacesByLabel))
+	case group.IncludedNamespacesByLabel != nil:
+		text = "in namespaces labeled " + metav1.FormatLabelSelector(group.IncludedNamespacesByLabel)
+	case len(group.IncludedNamespaces) != 0:
+		text = "in ns " + strings.Join(group.IncludedNamespaces, ", ")
+	default:
+		text = "in ns " + e.recipe.Namespace
+	}
+
+	if len(group.ExcludedNamespaces) != 0 {
+		text += " except " + strings.Join(group.ExcludedNamespaces, ", ")
+	}
+
+	return text
+}
+
+// selectorText describes label and name selectors, e.g. " matching app=db named glob:db-*"
+func selectorText(labelSelector *metav1.LabelSelector, nameSelector string) string {
+	var text string
+
+	if labelSelector != nil {
+		text += " matching " + metav1.FormatLabelSelector(labelSelector)
+	}
+
+	if nameSelector != "" {
+		text += " named " + nameSelector
+	}
+
+	return text
+}
+
+// retryText describes a retry policy, e.g. ", retried up to 3 times after 5s, 10s, 20s on exit code
+// 75", or returns "" for policies without retries
+func retryText(policy ramendrv1alpha1.RetryPolicy) string {
+	retries := policy.MaxAttempts() - 1
+	if retries == 0 {
+		return ""
+	}
+
+	delays := make([]string, 0, retries)
+	for retry := 1; retry <= retries; retry++ {
+		delays = append(delays, fmt.Sprintf("%ds", int(policy.RetryDelay(retry).Seconds())))
+	}
+
+	text := fmt.Sprintf(", retried up to %d times after %s", retries, strings.Join(delays, ", "))
+
+	if policy.RetryOn != nil {
+		conditions := []string{}
+
+		for _, code := range policy.RetryOn.ExitCodes {
+			conditions = append(conditions, fmt.Sprintf("exit code %d", code))
+		}
+
+		if policy.RetryOn.StderrRegex != "" {
+			conditions = append(conditions, fmt.Sprintf("stderr matching %q", policy.RetryOn.StderrRegex))
+		}
+
+		text += " on " + strings.Join(conditions, " or ")
+	}
+
+	return text
+}
+
+func essentialText(essential *bool) string {
+	if essential == nil || *essential {
+		return "essential"
+	}
+
+	return "non-essential"
+}
+
+func failOnText(failOn string) string {
+	switch failOn {
+	case workflow.FailOnEssentialError:
+		return "stopping at the first failing essential step"
+	case workflow.FailOnFullError:
+		return "failing only if every step fails"
+	default:
+		return "stopping at the first failing step"
+	}
+}
+
+// subStepLetter returns the letter numbering the steps of a parallel set: a, b, ..., z, aa, ab, ...
+func subStepLetter(index int) string {
+	if index < 26 {
+		return string(rune('a' + index))
+	}
+
+	return subStepLetter(index/26-1) + subStepLetter(index%26)
+}
@@ -0,0 +1,190 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package explain_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	Recipe "github.com/ramendr/recipe/api/v1alpha1"
+	"github.com/ramendr/recipe/pkg/explain"
+	"github.com/ramendr/recipe/pkg/workflow"
+)
+
+func explainRecipe() *Recipe.Recipe {
+	return &Recipe.Recipe{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "prod"},
+		Spec: Recipe.RecipeSpec{
+			Groups: []*Recipe.Group{
+				{
+					Name:          "data",
+					Type:          "volume",
+					LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}},
+				},
+				{
+					Name:                  "config",
+					Type:                  "resource",
+					IncludedResourceTypes: []string{"configmaps", "secrets"},
+					IncludedNamespaces:    []string{"prod", "prod-config"},
+					Essential:             ptr.To(false),
+				},
+				{Name: "config-restore", Type: "resource", BackupRef: "config"},
+			},
+			Hooks: []*Recipe.Hook{
+				{
+					Name:          "db",
+					Type:          "exec",
+					LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}},
+					SinglePodOnly: true,
+					Timeout:       60,
+					Ops: []*Recipe.Operation{
+						{Name: "quiesce", Command: "/bin/quiesce", Container: "postgres", InverseOp: "unquiesce"},
+						{Name: "unquiesce", Command: "/bin/unquiesce", OnError: "continue", Timeout: 10},
+					},
+				},
+				{
+					Name:           "app",
+					Type:           "scale",
+					Namespace:      "prod-app",
+					SelectResource: "deployment",
+					NameSelector:   "glob:web-*",
+					Ops:            []*Recipe.Operation{{Name: "stop", Command: "down"}},
+					Chks: []*Recipe.Check{
+						{Name: "stopped", Condition: "{$.status.replicas} == 0"},
+					},
+				},
+			},
+			Workflows: []*Recipe.Workflow{{
+				Name: "backup",
+				Sequence: []map[string]string{
+					{"hook": "db/quiesce"},
+					{"parallel": "groups"},
+					{"hook": "db/unquiesce"},
+				},
+				Parallel: []*Recipe.ParallelSteps{{
+					Name:  "groups",
+					Steps: []map[string]string{{"group": "data"}, {"group": "config"}},
+				}},
+			}},
+		},
+	}
+}
+
+var _ = Describe("Workflow", func() {
+	It("explains hook steps and parallel sets", func() {
+		plan, err := explain.Workflow(explainRecipe(), "backup")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan).To(Equal(`Workflow backup of recipe prod/db, stopping at the first failing step:
+1. run hook db/quiesce on one of the pods matching app=db in ns prod, container postgres, timeout 60s, essential, ` +
+			`reverted by db/unquiesce if the workflow fails
+2. run in parallel, at most 5 at a time:
+   2a. back up volume group data: PVCs matching app=db in ns prod, essential
+   2b. back up resource group config: resources of types configmaps, secrets in ns prod, prod-config, non-essential
+3. run hook db/unquiesce on one of the pods matching app=db in ns prod, timeout 10s, essential, errors ignored
+`))
+	})
+	It("explains default workflows, restores, scale hooks and checks", func() {
+		recipe := explainRecipe()
+		recipe.Spec.Workflows = append(recipe.Spec.Workflows, &Recipe.Workflow{
+			Name:     "cleanup",
+			FailOn:   workflow.FailOnFullError,
+			Sequence: []map[string]string{{"hook": "app/stop"}, {"hook": "app/stopped"}},
+		})
+
+		plan, err := explain.Workflow(recipe, "restore")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan).To(Equal(`Workflow restore of recipe prod/db, stopping at the first failing step:
+1. restore volume group data: PVCs matching app=db in ns prod, essential
+2. restore resource group config-restore from the backup of group config: all resource types in ns prod, essential
+`))
+
+		plan, err = explain.Workflow(recipe, "cleanup")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan).To(Equal(`Workflow cleanup of recipe prod/db, failing only if every step fails:
+1. run hook app/stop (scale down) on deployments named glob:web-* in ns prod-app, timeout 30s, essential
+2. wait until check app/stopped holds for deployments named glob:web-* in ns prod-app: {$.status.replicas} == 0, ` +
+			`timeout 30s, essential
+`))
+	})
+	It("explains retry policies", func() {
+		recipe := explainRecipe()
+		hook := recipe.Spec.FindHook("db")
+		hook.Retries = ptr.To(2)
+		hook.FindOp("quiesce").RetryPolicy = Recipe.RetryPolicy{
+			Retries: ptr.To(3),
+			Backoff: &Recipe.Backoff{Type: Recipe.BackoffExponential, Delay: 10, MaxDelay: 30},
+			RetryOn: &Recipe.RetryOn{ExitCodes: []int{75}, StderrRegex: "locked"},
+		}
+
+		plan, err := explain.Workflow(recipe, "backup")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan).To(ContainSubstring(`1. run hook db/quiesce on one of the pods matching app=db in ns prod, ` +
+			`container postgres, timeout 60s, retried up to 3 times after 10s, 20s, 30s on exit code 75 or stderr ` +
+			`matching "locked", essential, reverted by db/unquiesce if the workflow fails`))
+		Expect(plan).To(ContainSubstring(`3. run hook db/unquiesce on one of the pods matching app=db in ns prod, ` +
+			`timeout 10s, retried up to 2 times after 5s, 5s, essential, errors ignored`))
+	})
+	It("explains when conditions", func() {
+		recipe := explainRecipe()
+		recipe.Spec.Workflows[0].Sequence[0]["when"] = "statefulset db: {$.spec.replicas} > 1"
+		recipe.Spec.Workflows[0].Parallel[0].Steps[1]["when"] = "namespace prod-config"
+
+		plan, err := explain.Workflow(recipe, "backup")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan).To(ContainSubstring("1. if statefulset prod/db exists and {$.spec.replicas} > 1: run hook " +
+			"db/quiesce on one of the pods matching app=db in ns prod"))
+		Expect(plan).To(ContainSubstring("   2b. if namespace prod-config exists: back up resource group config: "))
+	})
+	It("explains the timeout and onError of steps", func() {
+		recipe := explainRecipe()
+		recipe.Spec.Workflows[0].Sequence[0]["timeout"] = "300"
+		recipe.Spec.Workflows[0].Sequence[2]["onError"] = "fail"
+		recipe.Spec.Workflows[0].Parallel[0].Steps[0]["onError"] = "continue"
+
+		plan, err := explain.Workflow(recipe, "backup")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan).To(ContainSubstring("1. run hook db/quiesce on one of the pods matching app=db in ns prod, " +
+			"container postgres, timeout 300s, essential, reverted by db/unquiesce if the workflow fails\n"))
+		Expect(plan).To(ContainSubstring("   2a. back up volume group data: PVCs matching app=db in ns prod, " +
+			"essential, errors ignored\n"))
+		Expect(plan).To(ContainSubstring("3. run hook db/unquiesce on one of the pods matching app=db in ns prod, " +
+			"timeout 10s, essential\n"))
+	})
+	It("explains workflow steps with the steps of the called workflows", func() {
+		recipe := explainRecipe()
+		recipe.Spec.Workflows = append(recipe.Spec.Workflows,
+			&Recipe.Workflow{Name: "quiesce-all", Sequence: []map[string]string{{"hook": "db/quiesce"}}},
+			&Recipe.Workflow{
+				Name:     "data-and-config",
+				FailOn:   workflow.FailOnEssentialError,
+				Sequence: []map[string]string{{"workflow": "quiesce-all"}, {"parallel": "groups"}},
+				Parallel: recipe.Spec.Workflows[0].Parallel,
+			},
+		)
+		recipe.Spec.Workflows[0].Sequence = []map[string]string{{"workflow": "data-and-config"}, {"hook": "db/unquiesce"}}
+
+		plan, err := explain.Workflow(recipe, "backup")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan).To(Equal(`Workflow backup of recipe prod/db, stopping at the first failing step:
+1. run workflow data-and-config, stopping at the first failing essential step:
+   1.1. run workflow quiesce-all, stopping at the first failing step:
+      1.1.1. run hook db/quiesce on one of the pods matching app=db in ns prod, container postgres, timeout 60s, ` +
+			`essential, reverted by db/unquiesce if the workflow fails
+   1.2. run in parallel, at most 5 at a time:
+      1.2a. back up volume group data: PVCs matching app=db in ns prod, essential
+      1.2b. back up resource group config: resources of types configmaps, secrets in ns prod, prod-config, ` +
+			`non-essential
+2. run hook db/unquiesce on one of the pods matching app=db in ns prod, timeout 10s, essential, errors ignored
+`))
+	})
+	It("fails for unknown workflows", func() {
+		_, err := explain.Workflow(explainRecipe(), "archive")
+		Expect(errors.Is(err, workflow.ErrWorkflowNotFound)).To(BeTrue())
+	})
+})
@@ -0,0 +1,363 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+// Package graph turns a recipe into a graph of its workflows, hooks and groups, and writes it as
+// Graphviz DOT or Mermaid to embed in runbooks and reviews.
+package graph
+
+import (
+	"fmt"
+
+	ramendrv1alpha1 "github.com/ramendr/recipe/api/v1alpha1"
+	"github.com/ramendr/recipe/pkg/workflow"
+)
+
+// NodeKind is what a node stands for
+type NodeKind string
+
+const (
+	// NodeStep is a step of the sequence of a workflow
+	NodeStep NodeKind = "step"
+	// NodeParallel is a step of a workflow running a parallel set
+	NodeParallel NodeKind = "parallel"
+	// NodeOp is an operation of a hook
+	NodeOp NodeKind = "op"
+	// NodeCheck is a check of a hook
+	NodeCheck NodeKind = "check"
+	// NodeGroup is a group of the recipe, or its volumes
+	NodeGroup NodeKind = "group"
+	// NodeParent is a group of the Application CR that groups of the recipe name as Parent
+	NodeParent NodeKind = "parent"
+)
+
+// EdgeKind is what an edge stands for
+type EdgeKind string
+
+const (
+	// EdgeSequence leads from a step to the next step of its workflow, or from a parallel step to the
+	// steps of its set and from these to the next step
+	EdgeSequence EdgeKind = "sequence"
+	// EdgeRuns leads from a step to the operation, check or group it runs
+	EdgeRuns EdgeKind = "runs"
+	// EdgeInverse leads from an operation to its InverseOp
+	EdgeInverse EdgeKind = "inverse"
+	// EdgeParent leads from a group to its Parent
+	EdgeParent EdgeKind = "parent"
+	// EdgeBackupRef leads from a restore-only group to the group its BackupRef names
+	EdgeBackupRef EdgeKind = "backupRef"
+	// EdgeCalls leads from a workflow step to the first step of the workflow it calls
+	EdgeCalls EdgeKind = "calls"
+)
+
+// Node of a graph
+type Node struct {
+	// ID of the node, usable as identifier in DOT and Mermaid
+	ID string
+	// Label shown for the node. Lines are separated by "\n".
+	Label string
+	Kind  NodeKind
+}
+
+// Edge of a graph
+type Edge struct {
+	From, To string
+	// Label shown for the edge, if any
+	Label string
+	Kind  EdgeKind
+}
+
+// Cluster is a set of nodes shown together, the steps of a workflow
+type Cluster struct {
+	ID    string
+	Label string
+	Nodes []string
+}
+
+// Graph of a recipe. Nodes of steps belong to the cluster of their workflow, the other nodes to no
+// cluster.
+type Graph struct {
+	// Name of the graph, the namespace and name of the recipe
+	Name     string
+	Clusters []Cluster
+	Nodes    []Node
+	Edges    []Edge
+}
+
+// New returns the graph of the named workflows of a recipe, or of all workflows it defines if none
+// are named. Named workflows the recipe omits are shown with their default, and the workflows they
+// call are shown too. All groups, operations and checks of the recipe are shown, also the ones no
+// workflow uses.
+func New(recipe *ramendrv1alpha1.Recipe, workflowNames ...string) (*Graph, error) {
+	b := &builder{
+		graph:  &Graph{Name: recipe.Namespace + "/" + recipe.Name},
+		recipe: recipe,
+		ids:    map[string]string{},
+		firsts: map[string]string{},
+		shown:  map[string]bool{},
+	}
+
+	b.groups()
+	b.hooks()
+
+	workflows := []*ramendrv1alpha1.Workflow{}
+
+	for _, name := range workflowNames {
+		wf := recipe.Spec.EffectiveWorkflow(name)
+		if wf == nil {
+			return nil, fmt.Errorf("%w: recipe %s/%s has no workflow %q",
+				workflow.ErrWorkflowNotFound, recipe.Namespace, recipe.Name, name)
+		}
+
+		workflows = append(workflows, wf)
+	}
+
+	if len(workflowNames) == 0 {
+		for _, wf := range recipe.Spec.Workflows {
+			if wf != nil {
+				workflows = append(workflows, wf)
+			}
+		}
+	}
+
+	for _, wf := range workflows {
+		if err := b.workflow(wf, workflow.GroupActionFor(wf.Name)); err != nil {
+			return nil, err
+		}
+	}
+
+	// calls grows while the called workflows are added
+	for i := 0; i < len(b.calls); i++ {
+		call := b.calls[i]
+
+		if called := recipe.Spec.FindWorkflow(call.workflow); called != nil && !b.shown[called.Name] {
+			if err := b.workflow(called, call.action); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, call := range b.calls {
+		if first, ok := b.firsts[call.workflow]; ok {
+			b.edge(call.from, first, EdgeCalls, "calls")
+		}
+	}
+
+	return b.graph, nil
+}
+
+// builder adds the items of a recipe to a graph
+type builder struct {
+	graph  *Graph
+	recipe *ramendrv1alpha1.Recipe
+	// ids of the nodes of groups, operations, checks and parents, by kind and name
+	ids map[string]string
+	// firsts are the ids of the first steps of the shown workflows, by workflow name
+	firsts map[string]string
+	shown  map[string]bool
+	calls  []call
+}
+
+// call is a workflow step calling a workflow, with the action of the calling workflow for groups
+type call struct {
+	from     string
+	workflow string
+	action   workflow.GroupAction
+}
+
+// node adds a node and returns its ID
+func (b *builder) node(kind NodeKind, label string) string {
+	id := fmt.Sprintf("n%d", len(b.graph.Nodes))
+	b.graph.Nodes = append(b.graph.Nodes, Node{ID: id, Label: label, Kind: kind})
+
+	return id
+}
+
+func (b *builder) edge(from, to string, kind EdgeKind, label string) {
+	b.graph.Edges = append(b.graph.Edges, Edge{From: from, To: to, Kind: kind, Label: label})
+}
+
+func (b *builder) groups() {
+	groups := []*ramendrv1alpha1.Group{}
+
+	for _, group := range b.recipe.Spec.Groups {
+		if group != nil {
+			groups = append(groups, group)
+		}
+	}
+
+	if b.recipe.Spec.Volumes != nil {
+		groups = append(groups, b.recipe.Spec.Volumes)
+	}
+
+	for _, group := range groups {
+		b.ids["group/"+group.Name] = b.node(NodeGroup, fmt.Sprintf("group %s\n%s", group.Name, group.Type))
+	}
+
+	for _, group := range groups {
+		id := b.ids["group/"+group.Name]
+
+		if group.Parent != "" {
+			parent, ok := b.ids["parent/"+group.Parent]
+			if !ok {
+				parent = b.node(NodeParent, "application group "+group.Parent)
+				b.ids["parent/"+group.Parent] = parent
+			}
+
+			b.edge(id, parent, EdgeParent, "parent")
+		}
+
+		if backup, ok := b.ids["group/"+group.BackupRef]; ok && group.IsRestoreOnly() {
+			b.edge(id, backup, EdgeBackupRef, "backupRef")
+		}
+	}
+}
+
+func (b *builder) hooks() {
+	for _, hook := range b.recipe.Spec.Hooks {
+		if hook == nil {
+			continue
+		}
+
+		for _, op := range hook.Ops {
+			if op != nil {
+				b.ids["hook/"+hook.Name+"/"+op.Name] = b.node(NodeOp,
+					fmt.Sprintf("%s/%s\n%s: %s", hook.Name, op.Name, hook.Type, op.Command))
+			}
+		}
+
+		for _, chk := range hook.Chks {
+			if chk != nil {
+				b.ids["hook/"+hook.Name+"/"+chk.Name] = b.node(NodeCheck,
+					fmt.Sprintf("%s/%s\n%s", hook.Name, chk.Name, chk.Condition))
+			}
+		}
+
+		for _, op := range hook.Ops {
+			if op == nil || op.InverseOp == "" {
+				continue
+			}
+
+			if inverse, ok := b.ids["hook/"+hook.Name+"/"+op.InverseOp]; ok {
+				b.edge(b.ids["hook/"+hook.Name+"/"+op.Name], inverse, EdgeInverse, "inverse")
+			}
+		}
+	}
+}
+
+// workflow adds the steps of a workflow as a cluster, chained in the order of the sequence. Group
+// steps are linked with the action given, the one of the workflow or of the workflow calling it.
+func (b *builder) workflow(wf *ramendrv1alpha1.Workflow, action workflow.GroupAction) error {
+	cluster := Cluster{
+		ID:    fmt.Sprintf("cluster_%d", len(b.graph.Clusters)),
+		Label: fmt.Sprintf("workflow %s\nfail on %s", wf.Name, failOn(wf)),
+	}
+	b.shown[wf.Name] = true
+
+	// the steps leading to the next step: the previous step, or the steps of a parallel set
+	previous := []string{}
+
+	for i, entry := range wf.Sequence {
+		step, err := ramendrv1alpha1.ParseStep(entry)
+		if err != nil {
+			return fmt.Errorf("workflow %q step %d: %w", wf.Name, i, err)
+		}
+
+		label := stepLabel(fmt.Sprint(i+1), step)
+
+		if step.Kind != ramendrv1alpha1.StepKindParallel {
+			id := b.node(NodeStep, label)
+			cluster.Nodes = append(cluster.Nodes, id)
+			b.chain(previous, id)
+			b.runs(id, step, action)
+
+			if step.Kind == ramendrv1alpha1.StepKindWorkflow {
+				b.calls = append(b.calls, call{from: id, workflow: step.Name, action: action})
+			}
+
+			previous = []string{id}
+
+			continue
+		}
+
+		set := wf.FindParallel(step.Name)
+		if set == nil {
+			return fmt.Errorf("workflow %q step %d: parallel set %q not found", wf.Name, i, step.Name)
+		}
+
+		id := b.node(NodeParallel, fmt.Sprintf("%s\nat most %d at a time", label, set.EffectiveMaxParallel()))
+		cluster.Nodes = append(cluster.Nodes, id)
+		b.chain(previous, id)
+
+		previous = []string{}
+
+		for j, entry := range set.Steps {
+			parallelStep, err := ramendrv1alpha1.ParseStep(entry)
+			if err != nil {
+				return fmt.Errorf("workflow %q parallel set %q step %d: %w", wf.Name, set.Name, j, err)
+			}
+
+			stepID := b.node(NodeStep, stepLabel(fmt.Sprintf("%d%c", i+1, 'a'+rune(j%26)), parallelStep))
+			cluster.Nodes = append(cluster.Nodes, stepID)
+			b.edge(id, stepID, EdgeSequence, "")
+			b.runs(stepID, parallelStep, action)
+
+			previous = append(previous, stepID)
+		}
+	}
+
+	if len(cluster.Nodes) != 0 {
+		b.firsts[wf.Name] = cluster.Nodes[0]
+	}
+
+	b.graph.Clusters = append(b.graph.Clusters, cluster)
+
+	return nil
+}
+
+// stepLabel returns the label of a step, e.g. "2. hook: db/quiesce", with its when condition on a
+// second line if it has one
+func stepLabel(number string, step ramendrv1alpha1.Step) string {
+	if step.When == "" {
+		return fmt.Sprintf("%s. %s", number, step)
+	}
+
+	return fmt.Sprintf("%s. %s\nwhen %s", number, step, step.When)
+}
+
+func (b *builder) chain(previous []string, id string) {
+	for _, from := range previous {
+		b.edge(from, id, EdgeSequence, "")
+	}
+}
+
+// runs links a step to the group, operation or check it runs, if the recipe defines it
+func (b *builder) runs(id string, step ramendrv1alpha1.Step, action workflow.GroupAction) {
+	switch step.Kind {
+	case ramendrv1alpha1.StepKindGroup:
+		if group, ok := b.ids["group/"+step.Name]; ok {
+			b.edge(id, group, EdgeRuns, string(action))
+		}
+	case ramendrv1alpha1.StepKindHook:
+		hook := b.recipe.Spec.FindHook(step.Name)
+		if hook == nil {
+			return
+		}
+
+		opName, err := hook.ResolveOp(step.Op)
+		if err != nil {
+			return
+		}
+
+		if op, ok := b.ids["hook/"+hook.Name+"/"+opName]; ok {
+			b.edge(id, op, EdgeRuns, "")
+		}
+	}
+}
+
+func failOn(wf *ramendrv1alpha1.Workflow) string {
+	if wf.FailOn == "" {
+		return workflow.FailOnAnyError
+	}
+
+	return wf.FailOn
+}
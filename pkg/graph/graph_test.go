@@ -0,0 +1,225 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package graph_test
+
+import (
+	"bytes"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	Recipe "github.com/ramendr/recipe/api/v1alpha1"
+	"github.com/ramendr/recipe/pkg/graph"
+	"github.com/ramendr/recipe/pkg/workflow"
+)
+
+func graphRecipe() *Recipe.Recipe {
+	return &Recipe.Recipe{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "prod"},
+		Spec: Recipe.RecipeSpec{
+			Groups: []*Recipe.Group{
+				{Name: "config", Type: "resource", Parent: "app"},
+				{Name: "config-restore", Type: "resource", BackupRef: "config"},
+			},
+			Volumes: &Recipe.Group{Name: "data", Type: "volume", Parent: "app"},
+			Hooks: []*Recipe.Hook{
+				{
+					Name: "db",
+					Type: "exec",
+					Ops: []*Recipe.Operation{
+						{Name: "quiesce", Command: "/bin/quiesce", InverseOp: "unquiesce"},
+						{Name: "unquiesce", Command: "/bin/unquiesce"},
+					},
+					Chks: []*Recipe.Check{{Name: "ready", Condition: `{$.status.phase} == "Running"`}},
+				},
+			},
+			Workflows: []*Recipe.Workflow{
+				{
+					Name: "backup",
+					Sequence: []map[string]string{
+						{"hook": "db/quiesce"},
+						{"parallel": "groups"},
+						{"hook": "db/unquiesce"},
+					},
+					Parallel: []*Recipe.ParallelSteps{
+						{
+							Name:        "groups",
+							MaxParallel: 2,
+							Steps:       []map[string]string{{"group": "config"}, {"group": "data"}},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+var _ = Describe("Graph", func() {
+	It("links steps, operations, checks and groups", func() {
+		g, err := graph.New(graphRecipe())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(g.Name).To(Equal("prod/db"))
+
+		labels := map[string]string{}
+		for _, node := range g.Nodes {
+			labels[node.ID] = node.Label
+		}
+
+		edges := []string{}
+		for _, edge := range g.Edges {
+			edges = append(edges, labels[edge.From]+" -"+string(edge.Kind)+"-> "+labels[edge.To])
+		}
+
+		Expect(edges).To(ConsistOf(
+			"group config\nresource -parent-> application group app",
+			"group data\nvolume -parent-> application group app",
+			"group config-restore\nresource -backupRef-> group config\nresource",
+			"db/quiesce\nexec: /bin/quiesce -inverse-> db/unquiesce\nexec: /bin/unquiesce",
+			"1. hook: db/quiesce -runs-> db/quiesce\nexec: /bin/quiesce",
+			"1. hook: db/quiesce -sequence-> 2. parallel: groups\nat most 2 at a time",
+			"2. parallel: groups\nat most 2 at a time -sequence-> 2a. group: config",
+			"2. parallel: groups\nat most 2 at a time -sequence-> 2b. group: data",
+			"2a. group: config -runs-> group config\nresource",
+			"2b. group: data -runs-> group data\nvolume",
+			"2a. group: config -sequence-> 3. hook: db/unquiesce",
+			"2b. group: data -sequence-> 3. hook: db/unquiesce",
+			"3. hook: db/unquiesce -runs-> db/unquiesce\nexec: /bin/unquiesce",
+		))
+		Expect(labels).To(ContainElement("db/ready\n" + `{$.status.phase} == "Running"`))
+		Expect(g.Clusters).To(HaveLen(1))
+		Expect(g.Clusters[0].Label).To(Equal("workflow backup\nfail on any-error"))
+		Expect(g.Clusters[0].Nodes).To(HaveLen(5))
+	})
+	It("shows the when conditions of steps", func() {
+		recipe := graphRecipe()
+		recipe.Spec.Workflows[0].Sequence[0]["when"] = "statefulset db: {$.spec.replicas} > 1"
+		recipe.Spec.Workflows[0].Parallel[0].Steps[0]["when"] = "namespace config"
+
+		g, err := graph.New(recipe)
+		Expect(err).NotTo(HaveOccurred())
+
+		labels := []string{}
+		for _, node := range g.Nodes {
+			labels = append(labels, node.Label)
+		}
+
+		Expect(labels).To(ContainElements(
+			"1. hook: db/quiesce\nwhen statefulset db: {$.spec.replicas} > 1",
+			"2a. group: config\nwhen namespace config",
+		))
+	})
+	It("shows named workflows, also defaults of reserved workflows", func() {
+		g, err := graph.New(graphRecipe(), "restore")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(g.Clusters).To(HaveLen(1))
+		Expect(g.Clusters[0].Label).To(HavePrefix("workflow restore\n"))
+
+		_, err = graph.New(graphRecipe(), "migrate")
+		Expect(errors.Is(err, workflow.ErrWorkflowNotFound)).To(BeTrue())
+	})
+	It("shows the workflows called by the shown workflows", func() {
+		recipe := graphRecipe()
+		recipe.Spec.Workflows = append(recipe.Spec.Workflows,
+			&Recipe.Workflow{Name: "groups", Sequence: []map[string]string{{"group": "data"}}},
+			&Recipe.Workflow{Name: "restore", Sequence: []map[string]string{{"workflow": "groups"}}},
+		)
+
+		g, err := graph.New(recipe, "restore")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(g.Clusters).To(HaveLen(2))
+		Expect(g.Clusters[0].Label).To(HavePrefix("workflow restore\n"))
+		Expect(g.Clusters[1].Label).To(HavePrefix("workflow groups\n"))
+
+		labels := map[string]string{}
+		for _, node := range g.Nodes {
+			labels[node.ID] = node.Label
+		}
+
+		edges := []string{}
+		for _, edge := range g.Edges {
+			edges = append(edges, labels[edge.From]+" -"+edge.Label+"-> "+labels[edge.To])
+		}
+
+		Expect(edges).To(ContainElements(
+			"1. workflow: groups -calls-> 1. group: data",
+			"1. group: data -restore-> group data\nvolume",
+		))
+	})
+	It("writes DOT and Mermaid", func() {
+		recipe := graphRecipe()
+		recipe.Spec.Groups = nil
+		recipe.Spec.Volumes = nil
+		recipe.Spec.Hooks[0].Chks = nil
+		recipe.Spec.Workflows[0].Sequence = recipe.Spec.Workflows[0].Sequence[:1]
+
+		g, err := graph.New(recipe)
+		Expect(err).NotTo(HaveOccurred())
+
+		out := &bytes.Buffer{}
+		Expect(graph.Write(out, g, graph.FormatDOT)).To(Succeed())
+		Expect(out.String()).To(Equal(`digraph "recipe prod/db" {
+  node [fontname="sans-serif"];
+  subgraph cluster_0 {
+    label="workflow backup\nfail on any-error";
+    n2 [label="1. hook: db/quiesce", shape=box];
+  }
+  n0 [label="db/quiesce\nexec: /bin/quiesce", shape=component];
+  n1 [label="db/unquiesce\nexec: /bin/unquiesce", shape=component];
+  n0 -> n1 [label="inverse", style=dotted];
+  n2 -> n0 [style=dashed];
+}
+`))
+
+		out.Reset()
+		Expect(graph.Write(out, g, graph.FormatMermaid)).To(Succeed())
+		Expect(out.String()).To(Equal(`---
+title: recipe prod/db
+---
+flowchart TB
+  subgraph cluster_0["workflow backup<br>fail on any-error"]
+    n2["1. hook: db/quiesce"]
+  end
+  n0[["db/quiesce<br>exec: /bin/quiesce"]]
+  n1[["db/unquiesce<br>exec: /bin/unquiesce"]]
+  n0 -.->|"inverse"| n1
+  n2 -.-> n0
+`))
+
+		Expect(graph.Write(out, g, "svg")).To(MatchError(ContainSubstring(`unsupported format "svg"`)))
+	})
+	It("escapes quotes in labels", func() {
+		g, err := graph.New(graphRecipe())
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(graph.DOT(g)).To(ContainSubstring(`[label="db/ready\n{$.status.phase} == \"Running\"", shape=diamond]`))
+		Expect(graph.Mermaid(g)).To(ContainSubstring(`{"db/ready<br>{$.status.phase} == #quot;Running#quot;"}`))
+	})
+	It("escapes markup and entities in Mermaid labels", func() {
+		recipe := graphRecipe()
+		recipe.Spec.Groups = nil
+		recipe.Spec.Volumes = nil
+		recipe.Spec.Hooks = nil
+		recipe.Spec.Workflows[0].Sequence = []map[string]string{{
+			"group": "config",
+			"when":  `statefulset db: {$.status.readyReplicas} < 3 && {$.metadata.labels.tier} != "#1"`,
+		}}
+		recipe.Spec.Workflows[0].Parallel = nil
+
+		g, err := graph.New(recipe)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(graph.Mermaid(g)).To(Equal(`---
+title: recipe prod/db
+---
+flowchart TB
+  subgraph cluster_0["workflow backup<br>fail on any-error"]
+    n0["1. group: config<br>when statefulset db: {$.status.readyReplicas} #lt; 3 #amp;#amp; ` +
+			`{$.metadata.labels.tier} != #quot;#35;1#quot;"]
+  end
+`))
+	})
+})
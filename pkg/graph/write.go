@@ -0,0 +1,215 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package graph
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Format of a written graph
+type Format string
+
+const (
+	// FormatDOT is the language of Graphviz
+	FormatDOT Format = "dot"
+	// FormatMermaid is a flowchart of Mermaid, which GitHub renders in markdown
+	FormatMermaid Format = "mermaid"
+)
+
+// SupportedFormats lists the formats Write supports
+var SupportedFormats = []Format{FormatDOT, FormatMermaid}
+
+// Write writes the graph in the format
+func Write(w io.Writer, g *Graph, format Format) error {
+	var text string
+
+	switch format {
+	case FormatDOT:
+		text = DOT(g)
+	case FormatMermaid:
+		text = Mermaid(g)
+	default:
+		return fmt.Errorf("unsupported format %q, must be one of %q", format, SupportedFormats)
+	}
+
+	_, err := io.WriteString(w, text)
+
+	return err
+}
+
+// dotNodeAttrs are the attributes of nodes by kind
+var dotNodeAttrs = map[NodeKind]string{
+	NodeStep:     "shape=box",
+	NodeParallel: "shape=box, style=rounded",
+	NodeOp:       "shape=component",
+	NodeCheck:    "shape=diamond",
+	NodeGroup:    "shape=cylinder",
+	NodeParent:   "shape=cylinder, style=dashed",
+}
+
+// dotEdgeAttrs are the attributes of edges by kind, besides their label
+var dotEdgeAttrs = map[EdgeKind]string{
+	EdgeSequence:  "",
+	EdgeRuns:      "style=dashed",
+	EdgeInverse:   "style=dotted",
+	EdgeParent:    "style=dashed",
+	EdgeBackupRef: "style=dashed",
+	EdgeCalls:     "style=bold",
+}
+
+// DOT returns the graph in the language of Graphviz, with the steps of each workflow in a cluster
+func DOT(g *Graph) string {
+	var out strings.Builder
+
+	fmt.Fprintf(&out, "digraph %s {\n", dotQuote("recipe "+g.Name))
+	out.WriteString("  node [fontname=\"sans-serif\"];\n")
+
+	nodes := make(map[string]Node, len(g.Nodes))
+	clustered := map[string]bool{}
+
+	for _, node := range g.Nodes {
+		nodes[node.ID] = node
+	}
+
+	for _, cluster := range g.Clusters {
+		fmt.Fprintf(&out, "  subgraph %s {\n", cluster.ID)
+		fmt.Fprintf(&out, "    label=%s;\n", dotQuote(cluster.Label))
+
+		for _, id := range cluster.Nodes {
+			writeDOTNode(&out, "    ", nodes[id])
+
+			clustered[id] = true
+		}
+
+		out.WriteString("  }\n")
+	}
+
+	for _, node := range g.Nodes {
+		if !clustered[node.ID] {
+			writeDOTNode(&out, "  ", node)
+		}
+	}
+
+	for _, edge := range g.Edges {
+		attrs := []string{}
+		if edge.Label != "" {
+			attrs = append(attrs, "label="+dotQuote(edge.Label))
+		}
+
+		if a := dotEdgeAttrs[edge.Kind]; a != "" {
+			attrs = append(attrs, a)
+		}
+
+		if len(attrs) == 0 {
+			fmt.Fprintf(&out, "  %s -> %s;\n", edge.From, edge.To)
+		} else {
+			fmt.Fprintf(&out, "  %s -> %s [%s];\n", edge.From, edge.To, strings.Join(attrs, ", "))
+		}
+	}
+
+	out.WriteString("}\n")
+
+	return out.String()
+}
+
+func writeDOTNode(out *strings.Builder, indent string, node Node) {
+	fmt.Fprintf(out, "%s%s [label=%s, %s];\n", indent, node.ID, dotQuote(node.Label), dotNodeAttrs[node.Kind])
+}
+
+// dotQuote returns a DOT string of the text, with its lines centered
+func dotQuote(text string) string {
+	text = strings.ReplaceAll(text, `\`, `\\`)
+	text = strings.ReplaceAll(text, `"`, `\"`)
+
+	return `"` + strings.ReplaceAll(text, "\n", `\n`) + `"`
+}
+
+// mermaidShapes are the opening and closing delimiters of nodes by kind
+var mermaidShapes = map[NodeKind][2]string{
+	NodeStep:     {"[", "]"},
+	NodeParallel: {"(", ")"},
+	NodeOp:       {"[[", "]]"},
+	NodeCheck:    {"{", "}"},
+	NodeGroup:    {"[(", ")]"},
+	NodeParent:   {"([", "])"},
+}
+
+// mermaidArrows are the arrows of edges by kind
+var mermaidArrows = map[EdgeKind]string{
+	EdgeSequence:  "-->",
+	EdgeRuns:      "-.->",
+	EdgeInverse:   "-.->",
+	EdgeParent:    "-.->",
+	EdgeBackupRef: "-.->",
+	EdgeCalls:     "==>",
+}
+
+// Mermaid returns the graph as Mermaid flowchart, with the steps of each workflow in a subgraph
+func Mermaid(g *Graph) string {
+	var out strings.Builder
+
+	out.WriteString("---\n")
+	fmt.Fprintf(&out, "title: recipe %s\n", g.Name)
+	out.WriteString("---\n")
+	out.WriteString("flowchart TB\n")
+
+	nodes := make(map[string]Node, len(g.Nodes))
+	clustered := map[string]bool{}
+
+	for _, node := range g.Nodes {
+		nodes[node.ID] = node
+	}
+
+	for _, cluster := range g.Clusters {
+		fmt.Fprintf(&out, "  subgraph %s[%s]\n", cluster.ID, mermaidQuote(cluster.Label))
+
+		for _, id := range cluster.Nodes {
+			writeMermaidNode(&out, "    ", nodes[id])
+
+			clustered[id] = true
+		}
+
+		out.WriteString("  end\n")
+	}
+
+	for _, node := range g.Nodes {
+		if !clustered[node.ID] {
+			writeMermaidNode(&out, "  ", node)
+		}
+	}
+
+	for _, edge := range g.Edges {
+		arrow := mermaidArrows[edge.Kind]
+		if edge.Label != "" {
+			arrow += "|" + mermaidQuote(edge.Label) + "|"
+		}
+
+		fmt.Fprintf(&out, "  %s %s %s\n", edge.From, arrow, edge.To)
+	}
+
+	return out.String()
+}
+
+func writeMermaidNode(out *strings.Builder, indent string, node Node) {
+	shape := mermaidShapes[node.Kind]
+
+	fmt.Fprintf(out, "%s%s%s%s%s\n", indent, node.ID, shape[0], mermaidQuote(node.Label), shape[1])
+}
+
+// mermaidEscaper writes the characters Mermaid would take as markup or entity as entities
+var mermaidEscaper = strings.NewReplacer(
+	`#`, "#35;",
+	`"`, "#quot;",
+	`&`, "#amp;",
+	`<`, "#lt;",
+	`>`, "#gt;",
+)
+
+// mermaidQuote returns a Mermaid string of the text. Quotes, markup and entity characters are
+// written as entities, and lines are separated by <br>.
+func mermaidQuote(text string) string {
+	return `"` + strings.ReplaceAll(mermaidEscaper.Replace(text), "\n", "<br>") + `"`
+}
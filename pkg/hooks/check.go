@@ -0,0 +1,201 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package hooks
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/ramendr/recipe/api/condition"
+	ramendrv1alpha1 "github.com/ramendr/recipe/api/v1alpha1"
+	"github.com/ramendr/recipe/pkg/selector"
+	"github.com/ramendr/recipe/pkg/workflow"
+)
+
+// CheckExecutor evaluates the conditions of checks against the objects a hook selects. It runs the
+// checks of check hooks, and of exec and scale hooks through their executors.
+type CheckExecutor struct {
+	Reader client.Reader
+	// PollInterval is the interval of evaluating the condition until it is true. Defaults to 2
+	// seconds.
+	PollInterval time.Duration
+}
+
+var _ workflow.HookExecutor = &CheckExecutor{}
+
+// ExecuteOp is not supported for check hooks, which only have checks
+func (e *CheckExecutor) ExecuteOp(ctx context.Context, recipe *ramendrv1alpha1.Recipe, hook *ramendrv1alpha1.Hook,
+	op *ramendrv1alpha1.Operation,
+) ([]workflow.Target, error) {
+	return nil, fmt.Errorf("op %q of hook %q: ops are not supported by check hooks", op.Name, hook.Name)
+}
+
+// ExecuteCheck selects the objects of the hook and evaluates the condition of the check against
+// each of them until it is true for all, or the timeout of the check elapses. Objects are selected
+// again on every evaluation, so the check waits for objects that do not exist yet.
+func (e *CheckExecutor) ExecuteCheck(ctx context.Context, recipe *ramendrv1alpha1.Recipe, hook *ramendrv1alpha1.Hook,
+	chk *ramendrv1alpha1.Check,
+) ([]workflow.Target, error) {
+	expr, err := checkCondition(hook, chk)
+	if err != nil {
+		return nil, err
+	}
+
+	interval := e.PollInterval
+	if interval == 0 {
+		interval = defaultPollInterval
+	}
+
+	resolver := &selector.Resolver{Reader: e.Reader, DefaultNamespace: recipe.Namespace}
+
+	ctx, cancel := context.WithTimeout(ctx, Timeout(chk.Timeout, hook))
+	defer cancel()
+
+	var targets []workflow.Target
+
+	err = wait.PollUntilContextCancel(ctx, interval, true, func(ctx context.Context) (bool, error) {
+		targets, err = e.evaluate(ctx, resolver, hook, expr)
+		if err != nil {
+			return false, err
+		}
+
+		return len(targets) != 0 && firstError(targets) == nil, nil
+	})
+	if err == nil {
+		return targets, nil
+	}
+
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("check %q of hook %q: no %ss selected in namespace %s: %w", chk.Name, hook.Name,
+			hookObjectKind(hook), resolver.HookNamespace(hook), err)
+	}
+
+	failed := 0
+
+	for i := range targets {
+		if targets[i].Err != nil {
+			failed++
+		}
+	}
+
+	return targets, fmt.Errorf("check %q of hook %q failed for %d of %d %ss, first error: %w",
+		chk.Name, hook.Name, failed, len(targets), hookObjectKind(hook), firstError(targets))
+}
+
+// EvaluateCheck evaluates the condition of a check once against every object the hook selects,
+// without waiting for it to become true, e.g. to report checks in a dry run. Objects not meeting
+// the condition have their Target.Err set.
+func EvaluateCheck(ctx context.Context, reader client.Reader, recipe *ramendrv1alpha1.Recipe,
+	hook *ramendrv1alpha1.Hook, chk *ramendrv1alpha1.Check,
+) ([]workflow.Target, error) {
+	expr, err := checkCondition(hook, chk)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &CheckExecutor{Reader: reader}
+
+	return e.evaluate(ctx, &selector.Resolver{Reader: reader, DefaultNamespace: recipe.Namespace}, hook, expr)
+}
+
+// checkCondition parses the condition of a check. A check without condition holds once the hook
+// selects objects.
+func checkCondition(hook *ramendrv1alpha1.Hook, chk *ramendrv1alpha1.Check) (*condition.Expression, error) {
+	text := chk.Condition
+	if text == "" {
+		text = "true"
+	}
+
+	expr, err := condition.Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("check %q of hook %q: invalid condition: %w", chk.Name, hook.Name, err)
+	}
+
+	return expr, nil
+}
+
+// evaluate evaluates the condition against every selected object. Objects not meeting the condition
+// have their Target.Err set.
+func (e *CheckExecutor) evaluate(ctx context.Context, resolver *selector.Resolver, hook *ramendrv1alpha1.Hook,
+	expr *condition.Expression,
+) ([]workflow.Target, error) {
+	objs, err := hookObjects(ctx, resolver, hook)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select objects of hook %q: %w", hook.Name, err)
+	}
+
+	targets := make([]workflow.Target, len(objs))
+
+	for i, obj := range objs {
+		targets[i] = workflow.Target{Kind: hookObjectKind(hook), Namespace: obj.GetNamespace(), Name: obj.GetName()}
+
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return nil, err
+		}
+
+		met, err := expr.Evaluate(content)
+
+		switch {
+		case err != nil:
+			targets[i].Err = fmt.Errorf("%s %s/%s: %w", targets[i].Kind, obj.GetNamespace(), obj.GetName(), err)
+		case met:
+			targets[i].Message = "condition met"
+		default:
+			targets[i].Err = fmt.Errorf("%s %s/%s: condition %q not met", targets[i].Kind, obj.GetNamespace(),
+				obj.GetName(), expr)
+		}
+	}
+
+	log.FromContext(ctx).V(1).Info("evaluated condition", "hook", hook.Name, "condition", expr.String(),
+		"objects", len(targets), "firstError", firstError(targets))
+
+	return targets, nil
+}
+
+// hookObjects returns the pods or workloads a hook selects
+func hookObjects(ctx context.Context, resolver *selector.Resolver, hook *ramendrv1alpha1.Hook,
+) ([]client.Object, error) {
+	objs := []client.Object{}
+
+	if hookObjectKind(hook) == selector.KindPod {
+		pods, err := resolver.HookPods(ctx, hook)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range pods {
+			objs = append(objs, &pods[i])
+		}
+
+		return objs, nil
+	}
+
+	workloads, err := resolver.HookWorkloads(ctx, hook)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range workloads {
+		objs = append(objs, workloads[i].Object)
+	}
+
+	return objs, nil
+}
+
+// hookObjectKind returns the kind of objects the checks of a hook are evaluated against. Exec hooks
+// evaluate them against pods, even if they select pods through workloads.
+func hookObjectKind(hook *ramendrv1alpha1.Hook) string {
+	if hook.Type == ramendrv1alpha1.HookTypeExec || hook.SelectResource == "" {
+		return selector.KindPod
+	}
+
+	return hook.SelectResource
+}
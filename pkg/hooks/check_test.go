@@ -0,0 +1,111 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package hooks_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	Recipe "github.com/ramendr/recipe/api/v1alpha1"
+	"github.com/ramendr/recipe/pkg/hooks"
+)
+
+var _ = Describe("CheckExecutor", func() {
+	var (
+		k8sClient client.Client
+		executor  *hooks.CheckExecutor
+		recipe    *Recipe.Recipe
+		hook      *Recipe.Hook
+	)
+
+	ctx := context.TODO()
+
+	BeforeEach(func() {
+		k8sClient = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+			runningPod("db-0", corev1.PodRunning),
+			runningPod("db-1", corev1.PodPending),
+			deployment(3, 3, nil),
+		).Build()
+		executor = &hooks.CheckExecutor{Reader: k8sClient, PollInterval: 10 * time.Millisecond}
+		recipe = &Recipe.Recipe{ObjectMeta: metav1.ObjectMeta{Namespace: "app", Name: "recipe"}}
+		hook = &Recipe.Hook{
+			Name:          "db",
+			Type:          Recipe.HookTypeCheck,
+			LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}},
+			Timeout:       1,
+		}
+	})
+
+	It("evaluates conditions against the selected workloads", func() {
+		hook.SelectResource = "deployment"
+		chk := &Recipe.Check{Name: "ready", Condition: "{$.status.readyReplicas} == {$.spec.replicas}"}
+
+		targets, err := executor.ExecuteCheck(ctx, recipe, hook, chk)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(targets).To(HaveLen(1))
+		Expect(targets[0].Kind).To(Equal("deployment"))
+		Expect(targets[0].Message).To(Equal("condition met"))
+	})
+	It("polls until the condition is true for all pods", func() {
+		go func() {
+			defer GinkgoRecover()
+			time.Sleep(50 * time.Millisecond)
+
+			pod := &corev1.Pod{}
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: "app", Name: "db-1"}, pod)).To(Succeed())
+			pod.Status.Phase = corev1.PodRunning
+			Expect(k8sClient.Status().Update(ctx, pod)).To(Succeed())
+		}()
+
+		chk := &Recipe.Check{Name: "running", Condition: "{$.status.phase} == 'Running'"}
+
+		targets, err := executor.ExecuteCheck(ctx, recipe, hook, chk)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(targets).To(HaveLen(2))
+	})
+	It("fails when the condition is not true within the timeout of the check", func() {
+		chk := &Recipe.Check{Name: "running", Condition: "{$.status.phase} == 'Running'", Timeout: 1}
+
+		start := time.Now()
+		targets, err := executor.ExecuteCheck(ctx, recipe, hook, chk)
+		Expect(err).To(MatchError(ContainSubstring("failed for 1 of 2 pods")))
+		Expect(time.Since(start)).To(BeNumerically("<", 2*time.Second))
+		Expect(targets[0].Err).ToNot(HaveOccurred())
+		Expect(targets[1].Err).To(MatchError(ContainSubstring("not met")))
+	})
+	It("fails without selected objects", func() {
+		hook.Namespace = "other"
+		chk := &Recipe.Check{Name: "running", Condition: "true"}
+
+		_, err := executor.ExecuteCheck(ctx, recipe, hook, chk)
+		Expect(err).To(MatchError(ContainSubstring("no pods selected")))
+	})
+	It("holds once objects are selected for checks without condition", func() {
+		targets, err := executor.ExecuteCheck(ctx, recipe, hook, &Recipe.Check{Name: "exists"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(targets).To(HaveLen(2))
+	})
+	It("evaluates conditions once without waiting", func() {
+		chk := &Recipe.Check{Name: "running", Condition: "{$.status.phase} == 'Running'", Timeout: 60}
+
+		targets, err := hooks.EvaluateCheck(ctx, k8sClient, recipe, hook, chk)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(targets).To(HaveLen(2))
+		Expect(targets[0].Message).To(Equal("condition met"))
+		Expect(targets[1].Err).To(MatchError(ContainSubstring("not met")))
+	})
+	It("rejects invalid conditions", func() {
+		_, err := executor.ExecuteCheck(ctx, recipe, hook, &Recipe.Check{Name: "bad", Condition: "{$.x} =="})
+		Expect(err).To(MatchError(ContainSubstring("invalid condition")))
+	})
+})
@@ -0,0 +1,275 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	ramendrv1alpha1 "github.com/ramendr/recipe/api/v1alpha1"
+	"github.com/ramendr/recipe/pkg/selector"
+	"github.com/ramendr/recipe/pkg/workflow"
+)
+
+// MaxOutputBytes is the amount of stdout and stderr kept of each command
+const MaxOutputBytes = 64 * 1024
+
+const defaultContainerAnnotation = "kubectl.kubernetes.io/default-container"
+
+// RemoteExecutor runs a command in a container of a pod
+type RemoteExecutor interface {
+	// Exec runs the command and streams its output. It returns the exit code of the command, or an
+	// error if the command could not be run to completion.
+	Exec(ctx context.Context, namespace, pod, container string, command []string,
+		stdout, stderr io.Writer) (int, error)
+}
+
+// ExecExecutor runs the operations of exec hooks in the pods the hook selects
+type ExecExecutor struct {
+	// Reader is used to select pods
+	Reader client.Reader
+	// Remote runs the commands
+	Remote RemoteExecutor
+}
+
+var _ workflow.HookExecutor = &ExecExecutor{}
+
+// ExecuteOp runs Operation.Command in Operation.Container of the running pods the hook selects, or
+// in the first of them if the hook is SinglePodOnly. Commands run concurrently in all pods and
+// fail if any pod fails to run them or exits non-zero. A command given as a JSON array is run as
+// is, any other is split into arguments at white space outside of quotes.
+func (e *ExecExecutor) ExecuteOp(ctx context.Context, recipe *ramendrv1alpha1.Recipe, hook *ramendrv1alpha1.Hook,
+	op *ramendrv1alpha1.Operation,
+) ([]workflow.Target, error) {
+	command, err := SplitCommand(op.Command)
+	if err != nil {
+		return nil, fmt.Errorf("op %q of hook %q: %w", op.Name, hook.Name, err)
+	}
+
+	resolver := &selector.Resolver{Reader: e.Reader, DefaultNamespace: recipe.Namespace}
+
+	pods, err := resolver.HookPods(ctx, hook)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select pods of hook %q: %w", hook.Name, err)
+	}
+
+	pods = runningPods(pods)
+	if len(pods) == 0 {
+		return nil, fmt.Errorf("hook %q selects no running pods in namespace %s", hook.Name,
+			resolver.HookNamespace(hook))
+	}
+
+	if hook.SinglePodOnly {
+		pods = pods[:1]
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, Timeout(op.Timeout, hook))
+	defer cancel()
+
+	targets := make([]workflow.Target, len(pods))
+
+	var wg sync.WaitGroup
+
+	for i := range pods {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			targets[i] = e.exec(ctx, &pods[i], op.Container, command)
+		}(i)
+	}
+
+	wg.Wait()
+
+	failed := 0
+
+	for i := range targets {
+		if targets[i].Err != nil {
+			failed++
+		}
+	}
+
+	if failed != 0 {
+		return targets, fmt.Errorf("op %q of hook %q failed in %d of %d pods, first error: %w",
+			op.Name, hook.Name, failed, len(targets), firstError(targets))
+	}
+
+	return targets, nil
+}
+
+// ExecuteCheck evaluates the condition of the check against the pods the hook selects, see
+// CheckExecutor
+func (e *ExecExecutor) ExecuteCheck(ctx context.Context, recipe *ramendrv1alpha1.Recipe, hook *ramendrv1alpha1.Hook,
+	chk *ramendrv1alpha1.Check,
+) ([]workflow.Target, error) {
+	return (&CheckExecutor{Reader: e.Reader}).ExecuteCheck(ctx, recipe, hook, chk)
+}
+
+func (e *ExecExecutor) exec(ctx context.Context, pod *corev1.Pod, container string, command []string,
+) workflow.Target {
+	if container == "" {
+		container = defaultContainer(pod)
+	}
+
+	target := workflow.Target{Kind: selector.KindPod, Namespace: pod.Namespace, Name: pod.Name, Container: container}
+	stdout := &limitedBuffer{limit: MaxOutputBytes}
+	stderr := &limitedBuffer{limit: MaxOutputBytes}
+
+	target.ExitCode, target.Err = e.Remote.Exec(ctx, pod.Namespace, pod.Name, container, command, stdout, stderr)
+	target.Stdout = stdout.String()
+	target.Stderr = stderr.String()
+
+	switch {
+	case target.Err != nil:
+		target.Err = fmt.Errorf("pod %s/%s container %s: %w", pod.Namespace, pod.Name, container, target.Err)
+	case target.ExitCode != 0:
+		target.Err = fmt.Errorf("pod %s/%s container %s: command exited with code %d",
+			pod.Namespace, pod.Name, container, target.ExitCode)
+	}
+
+	log.FromContext(ctx).V(1).Info("ran command", "pod", pod.Name, "container", container,
+		"command", command, "exitCode", target.ExitCode)
+
+	return target
+}
+
+// SplitCommand splits a command into its arguments. A command starting with "[" is parsed as a
+// JSON array of strings. Otherwise, arguments are separated by white space; single and double
+// quotes group white space into an argument and are removed.
+func SplitCommand(command string) ([]string, error) {
+	if strings.HasPrefix(strings.TrimSpace(command), "[") {
+		args := []string{}
+		if err := json.Unmarshal([]byte(command), &args); err != nil {
+			return nil, fmt.Errorf("invalid command array: %w", err)
+		}
+
+		if len(args) == 0 {
+			return nil, fmt.Errorf("command array is empty")
+		}
+
+		return args, nil
+	}
+
+	args := []string{}
+
+	var (
+		arg     strings.Builder
+		inArg   bool
+		quote   rune
+		escaped bool
+	)
+
+	for _, c := range command {
+		switch {
+		case escaped:
+			arg.WriteRune(c)
+			escaped = false
+		case c == '\\' && quote != '\'':
+			escaped, inArg = true, true
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+			arg.WriteRune(c)
+		case c == '\'' || c == '"':
+			quote, inArg = c, true
+		case c == ' ' || c == '\t' || c == '\n':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(c)
+			inArg = true
+		}
+	}
+
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("unterminated quote or escape in command %q", command)
+	}
+
+	if inArg {
+		args = append(args, arg.String())
+	}
+
+	if len(args) == 0 {
+		return nil, fmt.Errorf("command is empty")
+	}
+
+	return args, nil
+}
+
+func runningPods(pods []corev1.Pod) []corev1.Pod {
+	running := []corev1.Pod{}
+
+	for i := range pods {
+		if pods[i].Status.Phase == corev1.PodRunning && pods[i].DeletionTimestamp == nil {
+			running = append(running, pods[i])
+		}
+	}
+
+	return running
+}
+
+// defaultContainer returns the container kubectl would exec into
+func defaultContainer(pod *corev1.Pod) string {
+	if name := pod.Annotations[defaultContainerAnnotation]; name != "" {
+		return name
+	}
+
+	if len(pod.Spec.Containers) != 0 {
+		return pod.Spec.Containers[0].Name
+	}
+
+	return ""
+}
+
+func firstError(targets []workflow.Target) error {
+	for i := range targets {
+		if targets[i].Err != nil {
+			return targets[i].Err
+		}
+	}
+
+	return nil
+}
+
+// limitedBuffer keeps the first limit bytes written to it and drops the rest
+type limitedBuffer struct {
+	bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.Len(); room < len(p) {
+		b.truncated = true
+
+		if room > 0 {
+			b.Buffer.Write(p[:room])
+		}
+
+		return len(p), nil
+	}
+
+	return b.Buffer.Write(p)
+}
+
+func (b *limitedBuffer) String() string {
+	if b.truncated {
+		return b.Buffer.String() + "\n[truncated]"
+	}
+
+	return b.Buffer.String()
+}
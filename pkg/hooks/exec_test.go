@@ -0,0 +1,168 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package hooks_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	Recipe "github.com/ramendr/recipe/api/v1alpha1"
+	"github.com/ramendr/recipe/pkg/hooks"
+)
+
+// fakeRemote records the commands it is asked to run and answers with the exit code configured for
+// the pod
+type fakeRemote struct {
+	mu        sync.Mutex
+	commands  map[string][]string
+	exitCodes map[string]int
+	deadlines map[string]time.Duration
+}
+
+func (r *fakeRemote) Exec(ctx context.Context, namespace, pod, container string, command []string,
+	stdout, stderr io.Writer,
+) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.commands[pod+"/"+container] = command
+
+	if deadline, ok := ctx.Deadline(); ok {
+		r.deadlines[pod] = time.Until(deadline)
+	}
+
+	fmt.Fprintf(stdout, "ran in %s", pod)
+
+	if code := r.exitCodes[pod]; code != 0 {
+		fmt.Fprintf(stderr, "failed in %s", pod)
+
+		return code, nil
+	}
+
+	return 0, nil
+}
+
+func runningPod(name string, phase corev1.PodPhase) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "app", Name: name, Labels: map[string]string{"app": "db"}},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "db"}, {Name: "sidecar"}}},
+		Status:     corev1.PodStatus{Phase: phase},
+	}
+}
+
+var _ = Describe("ExecExecutor", func() {
+	var (
+		remote   *fakeRemote
+		executor *hooks.ExecExecutor
+		recipe   *Recipe.Recipe
+		hook     *Recipe.Hook
+	)
+
+	ctx := context.TODO()
+
+	BeforeEach(func() {
+		remote = &fakeRemote{commands: map[string][]string{}, exitCodes: map[string]int{}, deadlines: map[string]time.Duration{}}
+		objs := []client.Object{
+			runningPod("db-0", corev1.PodRunning),
+			runningPod("db-1", corev1.PodRunning),
+			runningPod("db-2", corev1.PodPending),
+		}
+		executor = &hooks.ExecExecutor{
+			Reader: fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(objs...).Build(),
+			Remote: remote,
+		}
+		recipe = &Recipe.Recipe{ObjectMeta: metav1.ObjectMeta{Namespace: "app", Name: "recipe"}}
+		hook = &Recipe.Hook{
+			Name:          "db",
+			Type:          "exec",
+			LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}},
+			Timeout:       60,
+		}
+	})
+
+	It("runs the command in all running pods", func() {
+		op := &Recipe.Operation{Name: "quiesce", Command: `/bin/sh -c "fsfreeze -f /data"`}
+
+		targets, err := executor.ExecuteOp(ctx, recipe, hook, op)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(targets).To(HaveLen(2))
+		Expect(targets[0].Name).To(Equal("db-0"))
+		Expect(targets[0].Container).To(Equal("db"))
+		Expect(targets[0].Stdout).To(Equal("ran in db-0"))
+		Expect(remote.commands).To(Equal(map[string][]string{
+			"db-0/db": {"/bin/sh", "-c", "fsfreeze -f /data"},
+			"db-1/db": {"/bin/sh", "-c", "fsfreeze -f /data"},
+		}))
+	})
+	It("runs the command in a single pod", func() {
+		hook.SinglePodOnly = true
+		op := &Recipe.Operation{Name: "quiesce", Container: "sidecar", Command: `["quiesce", "--all"]`}
+
+		targets, err := executor.ExecuteOp(ctx, recipe, hook, op)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(targets).To(HaveLen(1))
+		Expect(remote.commands).To(Equal(map[string][]string{"db-0/sidecar": {"quiesce", "--all"}}))
+	})
+	It("fails on non-zero exit codes", func() {
+		remote.exitCodes["db-1"] = 3
+		op := &Recipe.Operation{Name: "quiesce", Command: "quiesce"}
+
+		targets, err := executor.ExecuteOp(ctx, recipe, hook, op)
+		Expect(err).To(MatchError(ContainSubstring("failed in 1 of 2 pods")))
+		Expect(targets[0].Err).ToNot(HaveOccurred())
+		Expect(targets[1].ExitCode).To(Equal(3))
+		Expect(targets[1].Stderr).To(Equal("failed in db-1"))
+	})
+	It("fails without running pods", func() {
+		hook.Namespace = "other"
+		op := &Recipe.Operation{Name: "quiesce", Command: "quiesce"}
+
+		_, err := executor.ExecuteOp(ctx, recipe, hook, op)
+		Expect(err).To(MatchError(ContainSubstring("selects no running pods")))
+	})
+	It("applies the timeout of the op, then of the hook", func() {
+		_, err := executor.ExecuteOp(ctx, recipe, hook, &Recipe.Operation{Name: "a", Command: "a", Timeout: 5})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(remote.deadlines["db-0"]).To(BeNumerically("~", 5*time.Second, time.Second))
+
+		_, err = executor.ExecuteOp(ctx, recipe, hook, &Recipe.Operation{Name: "b", Command: "b"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(remote.deadlines["db-0"]).To(BeNumerically("~", 60*time.Second, time.Second))
+	})
+})
+
+var _ = Describe("Timeout", func() {
+	It("defaults to 30s", func() {
+		Expect(hooks.Timeout(0, &Recipe.Hook{})).To(Equal(30 * time.Second))
+	})
+})
+
+var _ = Describe("SplitCommand", func() {
+	It("splits at white space outside of quotes", func() {
+		Expect(hooks.SplitCommand(`psql -c 'SELECT 1;' --dbname "my db" a\ b`)).To(
+			Equal([]string{"psql", "-c", "SELECT 1;", "--dbname", "my db", "a b"}))
+	})
+	It("rejects unterminated quotes and empty commands", func() {
+		_, err := hooks.SplitCommand(`echo "abc`)
+		Expect(err).To(HaveOccurred())
+
+		_, err = hooks.SplitCommand("  ")
+		Expect(err).To(HaveOccurred())
+
+		_, err = hooks.SplitCommand("[]")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,39 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+// Package hooks implements the workflow.HookExecutor of each Hook.Type.
+package hooks
+
+import (
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ramendrv1alpha1 "github.com/ramendr/recipe/api/v1alpha1"
+	"github.com/ramendr/recipe/pkg/workflow"
+)
+
+// DefaultTimeout applies to operations and checks when neither they nor their hook set a timeout
+const DefaultTimeout = 30 * time.Second
+
+// Timeout returns the timeout of an operation or check: its own if set, else the one of its hook,
+// else DefaultTimeout
+func Timeout(seconds int, hook *ramendrv1alpha1.Hook) time.Duration {
+	switch {
+	case seconds > 0:
+		return time.Duration(seconds) * time.Second
+	case hook.Timeout > 0:
+		return time.Duration(hook.Timeout) * time.Second
+	default:
+		return DefaultTimeout
+	}
+}
+
+// NewExecutors returns the executors of all hook types, by Hook.Type, for use in workflow.Engine
+func NewExecutors(c client.Client, remote RemoteExecutor) map[string]workflow.HookExecutor {
+	return map[string]workflow.HookExecutor{
+		ramendrv1alpha1.HookTypeExec:  &ExecExecutor{Reader: c, Remote: remote},
+		ramendrv1alpha1.HookTypeScale: &ScaleExecutor{Client: c},
+		ramendrv1alpha1.HookTypeCheck: &CheckExecutor{Reader: c},
+	}
+}
@@ -0,0 +1,70 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package hooks
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+// PodRemoteExecutor runs commands through the exec subresource of pods
+type PodRemoteExecutor struct {
+	config    *rest.Config
+	clientset kubernetes.Interface
+}
+
+var _ RemoteExecutor = &PodRemoteExecutor{}
+
+// NewPodRemoteExecutor returns a RemoteExecutor for the cluster of the given config
+func NewPodRemoteExecutor(config *rest.Config) (*PodRemoteExecutor, error) {
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PodRemoteExecutor{config: config, clientset: clientset}, nil
+}
+
+// Exec runs the command in the container of the pod
+func (e *PodRemoteExecutor) Exec(ctx context.Context, namespace, pod, container string, command []string,
+	stdout, stderr io.Writer,
+) (int, error) {
+	req := e.clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(namespace).
+		Name(pod).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: container,
+			Command:   command,
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec)
+
+	executor, err := remotecommand.NewSPDYExecutor(e.config, "POST", req.URL())
+	if err != nil {
+		return -1, err
+	}
+
+	err = executor.StreamWithContext(ctx, remotecommand.StreamOptions{Stdout: stdout, Stderr: stderr})
+
+	var exitErr utilexec.ExitError
+	if errors.As(err, &exitErr) && exitErr.Exited() {
+		return exitErr.ExitStatus(), nil
+	}
+
+	if err != nil {
+		return -1, err
+	}
+
+	return 0, nil
+}
@@ -0,0 +1,240 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package hooks
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	ramendrv1alpha1 "github.com/ramendr/recipe/api/v1alpha1"
+	"github.com/ramendr/recipe/pkg/selector"
+	"github.com/ramendr/recipe/pkg/workflow"
+)
+
+const defaultPollInterval = 2 * time.Second
+
+// ScaleExecutor runs the operations of scale hooks on the deployments, statefulsets or replicasets
+// the hook selects. The command of an operation is either ScaleDown or ScaleUp.
+type ScaleExecutor struct {
+	Client client.Client
+	// PollInterval is the interval of checking whether pods terminated or became ready. Defaults to
+	// 2 seconds.
+	PollInterval time.Duration
+}
+
+var _ workflow.HookExecutor = &ScaleExecutor{}
+
+// ExecuteOp scales all selected workloads and waits until they reached the desired state within the
+// timeout of the operation
+func (e *ScaleExecutor) ExecuteOp(ctx context.Context, recipe *ramendrv1alpha1.Recipe, hook *ramendrv1alpha1.Hook,
+	op *ramendrv1alpha1.Operation,
+) ([]workflow.Target, error) {
+	command := strings.TrimSpace(op.Command)
+	if command != ramendrv1alpha1.ScaleDown && command != ramendrv1alpha1.ScaleUp {
+		return nil, fmt.Errorf("op %q of hook %q: unsupported scale command %q, must be one of %q",
+			op.Name, hook.Name, command, ramendrv1alpha1.ScaleCommands)
+	}
+
+	resolver := &selector.Resolver{Reader: e.Client, DefaultNamespace: recipe.Namespace}
+
+	workloads, err := resolver.HookWorkloads(ctx, hook)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select workloads of hook %q: %w", hook.Name, err)
+	}
+
+	if len(workloads) == 0 {
+		return nil, fmt.Errorf("hook %q selects no %ss in namespace %s", hook.Name, hook.SelectResource,
+			resolver.HookNamespace(hook))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, Timeout(op.Timeout, hook))
+	defer cancel()
+
+	targets := make([]workflow.Target, len(workloads))
+	failed := 0
+
+	for i := range workloads {
+		workload := &workloads[i]
+		targets[i] = workflow.Target{Kind: workload.Kind, Namespace: workload.GetNamespace(), Name: workload.GetName()}
+
+		if command == ramendrv1alpha1.ScaleDown {
+			targets[i].Message, targets[i].Err = e.scaleDown(ctx, workload)
+		} else {
+			targets[i].Message, targets[i].Err = e.scaleUp(ctx, workload)
+		}
+	}
+
+	for i := range workloads {
+		if targets[i].Err == nil {
+			targets[i].Err = e.wait(ctx, resolver, &workloads[i], command)
+		}
+
+		if targets[i].Err != nil {
+			failed++
+		}
+	}
+
+	if failed != 0 {
+		return targets, fmt.Errorf("op %q of hook %q failed for %d of %d %ss, first error: %w",
+			op.Name, hook.Name, failed, len(targets), hook.SelectResource, firstError(targets))
+	}
+
+	return targets, nil
+}
+
+// ExecuteCheck evaluates the condition of the check against the workloads the hook selects, see
+// CheckExecutor
+func (e *ScaleExecutor) ExecuteCheck(ctx context.Context, recipe *ramendrv1alpha1.Recipe, hook *ramendrv1alpha1.Hook,
+	chk *ramendrv1alpha1.Check,
+) ([]workflow.Target, error) {
+	return (&CheckExecutor{Reader: e.Client, PollInterval: e.PollInterval}).ExecuteCheck(ctx, recipe, hook, chk)
+}
+
+// scaleDown records the replicas of the workload and scales it to zero. A workload that was scaled
+// down before keeps the replicas recorded then.
+func (e *ScaleExecutor) scaleDown(ctx context.Context, workload *selector.Workload) (string, error) {
+	replicas := specReplicas(workload.Object)
+	if _, recorded := workload.GetAnnotations()[ramendrv1alpha1.OriginalReplicasAnnotation]; recorded && replicas == 0 {
+		return "already scaled down", nil
+	}
+
+	patch := client.MergeFrom(workload.Object.DeepCopyObject().(client.Object))
+	annotations := workload.GetAnnotations()
+
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	annotations[ramendrv1alpha1.OriginalReplicasAnnotation] = strconv.Itoa(int(replicas))
+	workload.SetAnnotations(annotations)
+	setSpecReplicas(workload.Object, 0)
+
+	if err := e.Client.Patch(ctx, workload.Object, patch); err != nil {
+		return "", fmt.Errorf("failed to scale down %s %s/%s: %w", workload.Kind, workload.GetNamespace(),
+			workload.GetName(), err)
+	}
+
+	log.FromContext(ctx).Info("scaled down", "kind", workload.Kind, "namespace", workload.GetNamespace(),
+		"name", workload.GetName(), "replicas", replicas)
+
+	return fmt.Sprintf("scaled down from %d replicas", replicas), nil
+}
+
+// scaleUp restores the replicas recorded by scaleDown and removes the record
+func (e *ScaleExecutor) scaleUp(ctx context.Context, workload *selector.Workload) (string, error) {
+	value, recorded := workload.GetAnnotations()[ramendrv1alpha1.OriginalReplicasAnnotation]
+	if !recorded {
+		if specReplicas(workload.Object) == 0 {
+			return "", fmt.Errorf("%s %s/%s has no replicas and no %s annotation to restore them from",
+				workload.Kind, workload.GetNamespace(), workload.GetName(), ramendrv1alpha1.OriginalReplicasAnnotation)
+		}
+
+		return "not scaled down", nil
+	}
+
+	replicas, err := strconv.ParseInt(value, 10, 32)
+	if err != nil || replicas < 0 {
+		return "", fmt.Errorf("%s %s/%s has an invalid %s annotation %q", workload.Kind,
+			workload.GetNamespace(), workload.GetName(), ramendrv1alpha1.OriginalReplicasAnnotation, value)
+	}
+
+	patch := client.MergeFrom(workload.Object.DeepCopyObject().(client.Object))
+	annotations := workload.GetAnnotations()
+
+	delete(annotations, ramendrv1alpha1.OriginalReplicasAnnotation)
+	workload.SetAnnotations(annotations)
+	setSpecReplicas(workload.Object, int32(replicas))
+
+	if err := e.Client.Patch(ctx, workload.Object, patch); err != nil {
+		return "", fmt.Errorf("failed to scale up %s %s/%s: %w", workload.Kind, workload.GetNamespace(),
+			workload.GetName(), err)
+	}
+
+	log.FromContext(ctx).Info("scaled up", "kind", workload.Kind, "namespace", workload.GetNamespace(),
+		"name", workload.GetName(), "replicas", replicas)
+
+	return fmt.Sprintf("scaled up to %d replicas", replicas), nil
+}
+
+// wait polls until the pods of a workload scaled down terminated, or the pods of a workload scaled
+// up are ready
+func (e *ScaleExecutor) wait(ctx context.Context, resolver *selector.Resolver, workload *selector.Workload,
+	command string,
+) error {
+	interval := e.PollInterval
+	if interval == 0 {
+		interval = defaultPollInterval
+	}
+
+	key := client.ObjectKeyFromObject(workload.Object)
+
+	err := wait.PollUntilContextCancel(ctx, interval, true, func(ctx context.Context) (bool, error) {
+		if err := e.Client.Get(ctx, key, workload.Object); err != nil {
+			return false, err
+		}
+
+		if command == ramendrv1alpha1.ScaleUp {
+			return readyReplicas(workload.Object) >= specReplicas(workload.Object), nil
+		}
+
+		pods, err := resolver.WorkloadPods(ctx, workload)
+
+		return len(pods) == 0, err
+	})
+	if err != nil {
+		return fmt.Errorf("%s %s/%s did not scale %s: %w", workload.Kind, workload.GetNamespace(),
+			workload.GetName(), command, err)
+	}
+
+	return nil
+}
+
+func specReplicas(obj client.Object) int32 {
+	var replicas *int32
+
+	switch workload := obj.(type) {
+	case *appsv1.Deployment:
+		replicas = workload.Spec.Replicas
+	case *appsv1.StatefulSet:
+		replicas = workload.Spec.Replicas
+	case *appsv1.ReplicaSet:
+		replicas = workload.Spec.Replicas
+	}
+
+	// replicas default to 1 for all workload kinds
+	return ptr.Deref(replicas, 1)
+}
+
+func setSpecReplicas(obj client.Object, replicas int32) {
+	switch workload := obj.(type) {
+	case *appsv1.Deployment:
+		workload.Spec.Replicas = &replicas
+	case *appsv1.StatefulSet:
+		workload.Spec.Replicas = &replicas
+	case *appsv1.ReplicaSet:
+		workload.Spec.Replicas = &replicas
+	}
+}
+
+func readyReplicas(obj client.Object) int32 {
+	switch workload := obj.(type) {
+	case *appsv1.Deployment:
+		return workload.Status.ReadyReplicas
+	case *appsv1.StatefulSet:
+		return workload.Status.ReadyReplicas
+	case *appsv1.ReplicaSet:
+		return workload.Status.ReadyReplicas
+	}
+
+	return 0
+}
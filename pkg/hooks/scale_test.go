@@ -0,0 +1,129 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package hooks_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	Recipe "github.com/ramendr/recipe/api/v1alpha1"
+	"github.com/ramendr/recipe/pkg/hooks"
+)
+
+func deployment(replicas, readyReplicas int32, annotations map[string]string) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "app", Name: "db", Labels: map[string]string{"app": "db"}, Annotations: annotations,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: ptr.To(replicas),
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}},
+		},
+		Status: appsv1.DeploymentStatus{ReadyReplicas: readyReplicas},
+	}
+}
+
+var _ = Describe("ScaleExecutor", func() {
+	var (
+		k8sClient client.Client
+		executor  *hooks.ScaleExecutor
+		recipe    *Recipe.Recipe
+		hook      *Recipe.Hook
+	)
+
+	ctx := context.TODO()
+
+	build := func(objs ...client.Object) {
+		k8sClient = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(objs...).Build()
+		executor = &hooks.ScaleExecutor{Client: k8sClient, PollInterval: 10 * time.Millisecond}
+	}
+
+	get := func() *appsv1.Deployment {
+		obj := &appsv1.Deployment{}
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: "app", Name: "db"}, obj)).To(Succeed())
+
+		return obj
+	}
+
+	BeforeEach(func() {
+		recipe = &Recipe.Recipe{ObjectMeta: metav1.ObjectMeta{Namespace: "app", Name: "recipe"}}
+		hook = &Recipe.Hook{
+			Name:           "db",
+			Type:           Recipe.HookTypeScale,
+			SelectResource: "deployment",
+			LabelSelector:  &metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}},
+			Timeout:        5,
+		}
+	})
+
+	It("scales down, records the replicas and waits for the pods to terminate", func() {
+		pod := runningPod("db-0", corev1.PodRunning)
+		build(deployment(3, 3, nil), pod)
+
+		go func() {
+			defer GinkgoRecover()
+			time.Sleep(50 * time.Millisecond)
+			Expect(k8sClient.Delete(ctx, pod)).To(Succeed())
+		}()
+
+		targets, err := executor.ExecuteOp(ctx, recipe, hook, &Recipe.Operation{Name: "stop", Command: "down"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(targets).To(HaveLen(1))
+		Expect(targets[0].Name).To(Equal("db"))
+		Expect(targets[0].Message).To(Equal("scaled down from 3 replicas"))
+
+		obj := get()
+		Expect(*obj.Spec.Replicas).To(BeEquivalentTo(0))
+		Expect(obj.Annotations).To(HaveKeyWithValue(Recipe.OriginalReplicasAnnotation, "3"))
+	})
+	It("keeps the recorded replicas when scaling down again", func() {
+		build(deployment(0, 0, map[string]string{Recipe.OriginalReplicasAnnotation: "3"}))
+
+		targets, err := executor.ExecuteOp(ctx, recipe, hook, &Recipe.Operation{Name: "stop", Command: "down"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(targets[0].Message).To(Equal("already scaled down"))
+		Expect(get().Annotations).To(HaveKeyWithValue(Recipe.OriginalReplicasAnnotation, "3"))
+	})
+	It("scales up to the recorded replicas", func() {
+		build(deployment(0, 2, map[string]string{Recipe.OriginalReplicasAnnotation: "2"}))
+
+		targets, err := executor.ExecuteOp(ctx, recipe, hook, &Recipe.Operation{Name: "start", Command: "up"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(targets[0].Message).To(Equal("scaled up to 2 replicas"))
+
+		obj := get()
+		Expect(*obj.Spec.Replicas).To(BeEquivalentTo(2))
+		Expect(obj.Annotations).ToNot(HaveKey(Recipe.OriginalReplicasAnnotation))
+	})
+	It("fails when the pods do not become ready within the timeout", func() {
+		build(deployment(0, 0, map[string]string{Recipe.OriginalReplicasAnnotation: "2"}))
+
+		op := &Recipe.Operation{Name: "start", Command: "up", Timeout: 1}
+		_, err := executor.ExecuteOp(ctx, recipe, hook, op)
+		Expect(err).To(MatchError(ContainSubstring("failed for 1 of 1 deployments")))
+	})
+	It("fails to scale up without recorded replicas", func() {
+		build(deployment(0, 0, nil))
+
+		_, err := executor.ExecuteOp(ctx, recipe, hook, &Recipe.Operation{Name: "start", Command: "up"})
+		Expect(err).To(MatchError(ContainSubstring("no replicas")))
+	})
+	It("rejects unknown commands", func() {
+		build(deployment(1, 1, nil))
+
+		_, err := executor.ExecuteOp(ctx, recipe, hook, &Recipe.Operation{Name: "stop", Command: "stop"})
+		Expect(err).To(MatchError(ContainSubstring("unsupported scale command")))
+	})
+})
@@ -0,0 +1,99 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package hooks
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/ramendr/recipe/api/condition"
+	ramendrv1alpha1 "github.com/ramendr/recipe/api/v1alpha1"
+	"github.com/ramendr/recipe/pkg/selector"
+	"github.com/ramendr/recipe/pkg/workflow"
+)
+
+// WhenEvaluator evaluates the when conditions of steps against the objects they refer to
+type WhenEvaluator struct {
+	Reader client.Reader
+}
+
+var _ workflow.ConditionEvaluator = &WhenEvaluator{}
+
+// EvaluateWhen gets the object of the condition, in the namespace of the recipe unless the condition
+// names one, and evaluates the condition against it. The condition does not hold if the object does
+// not exist.
+func (e *WhenEvaluator) EvaluateWhen(ctx context.Context, recipe *ramendrv1alpha1.Recipe,
+	when ramendrv1alpha1.StepCondition,
+) (bool, string, error) {
+	obj, err := newObject(when.Kind)
+	if err != nil {
+		return false, "", err
+	}
+
+	if when.Namespace == "" && when.Kind != selector.KindNamespace {
+		when.Namespace = recipe.Namespace
+	}
+
+	key := client.ObjectKey{Namespace: when.Namespace, Name: when.Name}
+	object := when.Object()
+
+	if err := e.Reader.Get(ctx, key, obj); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return false, object + " not found", nil
+		}
+
+		return false, "", fmt.Errorf("failed to get %s: %w", object, err)
+	}
+
+	if when.Condition == "" {
+		return true, "", nil
+	}
+
+	expr, err := condition.Parse(when.Condition)
+	if err != nil {
+		return false, "", fmt.Errorf("invalid condition: %w", err)
+	}
+
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return false, "", err
+	}
+
+	met, err := expr.Evaluate(content)
+	if err != nil {
+		return false, "", fmt.Errorf("%s: %w", object, err)
+	}
+
+	if !met {
+		return false, fmt.Sprintf("condition %q not met for %s", expr, object), nil
+	}
+
+	return true, "", nil
+}
+
+// newObject returns an empty object of a kind of ramendrv1alpha1.WhenKinds
+func newObject(kind string) (client.Object, error) {
+	switch kind {
+	case selector.KindNamespace:
+		return &corev1.Namespace{}, nil
+	case selector.KindPVC:
+		return &corev1.PersistentVolumeClaim{}, nil
+	case selector.KindPod:
+		return &corev1.Pod{}, nil
+	case selector.KindDeployment:
+		return &appsv1.Deployment{}, nil
+	case selector.KindStatefulSet:
+		return &appsv1.StatefulSet{}, nil
+	case selector.KindReplicaSet:
+		return &appsv1.ReplicaSet{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported kind %q, must be one of %q", kind, ramendrv1alpha1.WhenKinds)
+	}
+}
@@ -0,0 +1,67 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package hooks_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	Recipe "github.com/ramendr/recipe/api/v1alpha1"
+	"github.com/ramendr/recipe/pkg/hooks"
+)
+
+var _ = Describe("WhenEvaluator", func() {
+	var evaluator *hooks.WhenEvaluator
+
+	ctx := context.TODO()
+	recipe := &Recipe.Recipe{ObjectMeta: metav1.ObjectMeta{Namespace: "app", Name: "recipe"}}
+
+	evaluate := func(text string) (bool, string, error) {
+		when, err := Recipe.ParseWhen(text)
+		Expect(err).ToNot(HaveOccurred())
+
+		return evaluator.EvaluateWhen(ctx, recipe, when)
+	}
+
+	BeforeEach(func() {
+		evaluator = &hooks.WhenEvaluator{Reader: fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app"}},
+			deployment(3, 2, nil),
+		).Build()}
+	})
+
+	It("holds for existing objects", func() {
+		met, _, err := evaluate("namespace app")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(met).To(BeTrue())
+
+		met, reason, err := evaluate("namespace redis")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(met).To(BeFalse())
+		Expect(reason).To(Equal("namespace redis not found"))
+	})
+	It("evaluates conditions against objects in the namespace of the recipe by default", func() {
+		met, _, err := evaluate("deployment db: {$.spec.replicas} > 1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(met).To(BeTrue())
+
+		met, reason, err := evaluate("deployment app/db: {$.status.readyReplicas} == {$.spec.replicas}")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(met).To(BeFalse())
+		Expect(reason).To(Equal(`condition "{$.status.readyReplicas} == {$.spec.replicas}" not met for ` +
+			`deployment app/db`))
+
+		met, reason, err = evaluate("deployment other/db: {$.spec.replicas} > 1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(met).To(BeFalse())
+		Expect(reason).To(Equal("deployment other/db not found"))
+	})
+})
@@ -0,0 +1,580 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+// Package selector resolves the selectors of recipe groups and hooks to the namespaces and objects
+// they currently match. It only reads through a client.Reader, so the same logic serves the live
+// cluster, the manager cache and offline snapshots.
+package selector
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ramendrv1alpha1 "github.com/ramendr/recipe/api/v1alpha1"
+)
+
+// Kinds of the objects a selection reports. They match the values of SelectResource.
+const (
+	KindNamespace   string = "namespace"
+	KindPVC         string = "pvc"
+	KindPod         string = "pod"
+	KindDeployment  string = "deployment"
+	KindStatefulSet string = "statefulset"
+	KindReplicaSet  string = "replicaset"
+)
+
+// Object identifies a selected object
+type Object struct {
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+// String returns the object as <kind>/<namespace>/<name>
+func (o Object) String() string {
+	return o.Kind + "/" + o.Namespace + "/" + o.Name
+}
+
+// Selection is the result of resolving a group or hook
+type Selection struct {
+	// Namespaces the selectors were evaluated in, sorted
+	Namespaces []string
+	// Objects matched by the selectors, sorted by kind, namespace and name. For volume groups
+	// selecting workloads, both the workloads and the PVCs they mount are included.
+	Objects []Object
+}
+
+// Count returns the number of selected objects of the given kind
+func (s *Selection) Count(kind string) int {
+	count := 0
+
+	for _, obj := range s.Objects {
+		if obj.Kind == kind {
+			count++
+		}
+	}
+
+	return count
+}
+
+func (s *Selection) add(kind, namespace, name string) {
+	s.Objects = append(s.Objects, Object{Kind: kind, Namespace: namespace, Name: name})
+}
+
+func (s *Selection) sort() {
+	slices.SortFunc(s.Objects, func(a, b Object) int {
+		return strings.Compare(a.String(), b.String())
+	})
+	s.Objects = slices.Compact(s.Objects)
+}
+
+// Resolver resolves groups and hooks using a client.Reader
+type Resolver struct {
+	client.Reader
+	// DefaultNamespace is used for groups that include no namespaces and hooks without a namespace.
+	// It is usually the namespace of the recipe.
+	DefaultNamespace string
+}
+
+// GroupNamespaces returns the sorted namespaces a group applies to: the included namespaces and the
+// namespaces matching IncludedNamespacesByLabel, less the excluded namespaces.
+func (r *Resolver) GroupNamespaces(ctx context.Context, group *ramendrv1alpha1.Group) ([]string, error) {
+	namespaces := sets.New(group.IncludedNamespaces...)
+
+	if group.IncludedNamespacesByLabel != nil {
+		selector, err := metav1.LabelSelectorAsSelector(group.IncludedNamespacesByLabel)
+		if err != nil {
+			return nil, fmt.Errorf("invalid includedNamespacesByLabel: %w", err)
+		}
+
+		namespaceList := &corev1.NamespaceList{}
+		if err := r.List(ctx, namespaceList, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+			return nil, fmt.Errorf("failed to list namespaces: %w", err)
+		}
+
+		for i := range namespaceList.Items {
+			namespaces.Insert(namespaceList.Items[i].Name)
+		}
+	} else if namespaces.Len() == 0 {
+		namespaces.Insert(r.DefaultNamespace)
+	}
+
+	namespaces.Delete(group.ExcludedNamespaces...)
+
+	return sets.List(namespaces), nil
+}
+
+// HookNamespace returns the namespace a hook applies to
+func (r *Resolver) HookNamespace(hook *ramendrv1alpha1.Hook) string {
+	if hook.Namespace != "" {
+		return hook.Namespace
+	}
+
+	return r.DefaultNamespace
+}
+
+// ResolveGroup returns the namespaces and objects a group currently selects. Volume groups select
+// PVCs, directly or through the pods, deployments or statefulsets named by SelectResource. Resource
+// groups select any type; the objects reported for them are limited to the kinds this package
+// knows, filtered by the included and excluded resource types.
+func (r *Resolver) ResolveGroup(ctx context.Context, group *ramendrv1alpha1.Group) (*Selection, error) {
+	namespaces, err := r.GroupNamespaces(ctx, group)
+	if err != nil {
+		return nil, err
+	}
+
+	selector, err := labelSelector(group.LabelSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid labelSelector: %w", err)
+	}
+
+	matcher, err := nameMatcher(group.NameSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	selection := &Selection{Namespaces: namespaces}
+
+	for _, namespace := range namespaces {
+		selection.add(KindNamespace, "", namespace)
+
+		if group.Type == "volume" {
+			err = r.selectVolumes(ctx, selection, namespace, group.SelectResource, selector, matcher)
+		} else {
+			err = r.selectResources(ctx, selection, namespace, group, selector)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	selection.sort()
+
+	return selection, nil
+}
+
+// ResolveHook returns the namespace and objects a hook currently selects, including the pods of
+// selected deployments and statefulsets
+func (r *Resolver) ResolveHook(ctx context.Context, hook *ramendrv1alpha1.Hook) (*Selection, error) {
+	namespace := r.HookNamespace(hook)
+	selection := &Selection{Namespaces: []string{namespace}}
+	selection.add(KindNamespace, "", namespace)
+
+	pods, err := r.HookPods(ctx, hook)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range pods {
+		selection.add(KindPod, pods[i].Namespace, pods[i].Name)
+	}
+
+	if kind := hookSelectResource(hook); kind != KindPod {
+		workloads, err := r.selectWorkloads(ctx, namespace, kind, hook.LabelSelector, hook.NameSelector)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, workload := range workloads {
+			selection.add(kind, namespace, workload.GetName())
+		}
+	}
+
+	selection.sort()
+
+	return selection, nil
+}
+
+// HookPods returns the pods a hook applies to, sorted by name. If the hook selects deployments or
+// statefulsets, these are the pods matching the selectors of the selected workloads.
+func (r *Resolver) HookPods(ctx context.Context, hook *ramendrv1alpha1.Hook) ([]corev1.Pod, error) {
+	namespace := r.HookNamespace(hook)
+
+	kind := hookSelectResource(hook)
+	if kind == KindPod {
+		selector, err := labelSelector(hook.LabelSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid labelSelector: %w", err)
+		}
+
+		matcher, err := nameMatcher(hook.NameSelector)
+		if err != nil {
+			return nil, err
+		}
+
+		return r.listPods(ctx, namespace, selector, matcher)
+	}
+
+	workloads, err := r.selectWorkloads(ctx, namespace, kind, hook.LabelSelector, hook.NameSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	pods := []corev1.Pod{}
+
+	for i := range workloads {
+		workloadPods, err := r.WorkloadPods(ctx, &workloads[i])
+		if err != nil {
+			return nil, err
+		}
+
+		pods = append(pods, workloadPods...)
+	}
+
+	slices.SortFunc(pods, func(a, b corev1.Pod) int { return strings.Compare(a.Name, b.Name) })
+
+	return slices.CompactFunc(pods, func(a, b corev1.Pod) bool { return a.Name == b.Name }), nil
+}
+
+// HookWorkloads returns the deployments, statefulsets or replicasets a hook selects, sorted by name
+func (r *Resolver) HookWorkloads(ctx context.Context, hook *ramendrv1alpha1.Hook) ([]Workload, error) {
+	return r.selectWorkloads(ctx, r.HookNamespace(hook), hookSelectResource(hook), hook.LabelSelector,
+		hook.NameSelector)
+}
+
+// WorkloadPods returns the pods matching the selector of a workload, sorted by name
+func (r *Resolver) WorkloadPods(ctx context.Context, workload *Workload) ([]corev1.Pod, error) {
+	selector, err := metav1.LabelSelectorAsSelector(workload.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector of %s %s/%s: %w", workload.Kind, workload.GetNamespace(),
+			workload.GetName(), err)
+	}
+
+	return r.listPods(ctx, workload.GetNamespace(), selector, nil)
+}
+
+func hookSelectResource(hook *ramendrv1alpha1.Hook) string {
+	if hook.SelectResource == "" {
+		return KindPod
+	}
+
+	return hook.SelectResource
+}
+
+func (r *Resolver) selectVolumes(ctx context.Context, selection *Selection, namespace, kind string,
+	selector labels.Selector, matcher *ramendrv1alpha1.NameMatcher,
+) error {
+	switch kind {
+	case "", KindPVC:
+		pvcList := &corev1.PersistentVolumeClaimList{}
+		if err := r.list(ctx, pvcList, namespace, selector); err != nil {
+			return err
+		}
+
+		for i := range pvcList.Items {
+			if matcher.Match(pvcList.Items[i].Name) {
+				selection.add(KindPVC, namespace, pvcList.Items[i].Name)
+			}
+		}
+	case KindPod:
+		pods, err := r.listPods(ctx, namespace, selector, matcher)
+		if err != nil {
+			return err
+		}
+
+		for i := range pods {
+			selection.add(KindPod, namespace, pods[i].Name)
+
+			for _, claim := range podClaims(&pods[i].Spec) {
+				selection.add(KindPVC, namespace, claim)
+			}
+		}
+	case KindDeployment, KindStatefulSet:
+		return r.selectWorkloadVolumes(ctx, selection, namespace, kind, selector, matcher)
+	default:
+		return fmt.Errorf("unsupported selectResource %q", kind)
+	}
+
+	return nil
+}
+
+func (r *Resolver) selectWorkloadVolumes(ctx context.Context, selection *Selection, namespace, kind string,
+	selector labels.Selector, matcher *ramendrv1alpha1.NameMatcher,
+) error {
+	workloads, err := r.selectWorkloadsBySelector(ctx, namespace, kind, selector, matcher)
+	if err != nil {
+		return err
+	}
+
+	var pvcList *corev1.PersistentVolumeClaimList
+
+	for _, workload := range workloads {
+		selection.add(kind, namespace, workload.GetName())
+
+		for _, claim := range podClaims(&workload.Template.Spec) {
+			selection.add(KindPVC, namespace, claim)
+		}
+
+		if len(workload.ClaimTemplates) == 0 {
+			continue
+		}
+
+		if pvcList == nil {
+			pvcList = &corev1.PersistentVolumeClaimList{}
+			if err := r.list(ctx, pvcList, namespace, labels.Everything()); err != nil {
+				return err
+			}
+		}
+
+		// PVCs created from volume claim templates are named <template>-<statefulset>-<ordinal>
+		for _, claimTemplate := range workload.ClaimTemplates {
+			prefix := claimTemplate + "-" + workload.GetName() + "-"
+
+			for i := range pvcList.Items {
+				// other StatefulSets extending the name have the prefix too, e.g. data-db-replica-0 for db
+				if ordinal, ok := strings.CutPrefix(pvcList.Items[i].Name, prefix); ok && isOrdinal(ordinal) {
+					selection.add(KindPVC, namespace, pvcList.Items[i].Name)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// isOrdinal reports whether a value is the ordinal of a pod of a StatefulSet
+func isOrdinal(value string) bool {
+	if value == "" {
+		return false
+	}
+
+	for _, c := range value {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (r *Resolver) selectResources(ctx context.Context, selection *Selection, namespace string,
+	group *ramendrv1alpha1.Group, selector labels.Selector,
+) error {
+	if includesResourceType(group, "persistentvolumeclaims") {
+		pvcList := &corev1.PersistentVolumeClaimList{}
+		if err := r.list(ctx, pvcList, namespace, selector); err != nil {
+			return err
+		}
+
+		for i := range pvcList.Items {
+			selection.add(KindPVC, namespace, pvcList.Items[i].Name)
+		}
+	}
+
+	if includesResourceType(group, "pods") {
+		pods, err := r.listPods(ctx, namespace, selector, nil)
+		if err != nil {
+			return err
+		}
+
+		for i := range pods {
+			selection.add(KindPod, namespace, pods[i].Name)
+		}
+	}
+
+	for kind, resource := range map[string]string{
+		KindDeployment:  "deployments",
+		KindStatefulSet: "statefulsets",
+	} {
+		if !includesResourceType(group, resource) {
+			continue
+		}
+
+		workloads, err := r.selectWorkloadsBySelector(ctx, namespace, kind, selector, nil)
+		if err != nil {
+			return err
+		}
+
+		for _, workload := range workloads {
+			selection.add(kind, namespace, workload.GetName())
+		}
+	}
+
+	return nil
+}
+
+// includesResourceType reports whether a resource group includes a resource type, given by its
+// plural name. Types may be qualified by their group, e.g. deployments.apps.
+func includesResourceType(group *ramendrv1alpha1.Group, resource string) bool {
+	matches := func(types []string) bool {
+		return slices.ContainsFunc(types, func(t string) bool {
+			name, _, _ := strings.Cut(strings.ToLower(t), ".")
+
+			return t == "*" || name == resource
+		})
+	}
+
+	if matches(group.ExcludedResourceTypes) {
+		return false
+	}
+
+	return len(group.IncludedResourceTypes) == 0 || matches(group.IncludedResourceTypes)
+}
+
+// Workload is a selected deployment, statefulset or replicaset
+type Workload struct {
+	client.Object
+	// Kind of the workload, one of KindDeployment, KindStatefulSet or KindReplicaSet
+	Kind string
+	// Selector of the pods of the workload
+	Selector *metav1.LabelSelector
+	// Template of the pods of the workload
+	Template *corev1.PodTemplateSpec
+	// Names of the volume claim templates, for statefulsets
+	ClaimTemplates []string
+}
+
+func (r *Resolver) selectWorkloads(ctx context.Context, namespace, kind string,
+	labelSel *metav1.LabelSelector, nameSelector string,
+) ([]Workload, error) {
+	selector, err := labelSelector(labelSel)
+	if err != nil {
+		return nil, fmt.Errorf("invalid labelSelector: %w", err)
+	}
+
+	matcher, err := nameMatcher(nameSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.selectWorkloadsBySelector(ctx, namespace, kind, selector, matcher)
+}
+
+// selectWorkloadsBySelector returns the workloads matching the selectors, sorted by name. A nil
+// matcher matches all names.
+func (r *Resolver) selectWorkloadsBySelector(ctx context.Context, namespace, kind string,
+	selector labels.Selector, matcher *ramendrv1alpha1.NameMatcher,
+) ([]Workload, error) {
+	workloads := []Workload{}
+
+	switch kind {
+	case KindDeployment:
+		deploymentList := &appsv1.DeploymentList{}
+		if err := r.list(ctx, deploymentList, namespace, selector); err != nil {
+			return nil, err
+		}
+
+		for i := range deploymentList.Items {
+			deployment := &deploymentList.Items[i]
+			workloads = append(workloads, Workload{
+				Object:   deployment,
+				Kind:     kind,
+				Selector: deployment.Spec.Selector,
+				Template: &deployment.Spec.Template,
+			})
+		}
+	case KindStatefulSet:
+		statefulSetList := &appsv1.StatefulSetList{}
+		if err := r.list(ctx, statefulSetList, namespace, selector); err != nil {
+			return nil, err
+		}
+
+		for i := range statefulSetList.Items {
+			statefulSet := &statefulSetList.Items[i]
+			claimTemplates := []string{}
+
+			for _, claimTemplate := range statefulSet.Spec.VolumeClaimTemplates {
+				claimTemplates = append(claimTemplates, claimTemplate.Name)
+			}
+
+			workloads = append(workloads, Workload{
+				Object:         statefulSet,
+				Kind:           kind,
+				Selector:       statefulSet.Spec.Selector,
+				Template:       &statefulSet.Spec.Template,
+				ClaimTemplates: claimTemplates,
+			})
+		}
+	case KindReplicaSet:
+		replicaSetList := &appsv1.ReplicaSetList{}
+		if err := r.list(ctx, replicaSetList, namespace, selector); err != nil {
+			return nil, err
+		}
+
+		for i := range replicaSetList.Items {
+			replicaSet := &replicaSetList.Items[i]
+			workloads = append(workloads, Workload{
+				Object:   replicaSet,
+				Kind:     kind,
+				Selector: replicaSet.Spec.Selector,
+				Template: &replicaSet.Spec.Template,
+			})
+		}
+	default:
+		return nil, fmt.Errorf("unsupported selectResource %q", kind)
+	}
+
+	workloads = slices.DeleteFunc(workloads, func(w Workload) bool {
+		return matcher != nil && !matcher.Match(w.GetName())
+	})
+	slices.SortFunc(workloads, func(a, b Workload) int { return strings.Compare(a.GetName(), b.GetName()) })
+
+	return workloads, nil
+}
+
+// listPods returns the pods matching the selectors, sorted by name. A nil matcher matches all names.
+func (r *Resolver) listPods(ctx context.Context, namespace string, selector labels.Selector,
+	matcher *ramendrv1alpha1.NameMatcher,
+) ([]corev1.Pod, error) {
+	podList := &corev1.PodList{}
+	if err := r.list(ctx, podList, namespace, selector); err != nil {
+		return nil, err
+	}
+
+	pods := slices.DeleteFunc(podList.Items, func(pod corev1.Pod) bool {
+		return matcher != nil && !matcher.Match(pod.Name)
+	})
+	slices.SortFunc(pods, func(a, b corev1.Pod) int { return strings.Compare(a.Name, b.Name) })
+
+	return pods, nil
+}
+
+// nameMatcher compiles the name selector of a group or hook once for all the objects it is matched
+// against
+func nameMatcher(nameSelector string) (*ramendrv1alpha1.NameMatcher, error) {
+	matcher, err := ramendrv1alpha1.CompileNameSelector(nameSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid nameSelector %q: %w", nameSelector, err)
+	}
+
+	return matcher, nil
+}
+
+func (r *Resolver) list(ctx context.Context, list client.ObjectList, namespace string, selector labels.Selector) error {
+	if err := r.List(ctx, list, client.InNamespace(namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return fmt.Errorf("failed to list %T in namespace %s: %w", list, namespace, err)
+	}
+
+	return nil
+}
+
+func podClaims(spec *corev1.PodSpec) []string {
+	claims := []string{}
+
+	for _, volume := range spec.Volumes {
+		if volume.PersistentVolumeClaim != nil {
+			claims = append(claims, volume.PersistentVolumeClaim.ClaimName)
+		}
+	}
+
+	return claims
+}
+
+// labelSelector converts a label selector, selecting everything if it is unset
+func labelSelector(selector *metav1.LabelSelector) (labels.Selector, error) {
+	if selector == nil {
+		return labels.Everything(), nil
+	}
+
+	return metav1.LabelSelectorAsSelector(selector)
+}
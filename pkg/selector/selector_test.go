@@ -0,0 +1,235 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package selector_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	Recipe "github.com/ramendr/recipe/api/v1alpha1"
+	"github.com/ramendr/recipe/pkg/selector"
+)
+
+func namespace(name string, labels map[string]string) *corev1.Namespace {
+	return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+}
+
+func pvc(namespace, name string, labels map[string]string) *corev1.PersistentVolumeClaim {
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: labels},
+	}
+}
+
+func pod(namespace, name string, labels map[string]string, claims ...string) *corev1.Pod {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: labels}}
+
+	for _, claim := range claims {
+		pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{
+			Name: claim,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: claim},
+			},
+		})
+	}
+
+	return pod
+}
+
+func statefulSet(namespace, name string, labels map[string]string, claimTemplates ...string) *appsv1.StatefulSet {
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: labels},
+		Spec: appsv1.StatefulSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"sts": name}},
+		},
+	}
+
+	for _, claimTemplate := range claimTemplates {
+		sts.Spec.VolumeClaimTemplates = append(sts.Spec.VolumeClaimTemplates, corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: claimTemplate},
+		})
+	}
+
+	return sts
+}
+
+func objects(kind string, selection *selector.Selection) []string {
+	names := []string{}
+
+	for _, obj := range selection.Objects {
+		if obj.Kind == kind {
+			names = append(names, obj.Namespace+"/"+obj.Name)
+		}
+	}
+
+	return names
+}
+
+var _ = Describe("Resolver", func() {
+	var resolver *selector.Resolver
+
+	ctx := context.TODO()
+	app := map[string]string{"app": "db"}
+
+	BeforeEach(func() {
+		objs := []client.Object{
+			namespace("app", map[string]string{"tier": "db"}),
+			namespace("app-2", map[string]string{"tier": "db"}),
+			namespace("other", nil),
+			pvc("app", "data-1", app),
+			pvc("app", "data-2", app),
+			pvc("app", "scratch", nil),
+			pvc("app", "www-db-0", nil),
+			pvc("app", "www-db-1", nil),
+			pvc("app-2", "data-3", app),
+			pvc("other", "data-4", app),
+			pod("app", "db-0", map[string]string{"app": "db", "sts": "db"}, "www-db-0"),
+			pod("app", "db-1", map[string]string{"app": "db", "sts": "db"}, "www-db-1"),
+			pod("app", "web", map[string]string{"app": "web"}, "scratch"),
+			statefulSet("app", "db", app, "www"),
+		}
+
+		resolver = &selector.Resolver{
+			Reader:           fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(objs...).Build(),
+			DefaultNamespace: "app",
+		}
+	})
+
+	Context("groups", func() {
+		It("default to the recipe namespace", func() {
+			group := &Recipe.Group{Name: "data", Type: "volume", LabelSelector: &metav1.LabelSelector{MatchLabels: app}}
+
+			selection, err := resolver.ResolveGroup(ctx, group)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(selection.Namespaces).To(Equal([]string{"app"}))
+			Expect(objects(selector.KindPVC, selection)).To(Equal([]string{"app/data-1", "app/data-2"}))
+		})
+		It("select namespaces by label less excluded namespaces", func() {
+			group := &Recipe.Group{
+				Name:                      "data",
+				Type:                      "volume",
+				LabelSelector:             &metav1.LabelSelector{MatchLabels: app},
+				IncludedNamespacesByLabel: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "db"}},
+				IncludedNamespaces:        []string{"other"},
+				ExcludedNamespaces:        []string{"app"},
+			}
+
+			selection, err := resolver.ResolveGroup(ctx, group)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(selection.Namespaces).To(Equal([]string{"app-2", "other"}))
+			Expect(objects(selector.KindPVC, selection)).To(Equal([]string{"app-2/data-3", "other/data-4"}))
+		})
+		It("filter by name selector", func() {
+			group := &Recipe.Group{Name: "data", Type: "volume", NameSelector: "^data-2$"}
+
+			selection, err := resolver.ResolveGroup(ctx, group)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(objects(selector.KindPVC, selection)).To(Equal([]string{"app/data-2"}))
+		})
+		It("report invalid name selectors", func() {
+			group := &Recipe.Group{Name: "data", Type: "volume", NameSelector: "data-("}
+
+			_, err := resolver.ResolveGroup(ctx, group)
+			Expect(err).To(MatchError(ContainSubstring(`invalid nameSelector "data-("`)))
+		})
+		It("select PVCs mounted by pods", func() {
+			group := &Recipe.Group{
+				Name:           "data",
+				Type:           "volume",
+				SelectResource: "pod",
+				LabelSelector:  &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			}
+
+			selection, err := resolver.ResolveGroup(ctx, group)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(objects(selector.KindPod, selection)).To(Equal([]string{"app/web"}))
+			Expect(objects(selector.KindPVC, selection)).To(Equal([]string{"app/scratch"}))
+		})
+		It("select PVCs of statefulset claim templates", func() {
+			group := &Recipe.Group{Name: "data", Type: "volume", SelectResource: "statefulset"}
+
+			selection, err := resolver.ResolveGroup(ctx, group)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(objects(selector.KindStatefulSet, selection)).To(Equal([]string{"app/db"}))
+			Expect(objects(selector.KindPVC, selection)).To(Equal([]string{"app/www-db-0", "app/www-db-1"}))
+		})
+		It("select only PVCs of the ordinals of statefulsets", func() {
+			resolver.Reader = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+				pvc("app", "www-db-0", nil),
+				pvc("app", "www-db-replica-0", nil),
+				pvc("app", "www-db-backup", nil),
+				statefulSet("app", "db", app, "www"),
+				statefulSet("app", "db-replica", nil, "www"),
+			).Build()
+			group := &Recipe.Group{
+				Name: "data", Type: "volume", SelectResource: "statefulset",
+				LabelSelector: &metav1.LabelSelector{MatchLabels: app},
+			}
+
+			selection, err := resolver.ResolveGroup(ctx, group)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(objects(selector.KindStatefulSet, selection)).To(Equal([]string{"app/db"}))
+			Expect(objects(selector.KindPVC, selection)).To(Equal([]string{"app/www-db-0"}))
+		})
+		It("limit resource groups to included types", func() {
+			group := &Recipe.Group{
+				Name:                  "config",
+				Type:                  "resource",
+				LabelSelector:         &metav1.LabelSelector{MatchLabels: app},
+				IncludedResourceTypes: []string{"statefulsets.apps", "pods"},
+			}
+
+			selection, err := resolver.ResolveGroup(ctx, group)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(selection.Count(selector.KindPVC)).To(BeZero())
+			Expect(objects(selector.KindPod, selection)).To(Equal([]string{"app/db-0", "app/db-1"}))
+			Expect(objects(selector.KindStatefulSet, selection)).To(Equal([]string{"app/db"}))
+		})
+	})
+
+	Context("hooks", func() {
+		It("select pods by label", func() {
+			hook := &Recipe.Hook{Name: "db", Type: "exec", LabelSelector: &metav1.LabelSelector{MatchLabels: app}}
+
+			pods, err := resolver.HookPods(ctx, hook)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pods).To(HaveLen(2))
+			Expect(pods[0].Name).To(Equal("db-0"))
+			Expect(pods[1].Name).To(Equal("db-1"))
+		})
+		It("select pods of workloads", func() {
+			hook := &Recipe.Hook{Name: "db", Type: "exec", Namespace: "app", SelectResource: "statefulset"}
+
+			selection, err := resolver.ResolveHook(ctx, hook)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(objects(selector.KindStatefulSet, selection)).To(Equal([]string{"app/db"}))
+			Expect(objects(selector.KindPod, selection)).To(Equal([]string{"app/db-0", "app/db-1"}))
+		})
+		It("select nothing in other namespaces", func() {
+			hook := &Recipe.Hook{Name: "db", Type: "exec", Namespace: "other"}
+
+			selection, err := resolver.ResolveHook(ctx, hook)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(selection.Count(selector.KindPod)).To(BeZero())
+		})
+		It("report invalid name selectors", func() {
+			hook := &Recipe.Hook{Name: "db", Type: "exec", NameSelector: "glob:db-["}
+
+			_, err := resolver.HookPods(ctx, hook)
+			Expect(err).To(MatchError(ContainSubstring(`invalid nameSelector "glob:db-["`)))
+
+			hook.SelectResource = "statefulset"
+			_, err = resolver.ResolveHook(ctx, hook)
+			Expect(err).To(MatchError(ContainSubstring(`invalid nameSelector "glob:db-["`)))
+		})
+	})
+})
@@ -0,0 +1,57 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package velero
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// The types of this file mirror the fields of the velero.io/v1 API that groups map to, with the
+// same JSON names, so that they serialize into valid Velero specs without depending on the Velero
+// module. Consumers using the Velero types can convert through JSON.
+
+// GroupVersion of the Velero API
+var GroupVersion = schema.GroupVersion{Group: "velero.io", Version: "v1"}
+
+// BackupSpec mirrors velero.io/v1 BackupSpec
+type BackupSpec struct {
+	IncludedNamespaces      []string              `json:"includedNamespaces,omitempty"`
+	ExcludedNamespaces      []string              `json:"excludedNamespaces,omitempty"`
+	IncludedResources       []string              `json:"includedResources,omitempty"`
+	ExcludedResources       []string              `json:"excludedResources,omitempty"`
+	LabelSelector           *metav1.LabelSelector `json:"labelSelector,omitempty"`
+	IncludeClusterResources *bool                 `json:"includeClusterResources,omitempty"`
+}
+
+// PolicyType mirrors velero.io/v1 PolicyType, the policy for resources that already exist in the
+// cluster when restoring
+type PolicyType string
+
+const (
+	// PolicyTypeNone keeps existing resources
+	PolicyTypeNone PolicyType = "none"
+	// PolicyTypeUpdate updates existing resources to the backed up state
+	PolicyTypeUpdate PolicyType = "update"
+)
+
+// RestoreStatusSpec mirrors velero.io/v1 RestoreStatusSpec, the resource types whose status is
+// restored
+type RestoreStatusSpec struct {
+	IncludedResources []string `json:"includedResources,omitempty"`
+	ExcludedResources []string `json:"excludedResources,omitempty"`
+}
+
+// RestoreSpec mirrors velero.io/v1 RestoreSpec
+type RestoreSpec struct {
+	BackupName              string                `json:"backupName,omitempty"`
+	IncludedNamespaces      []string              `json:"includedNamespaces,omitempty"`
+	ExcludedNamespaces      []string              `json:"excludedNamespaces,omitempty"`
+	IncludedResources       []string              `json:"includedResources,omitempty"`
+	ExcludedResources       []string              `json:"excludedResources,omitempty"`
+	RestoreStatus           *RestoreStatusSpec    `json:"restoreStatus,omitempty"`
+	LabelSelector           *metav1.LabelSelector `json:"labelSelector,omitempty"`
+	IncludeClusterResources *bool                 `json:"includeClusterResources,omitempty"`
+	ExistingResourcePolicy  PolicyType            `json:"existingResourcePolicy,omitempty"`
+}
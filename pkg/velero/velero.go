@@ -0,0 +1,170 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+// Package velero converts the resource groups of a recipe into the specs of Velero backups and
+// restores.
+//
+// Group fields map to the Velero fields of the same name, IncludedResourceTypes and
+// ExcludedResourceTypes to IncludedResources and ExcludedResources. Velero cannot select namespaces
+// by label, so namespaces are passed in resolved, e.g. by selector.Resolver.GroupNamespaces. Groups
+// including no namespaces apply to the namespace of their recipe, as in GroupNamespaces.
+// RestoreOverwriteResources maps to ExistingResourcePolicy "update" if true and "none" otherwise,
+// and RestoreStatus to the RestoreStatus of the restore. Groups selecting resources by NameSelector or
+// SelectResource cannot be converted, as Velero would select more than the group does.
+package velero
+
+import (
+	"fmt"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	ramendrv1alpha1 "github.com/ramendr/recipe/api/v1alpha1"
+)
+
+const groupTypeResource = "resource"
+
+// NewBackupSpec converts a resource group of the recipe into a BackupSpec. Namespaces are the
+// namespaces the group applies to; if nil, the IncludedNamespaces of the group are used, or the
+// namespace of the recipe if the group includes none, which requires the group not to select
+// namespaces by label.
+func NewBackupSpec(recipe *ramendrv1alpha1.Recipe, group *ramendrv1alpha1.Group, namespaces []string,
+) (*BackupSpec, error) {
+	namespaces, err := includedNamespaces(recipe, group, namespaces)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BackupSpec{
+		IncludedNamespaces:      namespaces,
+		ExcludedNamespaces:      slices.Clone(group.ExcludedNamespaces),
+		IncludedResources:       slices.Clone(group.IncludedResourceTypes),
+		ExcludedResources:       slices.Clone(group.ExcludedResourceTypes),
+		LabelSelector:           group.LabelSelector.DeepCopy(),
+		IncludeClusterResources: cloneBool(group.IncludeClusterResources),
+	}, nil
+}
+
+// NewRestoreSpec converts a resource group into a RestoreSpec restoring from the named Velero
+// backup, which is the backup of the group returned by BackupGroup. Namespaces are handled as by
+// NewBackupSpec.
+func NewRestoreSpec(recipe *ramendrv1alpha1.Recipe, group *ramendrv1alpha1.Group, backupName string,
+	namespaces []string,
+) (*RestoreSpec, error) {
+	namespaces, err := includedNamespaces(recipe, group, namespaces)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := &RestoreSpec{
+		BackupName:              backupName,
+		IncludedNamespaces:      namespaces,
+		ExcludedNamespaces:      slices.Clone(group.ExcludedNamespaces),
+		IncludedResources:       slices.Clone(group.IncludedResourceTypes),
+		ExcludedResources:       slices.Clone(group.ExcludedResourceTypes),
+		LabelSelector:           group.LabelSelector.DeepCopy(),
+		IncludeClusterResources: cloneBool(group.IncludeClusterResources),
+		ExistingResourcePolicy:  PolicyTypeNone,
+	}
+
+	if group.RestoreOverwriteResources != nil && *group.RestoreOverwriteResources {
+		spec.ExistingResourcePolicy = PolicyTypeUpdate
+	}
+
+	if group.RestoreStatus != nil {
+		spec.RestoreStatus = &RestoreStatusSpec{
+			IncludedResources: slices.Clone(group.RestoreStatus.IncludedResources),
+			ExcludedResources: slices.Clone(group.RestoreStatus.ExcludedResources),
+		}
+	}
+
+	return spec, nil
+}
+
+// BackupGroup returns the group whose backup a group restores from: the group its BackupRef names,
+// or the group itself
+func BackupGroup(spec *ramendrv1alpha1.RecipeSpec, group *ramendrv1alpha1.Group) (*ramendrv1alpha1.Group, error) {
+	if group.BackupRef == "" {
+		return group, nil
+	}
+
+	backupGroup := spec.FindGroup(group.BackupRef)
+	if backupGroup == nil {
+		return nil, fmt.Errorf("group %q: backupRef %q not found", group.Name, group.BackupRef)
+	}
+
+	if backupGroup.Type != group.Type {
+		return nil, fmt.Errorf("group %q of type %s: backupRef %q is of type %s", group.Name, group.Type,
+			group.BackupRef, backupGroup.Type)
+	}
+
+	return backupGroup, nil
+}
+
+// NewBackup returns a Velero Backup with the given spec, as unstructured object
+func NewBackup(namespace, name string, spec *BackupSpec) (*unstructured.Unstructured, error) {
+	return newObject("Backup", namespace, name, spec)
+}
+
+// NewRestore returns a Velero Restore with the given spec, as unstructured object
+func NewRestore(namespace, name string, spec *RestoreSpec) (*unstructured.Unstructured, error) {
+	return newObject("Restore", namespace, name, spec)
+}
+
+func newObject(kind, namespace, name string, spec interface{}) (*unstructured.Unstructured, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{"spec": content}}
+	obj.SetGroupVersionKind(GroupVersion.WithKind(kind))
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+
+	return obj, nil
+}
+
+func includedNamespaces(recipe *ramendrv1alpha1.Recipe, group *ramendrv1alpha1.Group, namespaces []string,
+) ([]string, error) {
+	if group.Type != groupTypeResource {
+		return nil, fmt.Errorf("group %q is of type %s, only resource groups convert to Velero specs",
+			group.Name, group.Type)
+	}
+
+	// Velero selects resources by type and label only, dropping these would select more
+	switch {
+	case group.NameSelector != "":
+		return nil, fmt.Errorf("group %q selects resources by name, which Velero does not support", group.Name)
+	case group.SelectResource != "":
+		return nil, fmt.Errorf("group %q selects resources through the %ss they belong to, which Velero "+
+			"does not support", group.Name, group.SelectResource)
+	}
+
+	if namespaces != nil {
+		return slices.Clone(namespaces), nil
+	}
+
+	if group.IncludedNamespacesByLabel != nil {
+		return nil, fmt.Errorf("group %q selects namespaces by label, which Velero does not support, "+
+			"namespaces must be resolved", group.Name)
+	}
+
+	if len(group.IncludedNamespaces) == 0 {
+		// Velero backs up all namespaces if none are included
+		return []string{recipe.Namespace}, nil
+	}
+
+	return slices.Clone(group.IncludedNamespaces), nil
+}
+
+func cloneBool(value *bool) *bool {
+	if value == nil {
+		return nil
+	}
+
+	clone := *value
+
+	return &clone
+}
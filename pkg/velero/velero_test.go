@@ -0,0 +1,130 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package velero_test
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	Recipe "github.com/ramendr/recipe/api/v1alpha1"
+	"github.com/ramendr/recipe/pkg/velero"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+func loadRecipe() *Recipe.Recipe {
+	data, err := os.ReadFile(filepath.Join("testdata", "recipe.yaml"))
+	Expect(err).ToNot(HaveOccurred())
+
+	recipe := &Recipe.Recipe{}
+	Expect(yaml.UnmarshalStrict(data, recipe)).To(Succeed())
+
+	return recipe
+}
+
+// expectGolden compares the object with the golden file testdata/<name>.yaml
+func expectGolden(name string, obj *unstructured.Unstructured) {
+	data, err := yaml.Marshal(obj.Object)
+	Expect(err).ToNot(HaveOccurred())
+
+	path := filepath.Join("testdata", name+".yaml")
+	if *update {
+		Expect(os.WriteFile(path, data, 0o600)).To(Succeed())
+	}
+
+	golden, err := os.ReadFile(path)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(string(data)).To(Equal(string(golden)), "run go test ./pkg/velero -args -update to update %s", path)
+}
+
+var _ = Describe("Velero", func() {
+	var recipe *Recipe.Recipe
+
+	BeforeEach(func() {
+		recipe = loadRecipe()
+	})
+
+	DescribeTable("converts groups to backups",
+		func(groupName string, namespaces []string) {
+			spec, err := velero.NewBackupSpec(recipe, recipe.Spec.FindGroup(groupName), namespaces)
+			Expect(err).ToNot(HaveOccurred())
+
+			backup, err := velero.NewBackup("velero", "app-"+groupName, spec)
+			Expect(err).ToNot(HaveOccurred())
+
+			expectGolden(groupName+".backup", backup)
+		},
+		Entry("with all fields", "all-fields", nil),
+		Entry("without fields", "minimal", nil),
+		Entry("with resolved namespaces", "by-label", []string{"app", "app-2"}),
+	)
+
+	DescribeTable("converts groups to restores",
+		func(groupName string, namespaces []string) {
+			group := recipe.Spec.FindGroup(groupName)
+
+			backupGroup, err := velero.BackupGroup(&recipe.Spec, group)
+			Expect(err).ToNot(HaveOccurred())
+
+			spec, err := velero.NewRestoreSpec(recipe, group, "app-"+backupGroup.Name, namespaces)
+			Expect(err).ToNot(HaveOccurred())
+
+			restore, err := velero.NewRestore("velero", "app-"+groupName, spec)
+			Expect(err).ToNot(HaveOccurred())
+
+			expectGolden(groupName+".restore", restore)
+		},
+		Entry("with all fields", "all-fields", nil),
+		Entry("without fields", "minimal", nil),
+		Entry("with a backupRef", "restore-subset", nil),
+		Entry("with resolved namespaces", "by-label", []string{"app", "app-2"}),
+	)
+
+	It("rejects volume groups", func() {
+		_, err := velero.NewBackupSpec(recipe, recipe.Spec.FindGroup("data"), nil)
+		Expect(err).To(MatchError(ContainSubstring("only resource groups")))
+	})
+	It("requires resolved namespaces for groups selecting namespaces by label", func() {
+		_, err := velero.NewRestoreSpec(recipe, recipe.Spec.FindGroup("by-label"), "backup", nil)
+		Expect(err).To(MatchError(ContainSubstring("namespaces must be resolved")))
+	})
+	It("rejects groups selecting resources in ways Velero does not support", func() {
+		group := recipe.Spec.FindGroup("minimal")
+		group.NameSelector = "glob:db-*"
+		_, err := velero.NewBackupSpec(recipe, group, nil)
+		Expect(err).To(MatchError(ContainSubstring("selects resources by name")))
+
+		group.NameSelector = ""
+		group.SelectResource = "statefulset"
+		_, err = velero.NewRestoreSpec(recipe, group, "backup", []string{"app"})
+		Expect(err).To(MatchError(ContainSubstring("through the statefulsets")))
+	})
+	It("rejects backupRefs to missing groups or groups of other types", func() {
+		group := &Recipe.Group{Name: "restore", Type: "resource", BackupRef: "unknown"}
+		_, err := velero.BackupGroup(&recipe.Spec, group)
+		Expect(err).To(MatchError(ContainSubstring("not found")))
+
+		group.BackupRef = "data"
+		_, err = velero.BackupGroup(&recipe.Spec, group)
+		Expect(err).To(MatchError(ContainSubstring("is of type volume")))
+	})
+	It("does not share state with the group", func() {
+		group := recipe.Spec.FindGroup("all-fields")
+
+		spec, err := velero.NewBackupSpec(recipe, group, nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		spec.IncludedResources[0] = "changed"
+		spec.LabelSelector.MatchLabels["app"] = "changed"
+		*spec.IncludeClusterResources = true
+		Expect(loadRecipe().Spec.FindGroup("all-fields")).To(Equal(group))
+	})
+})
@@ -0,0 +1,126 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+// Package fake provides an executor for the workflow engine that records the steps it is asked to
+// run and fails them on demand, to test workflow semantics without a cluster.
+package fake
+
+import (
+	"context"
+	"sync"
+
+	ramendrv1alpha1 "github.com/ramendr/recipe/api/v1alpha1"
+	"github.com/ramendr/recipe/pkg/workflow"
+)
+
+// Executor implements workflow.GroupExecutor, workflow.HookExecutor and
+// workflow.ConditionEvaluator. Calls are identified as "backup: <group>", "restore: <group>" and
+// "hook: <hook>/<op or check>"; evaluating conditions is not recorded as call.
+type Executor struct {
+	mu     sync.Mutex
+	calls  []string
+	errors map[string]error
+	// unmet are the reasons of the when conditions that do not hold, by condition
+	unmet map[string]string
+}
+
+var (
+	_ workflow.GroupExecutor      = &Executor{}
+	_ workflow.HookExecutor       = &Executor{}
+	_ workflow.ConditionEvaluator = &Executor{}
+)
+
+// NewExecutor returns an executor whose calls all succeed and whose conditions all hold
+func NewExecutor() *Executor {
+	return &Executor{errors: map[string]error{}, unmet: map[string]string{}}
+}
+
+// Fail makes the given call return err
+func (e *Executor) Fail(call string, err error) *Executor {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.errors[call] = err
+
+	return e
+}
+
+// Unmet makes the given when condition not hold, for the reason given
+func (e *Executor) Unmet(when, reason string) *Executor {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.unmet[when] = reason
+
+	return e
+}
+
+// Calls returns the calls made so far, in order
+func (e *Executor) Calls() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return append([]string{}, e.calls...)
+}
+
+// Engine returns an engine that uses the executor for groups, for all hook types and for conditions
+func (e *Executor) Engine() *workflow.Engine {
+	return &workflow.Engine{
+		Groups:     e,
+		Conditions: e,
+		Hooks: map[string]workflow.HookExecutor{
+			ramendrv1alpha1.HookTypeExec:  e,
+			ramendrv1alpha1.HookTypeScale: e,
+			ramendrv1alpha1.HookTypeCheck: e,
+		},
+	}
+}
+
+func (e *Executor) call(call string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.calls = append(e.calls, call)
+
+	return e.errors[call]
+}
+
+// BackupGroup records "backup: <group>"
+func (e *Executor) BackupGroup(ctx context.Context, recipe *ramendrv1alpha1.Recipe,
+	group *ramendrv1alpha1.Group,
+) error {
+	return e.call("backup: " + group.Name)
+}
+
+// RestoreGroup records "restore: <group>"
+func (e *Executor) RestoreGroup(ctx context.Context, recipe *ramendrv1alpha1.Recipe,
+	group *ramendrv1alpha1.Group,
+) error {
+	return e.call("restore: " + group.Name)
+}
+
+// ExecuteOp records "hook: <hook>/<op>"
+func (e *Executor) ExecuteOp(ctx context.Context, recipe *ramendrv1alpha1.Recipe, hook *ramendrv1alpha1.Hook,
+	op *ramendrv1alpha1.Operation,
+) ([]workflow.Target, error) {
+	return nil, e.call("hook: " + hook.Name + "/" + op.Name)
+}
+
+// ExecuteCheck records "hook: <hook>/<check>"
+func (e *Executor) ExecuteCheck(ctx context.Context, recipe *ramendrv1alpha1.Recipe, hook *ramendrv1alpha1.Hook,
+	chk *ramendrv1alpha1.Check,
+) ([]workflow.Target, error) {
+	return nil, e.call("hook: " + hook.Name + "/" + chk.Name)
+}
+
+// EvaluateWhen reports that the condition holds unless it was made unmet
+func (e *Executor) EvaluateWhen(ctx context.Context, recipe *ramendrv1alpha1.Recipe,
+	when ramendrv1alpha1.StepCondition,
+) (bool, string, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	reason, unmet := e.unmet[when.String()]
+
+	return !unmet, reason, nil
+}
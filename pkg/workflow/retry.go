@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package workflow
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	ramendrv1alpha1 "github.com/ramendr/recipe/api/v1alpha1"
+)
+
+// retry runs an operation or check until it succeeds, the retry policy allows no further attempts,
+// or it fails in a way the policy does not retry. It returns the targets and error of the last
+// attempt, and the record of all attempts if the policy allows retries. Retrying stops when the
+// context is done.
+func retry(ctx context.Context, step ramendrv1alpha1.Step, policy ramendrv1alpha1.RetryPolicy,
+	attempt func(ctx context.Context) ([]Target, error),
+) ([]Target, []Attempt, error) {
+	maxAttempts := policy.MaxAttempts()
+
+	var attempts []Attempt
+
+	for i := 1; ; i++ {
+		current := Attempt{Start: time.Now()}
+		current.Targets, current.Err = attempt(ctx)
+		current.End = time.Now()
+
+		if maxAttempts > 1 {
+			attempts = append(attempts, current)
+		}
+
+		if current.Err == nil || i == maxAttempts || !retryable(policy.RetryOn, current.Targets) {
+			return current.Targets, attempts, current.Err
+		}
+
+		delay := policy.RetryDelay(i)
+
+		log.FromContext(ctx).Info("retrying step", "step", step.String(), "attempt", i, "maxAttempts", maxAttempts,
+			"delay", delay, "error", current.Err.Error())
+
+		timer := time.NewTimer(delay)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return current.Targets, attempts, current.Err
+		case <-timer.C:
+		}
+	}
+}
+
+// retryable reports whether a failed attempt is to be retried: always without RetryOn, else if a
+// command failed with one of its exit codes or wrote a match of its expression to stderr
+func retryable(retryOn *ramendrv1alpha1.RetryOn, targets []Target) bool {
+	if retryOn == nil {
+		return true
+	}
+
+	var stderr *regexp.Regexp
+
+	if retryOn.StderrRegex != "" {
+		// the expression is validated by the webhook, an invalid one matches nothing
+		stderr, _ = regexp.Compile(retryOn.StderrRegex)
+	}
+
+	for i := range targets {
+		target := &targets[i]
+		if target.Err == nil {
+			continue
+		}
+
+		if target.ExitCode != 0 && slices.Contains(retryOn.ExitCodes, target.ExitCode) {
+			return true
+		}
+
+		if stderr != nil && stderr.MatchString(target.Stderr) {
+			return true
+		}
+	}
+
+	return false
+}
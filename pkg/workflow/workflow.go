@@ -0,0 +1,776 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+// Package workflow runs the workflows of a recipe. It walks the sequence of a workflow, hands each
+// step to a pluggable executor and applies the failure semantics of the recipe API: Workflow.FailOn,
+// the Essential flags of groups and hooks, and OnError of hooks, operations, checks and steps. When a
+// workflow fails, the inverse operations of the operations that succeeded are run in reverse order,
+// so that e.g. a quiesced application is unquiesced again. Steps with a when condition are skipped,
+// and recorded as such, unless the condition holds. Workflow steps run the steps of another workflow
+// of the recipe as part of the workflow.
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	ramendrv1alpha1 "github.com/ramendr/recipe/api/v1alpha1"
+)
+
+// Values of Workflow.FailOn
+const (
+	// FailOnAnyError stops the workflow at the first failing step
+	FailOnAnyError string = "any-error"
+	// FailOnEssentialError stops the workflow at the first failing essential step. Failures of
+	// non-essential steps are recorded and the workflow continues.
+	FailOnEssentialError string = "essential-error"
+	// FailOnFullError runs all steps and fails the workflow only if every step that was not skipped
+	// failed
+	FailOnFullError string = "full-error"
+)
+
+// Values of OnError of hooks, operations and checks
+const (
+	// OnErrorFail handles a failing operation or check as a step failure
+	OnErrorFail string = "fail"
+	// OnErrorContinue records a failing operation or check and continues as if it succeeded
+	OnErrorContinue string = "continue"
+)
+
+// GroupAction is what is done with the groups of a workflow
+type GroupAction string
+
+const (
+	GroupActionBackup  GroupAction = "backup"
+	GroupActionRestore GroupAction = "restore"
+)
+
+// GroupExecutor backs up and restores the groups of a recipe
+type GroupExecutor interface {
+	BackupGroup(ctx context.Context, recipe *ramendrv1alpha1.Recipe, group *ramendrv1alpha1.Group) error
+	RestoreGroup(ctx context.Context, recipe *ramendrv1alpha1.Recipe, group *ramendrv1alpha1.Group) error
+}
+
+// HookExecutor runs the operations and checks of hooks of one type. It returns what was done on
+// each object the hook selected, also when it fails.
+type HookExecutor interface {
+	ExecuteOp(ctx context.Context, recipe *ramendrv1alpha1.Recipe, hook *ramendrv1alpha1.Hook,
+		op *ramendrv1alpha1.Operation) ([]Target, error)
+	ExecuteCheck(ctx context.Context, recipe *ramendrv1alpha1.Recipe, hook *ramendrv1alpha1.Hook,
+		chk *ramendrv1alpha1.Check) ([]Target, error)
+}
+
+// ConditionEvaluator evaluates the when conditions of steps
+type ConditionEvaluator interface {
+	// EvaluateWhen reports whether the condition holds, and if it does not, why
+	EvaluateWhen(ctx context.Context, recipe *ramendrv1alpha1.Recipe, when ramendrv1alpha1.StepCondition,
+	) (bool, string, error)
+}
+
+// Target is the record of running a hook operation or check on one object, e.g. a pod
+type Target struct {
+	// Kind, Namespace and Name of the object
+	Kind      string
+	Namespace string
+	Name      string
+	// Container the command ran in, for exec hooks
+	Container string
+	// ExitCode of the command, for exec hooks
+	ExitCode int
+	// Stdout and Stderr of the command, for exec hooks. They may be truncated by the executor.
+	Stdout string
+	Stderr string
+	// Message describes what was done to the object, for hooks other than exec hooks
+	Message string
+	// Err is set if the operation failed on this object
+	Err error
+}
+
+// Engine runs workflows
+type Engine struct {
+	// Groups backs up and restores groups
+	Groups GroupExecutor
+	// Hooks runs hook operations and checks, by Hook.Type
+	Hooks map[string]HookExecutor
+	// Conditions evaluates the when conditions of steps
+	Conditions ConditionEvaluator
+}
+
+// Outcome of a step
+type Outcome string
+
+const (
+	// OutcomeSucceeded is recorded for steps that succeeded
+	OutcomeSucceeded Outcome = "Succeeded"
+	// OutcomeFailed is recorded for steps that failed
+	OutcomeFailed Outcome = "Failed"
+	// OutcomeIgnored is recorded for steps that failed with OnError set to continue
+	OutcomeIgnored Outcome = "Ignored"
+	// OutcomeSkipped is recorded for steps whose when condition does not hold
+	OutcomeSkipped Outcome = "Skipped"
+)
+
+// StepResult is the record of a step that was run
+type StepResult struct {
+	// Index of the step in the sequence. Steps of a parallel set have the index of the set, and
+	// steps of called workflows the index of the workflow step calling them.
+	Index int
+	// Step that was run
+	Step ramendrv1alpha1.Step
+	// Parallel is the name of the parallel set the step ran in, if any
+	Parallel string
+	// Whether the group or hook of the step is essential. False for skipped steps.
+	Essential bool
+	// Outcome of the step
+	Outcome Outcome
+	// Workflow is the name of the called workflow the step ran in, with the names of the workflows
+	// calling it separated by "/", e.g. "quiesce-all". Empty for steps of the workflow the engine
+	// runs.
+	Workflow string
+	// Error of the step, for failed and ignored steps
+	Err error
+	// Reason the step was skipped, for skipped steps
+	Reason string
+	// Targets the hook operation or check of the step ran on, in its last attempt
+	Targets []Target
+	// Attempts of the hook operation or check of the step, in order, if its retry policy allows
+	// retries. The last attempt is the one reflected in Targets and Err.
+	Attempts []Attempt
+	// Start and End time of the step
+	Start, End time.Time
+}
+
+// Attempt is the record of one run of a hook operation or check that may be retried
+type Attempt struct {
+	// Targets the operation or check ran on
+	Targets []Target
+	// Err is set if the attempt failed
+	Err error
+	// Start and End time of the attempt
+	Start, End time.Time
+}
+
+// Result is the record of a workflow run
+type Result struct {
+	// Workflow is the name of the workflow that was run
+	Workflow string
+	// FailOn is the effective failure mode of the workflow
+	FailOn string
+	// Steps that were run, in order. Steps after the one that stopped the workflow are not included.
+	// Steps of a parallel set are recorded in the order they completed. Steps of a called workflow
+	// precede the workflow step calling it.
+	Steps []StepResult
+	// Err is set if the workflow failed
+	Err error
+	// Rollback records the inverse operations run after the workflow failed, in order. The Index of
+	// each is the one of the step it reverted.
+	Rollback []StepResult
+	// RollbackErr is set if any inverse operation failed
+	RollbackErr error
+}
+
+// Failed reports whether the workflow failed
+func (r *Result) Failed() bool {
+	return r.Err != nil
+}
+
+// ErrWorkflowNotFound is returned for workflows that the recipe does not define
+var ErrWorkflowNotFound = errors.New("workflow not found")
+
+// GroupActionFor returns the action applied to the groups of a workflow: restore for the reserved
+// workflows that restore, e.g. restore and failover, backup otherwise
+func GroupActionFor(workflowName string) GroupAction {
+	if ramendrv1alpha1.IsRestoreWorkflow(workflowName) {
+		return GroupActionRestore
+	}
+
+	return GroupActionBackup
+}
+
+// Run runs the named workflow of the recipe, or the default of a reserved workflow that the recipe
+// omits. It returns the record of the run, and the error that failed the workflow if any. The
+// record is nil only if the workflow cannot be started.
+func (e *Engine) Run(ctx context.Context, recipe *ramendrv1alpha1.Recipe, workflowName string) (*Result, error) {
+	workflow := recipe.Spec.EffectiveWorkflow(workflowName)
+	if workflow == nil {
+		return nil, fmt.Errorf("%w: recipe %s/%s has no workflow %q",
+			ErrWorkflowNotFound, recipe.Namespace, recipe.Name, workflowName)
+	}
+
+	steps, err := parseSequence(workflow)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Workflow: workflowName, FailOn: failOn(workflow)}
+	run := &run{
+		engine:   e,
+		recipe:   recipe,
+		workflow: workflow,
+		action:   GroupActionFor(workflowName),
+		calls:    []string{workflowName},
+		result:   result,
+		inverses: &inverses{},
+	}
+
+	run.sequence(ctx, steps)
+
+	result.Err = result.evaluate()
+	if run.cancelled != nil {
+		result.Err = run.cancelled
+	}
+
+	if result.Err != nil {
+		// the application must not be left e.g. quiesced, also when the workflow was cancelled
+		run.rollback(context.WithoutCancel(ctx))
+	}
+
+	return result, result.Err
+}
+
+// parseSequence parses the steps of the sequence of a workflow
+func parseSequence(workflow *ramendrv1alpha1.Workflow) ([]ramendrv1alpha1.Step, error) {
+	steps := make([]ramendrv1alpha1.Step, 0, len(workflow.Sequence))
+
+	for i, entry := range workflow.Sequence {
+		step, err := ramendrv1alpha1.ParseStep(entry)
+		if err != nil {
+			return nil, fmt.Errorf("workflow %q step %d: %w", workflow.Name, i, err)
+		}
+
+		steps = append(steps, step)
+	}
+
+	return steps, nil
+}
+
+// run is the state of running a workflow, or a workflow called by a workflow step
+type run struct {
+	engine   *Engine
+	recipe   *ramendrv1alpha1.Recipe
+	workflow *ramendrv1alpha1.Workflow
+	action   GroupAction
+	// calls are the names of the workflow the engine runs and of the workflows called down to this
+	// one, in order
+	calls []string
+	// callIndex is the index of the step of the workflow the engine runs that called this workflow,
+	// which steps of called workflows are recorded with
+	callIndex int
+	// cancelled is set if the workflow was cancelled before all steps were started
+	cancelled error
+
+	// mu guards result, which steps of parallel sets record concurrently
+	mu     sync.Mutex
+	result *Result
+	// inverses are shared with the workflows this workflow calls, so that a workflow step reverting
+	// an op run by another workflow step is recognized, and a failure reverts the ops of both
+	inverses *inverses
+}
+
+// inverses are the steps reverting the succeeded ops that have an InverseOp, in the order the ops
+// ran
+type inverses struct {
+	mu    sync.Mutex
+	steps []inverseStep
+}
+
+// inverseStep is the step reverting the op of the step at index
+type inverseStep struct {
+	index   int
+	reverts ramendrv1alpha1.Step
+	step    ramendrv1alpha1.Step
+}
+
+// sequence runs the steps of the sequence of the workflow until one stops it
+func (r *run) sequence(ctx context.Context, steps []ramendrv1alpha1.Step) {
+	for i, step := range steps {
+		if ctx.Err() != nil {
+			r.cancelled = fmt.Errorf("workflow %q cancelled before step %d: %w", r.result.Workflow, i, ctx.Err())
+
+			break
+		}
+
+		index := i
+		if len(r.calls) > 1 {
+			index = r.callIndex
+		}
+
+		if stop := r.step(ctx, index, step); stop {
+			break
+		}
+	}
+}
+
+// step runs a step and records its result. It returns whether the workflow has to stop.
+func (r *run) step(ctx context.Context, index int, step ramendrv1alpha1.Step) bool {
+	if stepResult, skipped := r.when(ctx, index, step); skipped {
+		return r.record(stepResult)
+	}
+
+	switch step.Kind {
+	case ramendrv1alpha1.StepKindParallel:
+		return r.parallel(ctx, index, step)
+	case ramendrv1alpha1.StepKindWorkflow:
+		return r.call(ctx, index, step)
+	default:
+		return r.record(r.execute(ctx, index, step))
+	}
+}
+
+// call runs the workflow a workflow step calls, with its own FailOn. The steps of the called workflow
+// are recorded before the workflow step, which fails if the called workflow fails. The ops of the
+// called workflow are reverted when the workflow the engine runs fails.
+func (r *run) call(ctx context.Context, index int, step ramendrv1alpha1.Step) bool {
+	called := r.recipe.Spec.FindWorkflow(step.Name)
+
+	switch {
+	case ramendrv1alpha1.IsReservedWorkflowName(step.Name):
+		return r.record(r.failed(ctx, index, step,
+			fmt.Errorf("workflow %q is reserved, reserved workflows cannot be called", step.Name)))
+	case called == nil:
+		return r.record(r.failed(ctx, index, step, fmt.Errorf("workflow %q not found", step.Name)))
+	case slices.Contains(r.calls, step.Name):
+		return r.record(r.failed(ctx, index, step, fmt.Errorf("workflow %q calls itself: %s", step.Name,
+			strings.Join(append(slices.Clone(r.calls), step.Name), " -> "))))
+	}
+
+	steps, err := parseSequence(called)
+	if err != nil {
+		return r.record(r.failed(ctx, index, step, err))
+	}
+
+	log.FromContext(ctx).Info("calling workflow", "workflow", r.result.Workflow, "called", step.Name,
+		"steps", len(steps))
+
+	callee := &run{
+		engine:    r.engine,
+		recipe:    r.recipe,
+		workflow:  called,
+		action:    r.action,
+		calls:     append(slices.Clone(r.calls), step.Name),
+		callIndex: index,
+		result:    &Result{Workflow: step.Name, FailOn: failOn(called)},
+		inverses:  r.inverses,
+	}
+	stepResult := StepResult{Index: index, Step: step, Essential: true, Start: time.Now()}
+
+	callee.sequence(ctx, steps)
+
+	stepResult.End = time.Now()
+	stepResult.Err = callee.result.evaluate()
+	stepResult.Outcome = OutcomeSucceeded
+
+	if callee.cancelled != nil {
+		stepResult.Err = callee.cancelled
+	}
+
+	if stepResult.Err != nil {
+		stepResult.Outcome = OutcomeFailed
+	}
+
+	r.mu.Lock()
+
+	if callee.cancelled != nil {
+		r.cancelled = callee.cancelled
+	}
+
+	for _, calledStep := range callee.result.Steps {
+		calledStep.Workflow = path.Join(step.Name, calledStep.Workflow)
+		r.result.Steps = append(r.result.Steps, calledStep)
+	}
+
+	r.mu.Unlock()
+
+	return r.record(stepResult) || callee.cancelled != nil
+}
+
+// parallel runs the steps of a parallel set concurrently, at most MaxParallel at a time. Once a step
+// fails in a way that stops the workflow, no further steps of the set are started, and the set
+// returns when the running ones completed.
+func (r *run) parallel(ctx context.Context, index int, step ramendrv1alpha1.Step) bool {
+	set := r.workflow.FindParallel(step.Name)
+	if set == nil {
+		return r.record(r.failed(ctx, index, step, fmt.Errorf("parallel set %q not found", step.Name)))
+	}
+
+	steps := make([]ramendrv1alpha1.Step, 0, len(set.Steps))
+
+	for i, entry := range set.Steps {
+		parsed, err := ramendrv1alpha1.ParseStep(entry)
+		if err != nil {
+			return r.record(r.failed(ctx, index, step, fmt.Errorf("parallel set %q step %d: %w", set.Name, i, err)))
+		}
+
+		steps = append(steps, parsed)
+	}
+
+	log.FromContext(ctx).Info("running parallel set", "workflow", r.result.Workflow, "parallel", set.Name,
+		"steps", len(steps), "maxParallel", set.EffectiveMaxParallel())
+
+	var (
+		wg      sync.WaitGroup
+		stopped atomic.Bool
+	)
+
+	slots := make(chan struct{}, set.EffectiveMaxParallel())
+
+	for i, parallelStep := range steps {
+		slots <- struct{}{}
+
+		if ctx.Err() != nil {
+			r.mu.Lock()
+			r.cancelled = fmt.Errorf("workflow %q cancelled before step %d of parallel set %q: %w",
+				r.result.Workflow, i, set.Name, ctx.Err())
+			r.mu.Unlock()
+			stopped.Store(true)
+		}
+
+		if stopped.Load() {
+			<-slots
+
+			break
+		}
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			stepResult, skipped := r.when(ctx, index, parallelStep)
+			if !skipped {
+				stepResult = r.execute(ctx, index, parallelStep)
+			}
+
+			stepResult.Parallel = set.Name
+
+			if r.record(stepResult) {
+				stopped.Store(true)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	return stopped.Load()
+}
+
+// failed returns the result of a step that could not be run
+func (r *run) failed(ctx context.Context, index int, step ramendrv1alpha1.Step, err error) StepResult {
+	log.FromContext(ctx).Error(err, "step failed", "workflow", r.result.Workflow, "step", step.String())
+
+	now := time.Now()
+
+	return StepResult{
+		Index: index, Step: step, Essential: true, Outcome: OutcomeFailed, Err: err, Start: now, End: now,
+	}
+}
+
+// when evaluates the when condition of a step. It returns whether the step is not to be run, and if
+// so its result: skipped if the condition does not hold, failed if it cannot be evaluated.
+func (r *run) when(ctx context.Context, index int, step ramendrv1alpha1.Step) (StepResult, bool) {
+	if step.When == "" {
+		return StepResult{}, false
+	}
+
+	when, err := ramendrv1alpha1.ParseWhen(step.When)
+	if err != nil {
+		return r.failed(ctx, index, step, fmt.Errorf("invalid when condition %q: %w", step.When, err)), true
+	}
+
+	if r.engine.Conditions == nil {
+		return r.failed(ctx, index, step, fmt.Errorf("no evaluator for when conditions")), true
+	}
+
+	start := time.Now()
+
+	met, reason, err := r.engine.Conditions.EvaluateWhen(ctx, r.recipe, when)
+	if err != nil {
+		return r.failed(ctx, index, step, fmt.Errorf("failed to evaluate when condition %q: %w", step.When, err)), true
+	}
+
+	if met {
+		return StepResult{}, false
+	}
+
+	log.FromContext(ctx).Info("skipping step", "workflow", r.result.Workflow, "step", step.String(),
+		"reason", reason)
+
+	return StepResult{
+		Index: index, Step: step, Outcome: OutcomeSkipped, Reason: reason, Start: start, End: time.Now(),
+	}, true
+}
+
+// execute runs a group or hook step and returns its result
+func (r *run) execute(ctx context.Context, index int, step ramendrv1alpha1.Step) StepResult {
+	logger := log.FromContext(ctx).WithValues("workflow", r.result.Workflow, "step", step.String())
+
+	stepResult := StepResult{Index: index, Step: step, Essential: true, Start: time.Now()}
+	onError := OnErrorFail
+
+	var err error
+
+	switch step.Kind {
+	case ramendrv1alpha1.StepKindGroup:
+		stepResult.Essential, err = r.runGroup(ctx, step)
+	case ramendrv1alpha1.StepKindHook:
+		onError, err = r.runHook(ctx, step, &stepResult)
+	default:
+		err = fmt.Errorf("unsupported step kind %q", step.Kind)
+	}
+
+	if step.OnError != "" {
+		onError = step.OnError
+	}
+
+	stepResult.End = time.Now()
+	stepResult.Err = err
+
+	switch {
+	case err == nil:
+		stepResult.Outcome = OutcomeSucceeded
+	case onError == OnErrorContinue:
+		stepResult.Outcome = OutcomeIgnored
+		logger.Info("step failed, continuing as its onError is continue", "error", err.Error())
+	default:
+		stepResult.Outcome = OutcomeFailed
+		logger.Error(err, "step failed", "essential", stepResult.Essential)
+	}
+
+	return stepResult
+}
+
+// record records the result of a step. It returns whether the workflow has to stop.
+func (r *run) record(stepResult StepResult) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.result.Steps = append(r.result.Steps, stepResult)
+
+	if stepResult.Outcome == OutcomeSucceeded && stepResult.Step.Kind == ramendrv1alpha1.StepKindHook {
+		r.recordInverse(stepResult.Index, stepResult.Step)
+	}
+
+	if stepResult.Outcome != OutcomeFailed {
+		return false
+	}
+
+	switch r.result.FailOn {
+	case FailOnEssentialError:
+		return stepResult.Essential
+	case FailOnFullError:
+		return false
+	default:
+		return true
+	}
+}
+
+func (r *run) runGroup(ctx context.Context, step ramendrv1alpha1.Step) (bool, error) {
+	group := r.recipe.Spec.FindGroup(step.Name)
+	if group == nil {
+		return true, fmt.Errorf("group %q not found", step.Name)
+	}
+
+	essential := isEssential(group.Essential)
+
+	if r.engine.Groups == nil {
+		return essential, fmt.Errorf("no executor for groups")
+	}
+
+	if r.action == GroupActionRestore {
+		return essential, r.engine.Groups.RestoreGroup(ctx, r.recipe, group)
+	}
+
+	return essential, r.engine.Groups.BackupGroup(ctx, r.recipe, group)
+}
+
+// runHook runs the operation or check of a hook step with the timeout of the step if it has one,
+// retrying it as its retry policy allows, and records whether the hook is essential, the targets and
+// the attempts in the step result. It returns the OnError of the operation or check and the error of
+// its last attempt.
+func (r *run) runHook(ctx context.Context, step ramendrv1alpha1.Step, stepResult *StepResult) (string, error) {
+	hook := r.recipe.Spec.FindHook(step.Name)
+	if hook == nil {
+		return OnErrorFail, fmt.Errorf("hook %q not found", step.Name)
+	}
+
+	stepResult.Essential = isEssential(hook.Essential)
+
+	opName, err := hook.ResolveOp(step.Op)
+	if err != nil {
+		return OnErrorFail, err
+	}
+
+	executor := r.engine.Hooks[hook.Type]
+	if executor == nil {
+		return OnErrorFail, fmt.Errorf("no executor for hooks of type %q", hook.Type)
+	}
+
+	if op := hook.FindOp(opName); op != nil {
+		if step.Timeout != 0 {
+			op = op.DeepCopy()
+			op.Timeout = step.Timeout
+		}
+
+		stepResult.Targets, stepResult.Attempts, err = retry(ctx, step, hook.EffectiveRetryPolicy(op.RetryPolicy),
+			func(ctx context.Context) ([]Target, error) {
+				return executor.ExecuteOp(ctx, r.recipe, hook, op)
+			})
+
+		return onError(op.OnError, hook.OnError), err
+	}
+
+	chk := hook.FindCheck(opName)
+	if step.Timeout != 0 {
+		chk = chk.DeepCopy()
+		chk.Timeout = step.Timeout
+	}
+
+	stepResult.Targets, stepResult.Attempts, err = retry(ctx, step, hook.EffectiveRetryPolicy(chk.RetryPolicy),
+		func(ctx context.Context) ([]Target, error) {
+			return executor.ExecuteCheck(ctx, r.recipe, hook, chk)
+		})
+
+	return onError(chk.OnError, hook.OnError), err
+}
+
+// recordInverse records the inverse op of a succeeded hook step. A step running the inverse op of
+// an earlier step, e.g. unquiesce after quiesce, reverts that step itself, so neither needs to be
+// reverted anymore.
+func (r *run) recordInverse(index int, step ramendrv1alpha1.Step) {
+	hook := r.recipe.Spec.FindHook(step.Name)
+
+	opName, err := hook.ResolveOp(step.Op)
+	if err != nil {
+		return
+	}
+
+	r.inverses.mu.Lock()
+	defer r.inverses.mu.Unlock()
+
+	for i := len(r.inverses.steps) - 1; i >= 0; i-- {
+		if inverse := r.inverses.steps[i].step; inverse.Name == hook.Name && inverse.Op == opName {
+			r.inverses.steps = append(r.inverses.steps[:i], r.inverses.steps[i+1:]...)
+
+			return
+		}
+	}
+
+	if op := hook.FindOp(opName); op != nil && op.InverseOp != "" {
+		r.inverses.steps = append(r.inverses.steps, inverseStep{
+			index:   index,
+			reverts: step,
+			step:    ramendrv1alpha1.Step{Kind: ramendrv1alpha1.StepKindHook, Name: hook.Name, Op: op.InverseOp},
+		})
+	}
+}
+
+// rollback runs the recorded inverse ops in reverse order. Failing inverse ops do not stop the
+// rollback, to revert as much as possible.
+func (r *run) rollback(ctx context.Context) {
+	logger := log.FromContext(ctx).WithValues("workflow", r.result.Workflow)
+	failed := []error{}
+
+	for i := len(r.inverses.steps) - 1; i >= 0; i-- {
+		inverse := r.inverses.steps[i]
+		stepResult := StepResult{Index: inverse.index, Step: inverse.step, Essential: true, Start: time.Now()}
+
+		logger.Info("reverting step", "step", inverse.reverts.String(), "inverse", inverse.step.String())
+
+		_, stepResult.Err = r.runHook(ctx, inverse.step, &stepResult)
+		stepResult.End = time.Now()
+
+		if stepResult.Err == nil {
+			stepResult.Outcome = OutcomeSucceeded
+		} else {
+			stepResult.Outcome = OutcomeFailed
+			failed = append(failed, fmt.Errorf("reverting step %d (%s): %w", inverse.index, inverse.reverts,
+				stepResult.Err))
+			logger.Error(stepResult.Err, "inverse op failed", "inverse", inverse.step.String())
+		}
+
+		r.result.Rollback = append(r.result.Rollback, stepResult)
+	}
+
+	if len(failed) != 0 {
+		r.result.RollbackErr = fmt.Errorf("rollback of workflow %q failed: %w", r.result.Workflow,
+			errors.Join(failed...))
+	}
+}
+
+// evaluate returns the error failing the workflow, if any, according to FailOn
+func (r *Result) evaluate() error {
+	failed := []error{}
+	essentialFailed := false
+	ran := 0
+
+	for i := range r.Steps {
+		step := &r.Steps[i]
+		if step.Workflow != "" {
+			// accounted for by the workflow step calling the workflow
+			continue
+		}
+
+		if step.Outcome != OutcomeSkipped {
+			ran++
+		}
+
+		if step.Outcome != OutcomeFailed {
+			continue
+		}
+
+		failed = append(failed, fmt.Errorf("step %d (%s): %w", step.Index, step.Step, step.Err))
+		essentialFailed = essentialFailed || step.Essential
+	}
+
+	if len(failed) == 0 {
+		return nil
+	}
+
+	switch r.FailOn {
+	case FailOnEssentialError:
+		if !essentialFailed {
+			return nil
+		}
+	case FailOnFullError:
+		if len(failed) < ran {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("workflow %q failed on %s: %w", r.Workflow, r.FailOn, errors.Join(failed...))
+}
+
+func failOn(workflow *ramendrv1alpha1.Workflow) string {
+	if workflow.FailOn == "" {
+		return FailOnAnyError
+	}
+
+	return workflow.FailOn
+}
+
+// onError returns the OnError of an operation or check, defaulting to the one of its hook
+func onError(value, hookValue string) string {
+	switch {
+	case value != "":
+		return value
+	case hookValue != "":
+		return hookValue
+	default:
+		return OnErrorFail
+	}
+}
+
+// isEssential returns the value of an Essential flag, which defaults to true
+func isEssential(essential *bool) bool {
+	return essential == nil || *essential
+}
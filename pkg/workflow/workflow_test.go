@@ -0,0 +1,718 @@
+// SPDX-FileCopyrightText: IBM Corp.
+// SPDX-License-Identifier: Apache2.0
+
+package workflow_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/utils/ptr"
+
+	Recipe "github.com/ramendr/recipe/api/v1alpha1"
+	"github.com/ramendr/recipe/pkg/workflow"
+	"github.com/ramendr/recipe/pkg/workflow/fake"
+)
+
+var errFake = errors.New("fake error")
+
+func testRecipe(failOn string, sequence ...map[string]string) *Recipe.Recipe {
+	return &Recipe.Recipe{
+		Spec: Recipe.RecipeSpec{
+			Groups: []*Recipe.Group{
+				{Name: "config", Type: "resource"},
+				{Name: "cache", Type: "resource", Essential: ptr.To(false)},
+				{Name: "data", Type: "volume"},
+			},
+			Hooks: []*Recipe.Hook{
+				{
+					Name: "db",
+					Type: "exec",
+					Ops: []*Recipe.Operation{
+						{Name: "quiesce", Command: "/bin/quiesce", InverseOp: "unquiesce"},
+						{Name: "unquiesce", Command: "/bin/unquiesce"},
+						{Name: "log", Command: "/bin/log", OnError: workflow.OnErrorContinue},
+					},
+					Chks: []*Recipe.Check{{Name: "ready"}},
+				},
+				{
+					Name:      "metrics",
+					Type:      "exec",
+					Essential: ptr.To(false),
+					OnError:   workflow.OnErrorFail,
+					Ops:       []*Recipe.Operation{{Name: "flush", Command: "/bin/flush"}},
+				},
+			},
+			Workflows: []*Recipe.Workflow{
+				{Name: Recipe.BackupWorkflowName, FailOn: failOn, Sequence: sequence},
+				{Name: Recipe.RestoreWorkflowName, FailOn: failOn, Sequence: sequence},
+			},
+		},
+	}
+}
+
+func group(name string) map[string]string { return map[string]string{"group": name} }
+func hook(name string) map[string]string  { return map[string]string{"hook": name} }
+
+func outcomes(result *workflow.Result) []workflow.Outcome {
+	outcomes := []workflow.Outcome{}
+	for _, step := range result.Steps {
+		outcomes = append(outcomes, step.Outcome)
+	}
+
+	return outcomes
+}
+
+// cancellingExecutor cancels the workflow after running an op
+type cancellingExecutor struct {
+	*fake.Executor
+	cancel context.CancelFunc
+}
+
+func (e *cancellingExecutor) ExecuteOp(ctx context.Context, recipe *Recipe.Recipe, hook *Recipe.Hook,
+	op *Recipe.Operation,
+) ([]workflow.Target, error) {
+	defer e.cancel()
+
+	return e.Executor.ExecuteOp(ctx, recipe, hook, op)
+}
+
+// concurrencyExecutor records the highest number of ops running at the same time
+type concurrencyExecutor struct {
+	*fake.Executor
+	mu      sync.Mutex
+	running int
+	max     int
+}
+
+func (e *concurrencyExecutor) ExecuteOp(ctx context.Context, recipe *Recipe.Recipe, hook *Recipe.Hook,
+	op *Recipe.Operation,
+) ([]workflow.Target, error) {
+	e.mu.Lock()
+	e.running++
+	e.max = max(e.max, e.running)
+	e.mu.Unlock()
+
+	time.Sleep(20 * time.Millisecond)
+
+	e.mu.Lock()
+	e.running--
+	e.mu.Unlock()
+
+	return e.Executor.ExecuteOp(ctx, recipe, hook, op)
+}
+
+// flakyExecutor fails the first ops it runs with a failing target
+type flakyExecutor struct {
+	*fake.Executor
+	failures int
+	target   workflow.Target
+}
+
+func (e *flakyExecutor) ExecuteOp(ctx context.Context, recipe *Recipe.Recipe, hook *Recipe.Hook,
+	op *Recipe.Operation,
+) ([]workflow.Target, error) {
+	if targets, err := e.Executor.ExecuteOp(ctx, recipe, hook, op); err != nil || e.failures == 0 {
+		return targets, err
+	}
+
+	e.failures--
+	target := e.target
+	target.Err = errFake
+
+	return []workflow.Target{target}, errFake
+}
+
+// timeoutExecutor records the timeouts of the ops and checks it runs
+type timeoutExecutor struct {
+	*fake.Executor
+	timeouts []int
+}
+
+func (e *timeoutExecutor) ExecuteOp(ctx context.Context, recipe *Recipe.Recipe, hook *Recipe.Hook,
+	op *Recipe.Operation,
+) ([]workflow.Target, error) {
+	e.timeouts = append(e.timeouts, op.Timeout)
+
+	return e.Executor.ExecuteOp(ctx, recipe, hook, op)
+}
+
+func (e *timeoutExecutor) ExecuteCheck(ctx context.Context, recipe *Recipe.Recipe, hook *Recipe.Hook,
+	chk *Recipe.Check,
+) ([]workflow.Target, error) {
+	e.timeouts = append(e.timeouts, chk.Timeout)
+
+	return e.Executor.ExecuteCheck(ctx, recipe, hook, chk)
+}
+
+func parallel(name string) map[string]string { return map[string]string{"parallel": name} }
+
+// parallelRecipe returns a recipe with hooks s0..s<n-1> with a quiesce op each, quiesced in parallel
+func parallelRecipe(failOn string, n, maxParallel int) *Recipe.Recipe {
+	recipe := testRecipe(failOn, parallel("quiesce"), group("config"))
+	steps := []map[string]string{}
+
+	for i := range n {
+		name := fmt.Sprintf("s%d", i)
+		recipe.Spec.Hooks = append(recipe.Spec.Hooks, &Recipe.Hook{
+			Name: name,
+			Type: "exec",
+			Ops:  []*Recipe.Operation{{Name: "quiesce", Command: "/bin/quiesce"}},
+		})
+		steps = append(steps, hook(name+"/quiesce"))
+	}
+
+	recipe.Spec.Workflows[0].Parallel = []*Recipe.ParallelSteps{
+		{Name: "quiesce", Steps: steps, MaxParallel: maxParallel},
+	}
+
+	return recipe
+}
+
+var _ = Describe("Engine", func() {
+	var executor *fake.Executor
+
+	ctx := context.TODO()
+	sequence := []map[string]string{
+		hook("db/quiesce"),
+		hook("db/ready"),
+		group("config"),
+		group("cache"),
+		hook("metrics/flush"),
+		group("data"),
+		hook("db/unquiesce"),
+	}
+
+	BeforeEach(func() {
+		executor = fake.NewExecutor()
+	})
+
+	It("runs the steps of the backup workflow in order", func() {
+		result, err := executor.Engine().Run(ctx, testRecipe("", sequence...), Recipe.BackupWorkflowName)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.FailOn).To(Equal(workflow.FailOnAnyError))
+		Expect(executor.Calls()).To(Equal([]string{
+			"hook: db/quiesce",
+			"hook: db/ready",
+			"backup: config",
+			"backup: cache",
+			"hook: metrics/flush",
+			"backup: data",
+			"hook: db/unquiesce",
+		}))
+	})
+	It("restores groups in the restore workflow", func() {
+		_, err := executor.Engine().Run(ctx, testRecipe("", group("config")), Recipe.RestoreWorkflowName)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(executor.Calls()).To(Equal([]string{"restore: config"}))
+	})
+	It("reports missing workflows", func() {
+		_, err := executor.Engine().Run(ctx, testRecipe(""), "archive")
+		Expect(err).To(MatchError(workflow.ErrWorkflowNotFound))
+	})
+	It("runs the workflow a reserved workflow falls back to", func() {
+		result, err := executor.Engine().Run(ctx, testRecipe("", group("config")), Recipe.FailoverWorkflowName)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Workflow).To(Equal(Recipe.FailoverWorkflowName))
+		Expect(executor.Calls()).To(Equal([]string{"restore: config"}))
+	})
+	It("runs the default of reserved workflows the recipe omits", func() {
+		recipe := testRecipe("")
+		recipe.Spec.Workflows = nil
+
+		_, err := executor.Engine().Run(ctx, recipe, Recipe.CaptureWorkflowName)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(executor.Calls()).To(Equal([]string{"backup: config", "backup: cache", "backup: data"}))
+	})
+	It("ignores failures of ops that continue on error", func() {
+		executor.Fail("hook: db/log", errFake)
+
+		result, err := executor.Engine().Run(ctx, testRecipe("", hook("db/log"), group("config")),
+			Recipe.BackupWorkflowName)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(outcomes(result)).To(Equal([]workflow.Outcome{workflow.OutcomeIgnored, workflow.OutcomeSucceeded}))
+	})
+	It("overrides the onError of hooks and groups with the one of their step", func() {
+		executor.Fail("hook: db/log", errFake).Fail("backup: config", errFake)
+
+		result, err := executor.Engine().Run(ctx, testRecipe("",
+			map[string]string{"group": "config", "onError": "continue"},
+			map[string]string{"hook": "db/log", "onError": "fail"},
+			group("data"),
+		), Recipe.BackupWorkflowName)
+		Expect(err).To(MatchError(errFake))
+		Expect(outcomes(result)).To(Equal([]workflow.Outcome{workflow.OutcomeIgnored, workflow.OutcomeFailed}))
+	})
+	It("overrides the timeout of ops and checks with the one of their step", func() {
+		engine := executor.Engine()
+		timeouts := &timeoutExecutor{Executor: executor}
+		engine.Hooks[Recipe.HookTypeExec] = timeouts
+		engine.Hooks[Recipe.HookTypeCheck] = timeouts
+
+		_, err := engine.Run(ctx, testRecipe("",
+			map[string]string{"hook": "db/quiesce", "timeout": "300"},
+			map[string]string{"hook": "db/ready", "timeout": "5"},
+			hook("db/unquiesce"),
+		), Recipe.BackupWorkflowName)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(timeouts.timeouts).To(Equal([]int{300, 5, 0}))
+	})
+
+	Context("any-error", func() {
+		It("stops at the first failure, even of non-essential steps", func() {
+			executor.Fail("backup: cache", errFake)
+
+			result, err := executor.Engine().Run(ctx, testRecipe(workflow.FailOnAnyError, sequence...),
+				Recipe.BackupWorkflowName)
+			Expect(err).To(MatchError(errFake))
+			Expect(result.Failed()).To(BeTrue())
+			Expect(executor.Calls()).To(HaveLen(5))
+			Expect(result.Steps[3].Outcome).To(Equal(workflow.OutcomeFailed))
+			Expect(result.Steps[3].Essential).To(BeFalse())
+		})
+	})
+
+	Context("essential-error", func() {
+		It("continues after failures of non-essential steps", func() {
+			executor.Fail("backup: cache", errFake).Fail("hook: metrics/flush", errFake)
+
+			result, err := executor.Engine().Run(ctx, testRecipe(workflow.FailOnEssentialError, sequence...),
+				Recipe.BackupWorkflowName)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(executor.Calls()).To(HaveLen(len(sequence)))
+			Expect(outcomes(result)).To(ContainElements(workflow.OutcomeFailed, workflow.OutcomeFailed))
+		})
+		It("stops at the first failure of an essential step", func() {
+			executor.Fail("backup: config", errFake)
+
+			_, err := executor.Engine().Run(ctx, testRecipe(workflow.FailOnEssentialError, sequence...),
+				Recipe.BackupWorkflowName)
+			Expect(err).To(MatchError(errFake))
+			Expect(executor.Calls()).To(Equal([]string{
+				"hook: db/quiesce", "hook: db/ready", "backup: config", "hook: db/unquiesce",
+			}))
+		})
+	})
+
+	Context("full-error", func() {
+		It("runs all steps and succeeds if some succeeded", func() {
+			executor.Fail("backup: config", errFake).Fail("hook: db/quiesce", errFake)
+
+			result, err := executor.Engine().Run(ctx, testRecipe(workflow.FailOnFullError, sequence...),
+				Recipe.BackupWorkflowName)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(executor.Calls()).To(HaveLen(len(sequence)))
+			Expect(result.Steps[0].Outcome).To(Equal(workflow.OutcomeFailed))
+		})
+		It("fails if all steps failed", func() {
+			executor.Fail("backup: config", errFake).Fail("backup: data", errFake)
+
+			_, err := executor.Engine().Run(ctx, testRecipe(workflow.FailOnFullError, group("config"), group("data")),
+				Recipe.BackupWorkflowName)
+			Expect(err).To(MatchError(errFake))
+			Expect(executor.Calls()).To(HaveLen(2))
+		})
+	})
+
+	Context("rollback", func() {
+		It("runs the inverse ops of succeeded ops in reverse order", func() {
+			recipe := testRecipe("", hook("db/quiesce"), hook("metrics/flush"), group("config"))
+			recipe.Spec.Hooks[1].Ops = append(recipe.Spec.Hooks[1].Ops,
+				&Recipe.Operation{Name: "pause", Command: "/bin/pause", InverseOp: "resume"},
+				&Recipe.Operation{Name: "resume", Command: "/bin/resume"})
+			recipe.Spec.Workflows[0].Sequence = []map[string]string{
+				hook("db/quiesce"), hook("metrics/pause"), group("config"),
+			}
+			executor.Fail("backup: config", errFake)
+
+			result, err := executor.Engine().Run(ctx, recipe, Recipe.BackupWorkflowName)
+			Expect(err).To(MatchError(errFake))
+			Expect(result.RollbackErr).ToNot(HaveOccurred())
+			Expect(executor.Calls()).To(Equal([]string{
+				"hook: db/quiesce", "hook: metrics/pause", "backup: config",
+				"hook: metrics/resume", "hook: db/unquiesce",
+			}))
+			Expect(result.Rollback).To(HaveLen(2))
+			Expect(result.Rollback[0].Index).To(Equal(1))
+			Expect(result.Rollback[0].Step.String()).To(Equal("hook: metrics/resume"))
+		})
+		It("does not revert ops that were already reverted by the workflow", func() {
+			executor.Fail("backup: data", errFake)
+
+			result, err := executor.Engine().Run(ctx,
+				testRecipe("", hook("db/quiesce"), hook("db/unquiesce"), group("data")), Recipe.BackupWorkflowName)
+			Expect(err).To(MatchError(errFake))
+			Expect(result.Rollback).To(BeEmpty())
+			Expect(executor.Calls()).To(HaveLen(3))
+		})
+		It("does not revert failed ops, or workflows that succeeded", func() {
+			executor.Fail("hook: db/quiesce", errFake)
+
+			result, err := executor.Engine().Run(ctx, testRecipe("", hook("db/quiesce")), Recipe.BackupWorkflowName)
+			Expect(err).To(MatchError(errFake))
+			Expect(result.Rollback).To(BeEmpty())
+
+			executor = fake.NewExecutor()
+			result, err = executor.Engine().Run(ctx, testRecipe("", hook("db/quiesce")), Recipe.BackupWorkflowName)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Rollback).To(BeEmpty())
+			Expect(executor.Calls()).To(Equal([]string{"hook: db/quiesce"}))
+		})
+		It("records failing inverse ops", func() {
+			executor.Fail("backup: config", errFake).Fail("hook: db/unquiesce", errFake)
+
+			result, err := executor.Engine().Run(ctx, testRecipe("", hook("db/quiesce"), group("config")),
+				Recipe.BackupWorkflowName)
+			Expect(err).To(MatchError(errFake))
+			Expect(result.RollbackErr).To(MatchError(ContainSubstring("reverting step 0 (hook: db/quiesce)")))
+			Expect(result.Rollback[0].Outcome).To(Equal(workflow.OutcomeFailed))
+		})
+		It("reverts cancelled workflows", func() {
+			cancelled, cancel := context.WithCancel(ctx)
+			engine := executor.Engine()
+			engine.Hooks[Recipe.HookTypeExec] = &cancellingExecutor{Executor: executor, cancel: cancel}
+
+			result, err := engine.Run(cancelled, testRecipe("", hook("db/quiesce"), group("config")),
+				Recipe.BackupWorkflowName)
+			Expect(err).To(MatchError(context.Canceled))
+			Expect(result.Rollback).To(HaveLen(1))
+			Expect(executor.Calls()).To(Equal([]string{"hook: db/quiesce", "hook: db/unquiesce"}))
+		})
+	})
+
+	Context("retries", func() {
+		var flaky *flakyExecutor
+
+		run := func(ctx context.Context, recipe *Recipe.Recipe) (*workflow.Result, error) {
+			engine := executor.Engine()
+			engine.Hooks[Recipe.HookTypeExec] = flaky
+
+			return engine.Run(ctx, recipe, Recipe.BackupWorkflowName)
+		}
+
+		BeforeEach(func() {
+			flaky = &flakyExecutor{
+				Executor: executor,
+				failures: 2,
+				target:   workflow.Target{Kind: "pod", Name: "db-0", Container: "db", ExitCode: 75, Stderr: "lock busy"},
+			}
+		})
+
+		It("retries failing ops and records each attempt", func() {
+			recipe := testRecipe("", hook("db/quiesce"))
+			recipe.Spec.Hooks[0].Ops[0].Retries = ptr.To(2)
+			recipe.Spec.Hooks[0].Ops[0].Backoff = &Recipe.Backoff{Delay: 0}
+
+			result, err := run(ctx, recipe)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(executor.Calls()).To(HaveLen(3))
+
+			step := result.Steps[0]
+			Expect(step.Outcome).To(Equal(workflow.OutcomeSucceeded))
+			Expect(step.Attempts).To(HaveLen(3))
+			Expect(step.Attempts[0].Err).To(MatchError(errFake))
+			Expect(step.Attempts[0].Targets[0].Name).To(Equal("db-0"))
+			Expect(step.Attempts[2].Err).ToNot(HaveOccurred())
+			Expect(step.Targets).To(BeNil())
+		})
+		It("fails once the retries are exhausted", func() {
+			recipe := testRecipe("", hook("db/quiesce"))
+			recipe.Spec.Hooks[0].Retries = ptr.To(1)
+			recipe.Spec.Hooks[0].Backoff = &Recipe.Backoff{Type: Recipe.BackoffExponential}
+
+			result, err := run(ctx, recipe)
+			Expect(err).To(MatchError(errFake))
+			Expect(executor.Calls()).To(HaveLen(2))
+			Expect(result.Steps[0].Attempts).To(HaveLen(2))
+			Expect(result.Steps[0].Targets[0].Err).To(MatchError(errFake))
+		})
+		It("does not record attempts of ops without retries", func() {
+			recipe := testRecipe("", hook("db/quiesce"))
+			recipe.Spec.Hooks[0].Retries = ptr.To(3)
+			recipe.Spec.Hooks[0].Ops[0].Retries = ptr.To(0)
+
+			result, err := run(ctx, recipe)
+			Expect(err).To(MatchError(errFake))
+			Expect(executor.Calls()).To(HaveLen(1))
+			Expect(result.Steps[0].Attempts).To(BeNil())
+		})
+		It("retries only the failures retryOn selects", func() {
+			recipe := testRecipe("", hook("db/quiesce"))
+			recipe.Spec.Hooks[0].Retries = ptr.To(2)
+			recipe.Spec.Hooks[0].Backoff = &Recipe.Backoff{}
+			recipe.Spec.Hooks[0].RetryOn = &Recipe.RetryOn{ExitCodes: []int{1, 2}}
+
+			_, err := run(ctx, recipe)
+			Expect(err).To(MatchError(errFake))
+			Expect(executor.Calls()).To(HaveLen(1))
+
+			for _, retryOn := range []*Recipe.RetryOn{{ExitCodes: []int{75}}, {StderrRegex: "^lock"}} {
+				executor = fake.NewExecutor()
+				flaky.Executor, flaky.failures = executor, 2
+				recipe.Spec.Hooks[0].RetryOn = retryOn
+
+				_, err = run(ctx, recipe)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(executor.Calls()).To(HaveLen(3))
+			}
+		})
+		It("stops waiting for a retry when the workflow is cancelled", func() {
+			recipe := testRecipe("", hook("db/quiesce"))
+			recipe.Spec.Hooks[0].Retries = ptr.To(2)
+
+			cancelled, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+			defer cancel()
+
+			start := time.Now()
+			result, err := run(cancelled, recipe)
+			Expect(err).To(MatchError(errFake))
+			Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+			Expect(result.Steps[0].Attempts).To(HaveLen(1))
+		})
+	})
+
+	Context("when conditions", func() {
+		when := func(entry map[string]string, condition string) map[string]string {
+			entry["when"] = condition
+
+			return entry
+		}
+
+		It("skips steps whose condition does not hold and records why", func() {
+			executor.Unmet("namespace redis", "namespace redis not found")
+
+			result, err := executor.Engine().Run(ctx, testRecipe("",
+				when(hook("db/quiesce"), "statefulset db: {$.spec.replicas} > 1"),
+				when(group("cache"), "namespace redis"),
+				group("config"),
+			), Recipe.BackupWorkflowName)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(executor.Calls()).To(Equal([]string{"hook: db/quiesce", "backup: config"}))
+			Expect(outcomes(result)).To(Equal([]workflow.Outcome{
+				workflow.OutcomeSucceeded, workflow.OutcomeSkipped, workflow.OutcomeSucceeded,
+			}))
+			Expect(result.Steps[1].Reason).To(Equal("namespace redis not found"))
+			Expect(result.Steps[1].Step.When).To(Equal("namespace redis"))
+		})
+		It("skips parallel sets and steps of parallel sets", func() {
+			executor.Unmet("pod s1", "pod s1 not found")
+
+			recipe := parallelRecipe("", 3, 3)
+			recipe.Spec.Workflows[0].Parallel[0].Steps[1]["when"] = "pod s1"
+
+			result, err := executor.Engine().Run(ctx, recipe, Recipe.BackupWorkflowName)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(executor.Calls()).To(ConsistOf("hook: s0/quiesce", "hook: s2/quiesce", "backup: config"))
+			Expect(outcomes(result)).To(ContainElement(workflow.OutcomeSkipped))
+
+			executor = fake.NewExecutor().Unmet("namespace quiesce", "namespace quiesce not found")
+			recipe.Spec.Workflows[0].Sequence[0]["when"] = "namespace quiesce"
+
+			result, err = executor.Engine().Run(ctx, recipe, Recipe.BackupWorkflowName)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(executor.Calls()).To(Equal([]string{"backup: config"}))
+			Expect(outcomes(result)).To(Equal([]workflow.Outcome{workflow.OutcomeSkipped, workflow.OutcomeSucceeded}))
+		})
+		It("does not revert skipped ops", func() {
+			executor.Unmet("pod db-0", "pod db-0 not found").Fail("backup: config", errFake)
+
+			result, err := executor.Engine().Run(ctx, testRecipe("", when(hook("db/quiesce"), "pod db-0"),
+				group("config")), Recipe.BackupWorkflowName)
+			Expect(err).To(MatchError(errFake))
+			Expect(result.Rollback).To(BeEmpty())
+		})
+		It("does not count skipped steps on full-error", func() {
+			executor.Unmet("namespace redis", "namespace redis not found").Fail("backup: config", errFake)
+
+			_, err := executor.Engine().Run(ctx, testRecipe(workflow.FailOnFullError,
+				when(group("cache"), "namespace redis"), group("config")), Recipe.BackupWorkflowName)
+			Expect(err).To(MatchError(errFake))
+		})
+		It("fails steps whose condition cannot be evaluated", func() {
+			engine := executor.Engine()
+			engine.Conditions = nil
+
+			result, err := engine.Run(ctx, testRecipe("", when(group("cache"), "namespace redis"), group("config")),
+				Recipe.BackupWorkflowName)
+			Expect(err).To(MatchError(ContainSubstring("no evaluator for when conditions")))
+			Expect(executor.Calls()).To(BeEmpty())
+			Expect(outcomes(result)).To(Equal([]workflow.Outcome{workflow.OutcomeFailed}))
+
+			result, err = executor.Engine().Run(ctx, testRecipe("", when(group("cache"), "secret redis")),
+				Recipe.BackupWorkflowName)
+			Expect(err).To(MatchError(ContainSubstring(`invalid when condition "secret redis"`)))
+			Expect(outcomes(result)).To(Equal([]workflow.Outcome{workflow.OutcomeFailed}))
+		})
+	})
+
+	Context("workflow steps", func() {
+		call := func(name string) map[string]string { return map[string]string{"workflow": name} }
+
+		// fragmentRecipe returns a recipe whose backup workflow runs the given steps, with the
+		// quiesce-all and unquiesce-all workflows to call
+		fragmentRecipe := func(failOn string, sequence ...map[string]string) *Recipe.Recipe {
+			recipe := testRecipe(failOn, sequence...)
+			recipe.Spec.Workflows = append(recipe.Spec.Workflows,
+				&Recipe.Workflow{Name: "quiesce-all", Sequence: []map[string]string{
+					hook("db/quiesce"), hook("metrics/flush"),
+				}},
+				&Recipe.Workflow{Name: "unquiesce-all", Sequence: []map[string]string{hook("db/unquiesce")}},
+			)
+
+			return recipe
+		}
+
+		It("runs the steps of called workflows and records them before the calling step", func() {
+			result, err := executor.Engine().Run(ctx,
+				fragmentRecipe("", call("quiesce-all"), group("data"), call("unquiesce-all")), Recipe.BackupWorkflowName)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(executor.Calls()).To(Equal([]string{
+				"hook: db/quiesce", "hook: metrics/flush", "backup: data", "hook: db/unquiesce",
+			}))
+
+			steps := []string{}
+			for _, step := range result.Steps {
+				steps = append(steps, fmt.Sprintf("%d %s %s", step.Index, step.Workflow, step.Step))
+			}
+
+			Expect(steps).To(Equal([]string{
+				"0 quiesce-all hook: db/quiesce",
+				"0 quiesce-all hook: metrics/flush",
+				"0  workflow: quiesce-all",
+				"1  group: data",
+				"2 unquiesce-all hook: db/unquiesce",
+				"2  workflow: unquiesce-all",
+			}))
+		})
+		It("backs up or restores the groups of called workflows as the calling workflow", func() {
+			recipe := fragmentRecipe("", call("groups"))
+			recipe.Spec.Workflows[1].Sequence = []map[string]string{call("groups")}
+			recipe.Spec.Workflows = append(recipe.Spec.Workflows,
+				&Recipe.Workflow{Name: "groups", Sequence: []map[string]string{group("data")}})
+
+			_, err := executor.Engine().Run(ctx, recipe, Recipe.RestoreWorkflowName)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(executor.Calls()).To(Equal([]string{"restore: data"}))
+		})
+		It("fails the calling step under the FailOn of the called workflow", func() {
+			executor.Fail("hook: metrics/flush", errFake)
+
+			result, err := executor.Engine().Run(ctx, fragmentRecipe("", call("quiesce-all"), group("data")),
+				Recipe.BackupWorkflowName)
+			Expect(err).To(MatchError(errFake))
+			Expect(outcomes(result)).To(Equal([]workflow.Outcome{
+				workflow.OutcomeSucceeded, workflow.OutcomeFailed, workflow.OutcomeFailed,
+			}))
+			Expect(executor.Calls()).To(Equal([]string{
+				"hook: db/quiesce", "hook: metrics/flush", "hook: db/unquiesce",
+			}))
+			Expect(result.Rollback).To(HaveLen(1))
+			Expect(result.Rollback[0].Index).To(Equal(0))
+
+			executor = fake.NewExecutor().Fail("hook: metrics/flush", errFake)
+			recipe := fragmentRecipe("", call("quiesce-all"), group("data"))
+			recipe.Spec.Workflows[2].FailOn = workflow.FailOnEssentialError
+
+			_, err = executor.Engine().Run(ctx, recipe, Recipe.BackupWorkflowName)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(executor.Calls()).To(Equal([]string{"hook: db/quiesce", "hook: metrics/flush", "backup: data"}))
+		})
+		It("recognizes ops reverted by another called workflow", func() {
+			executor.Fail("backup: data", errFake)
+
+			result, err := executor.Engine().Run(ctx,
+				fragmentRecipe("", call("quiesce-all"), call("unquiesce-all"), group("data")), Recipe.BackupWorkflowName)
+			Expect(err).To(MatchError(errFake))
+			Expect(result.Rollback).To(BeEmpty())
+		})
+		It("fails on unknown, reserved and recursive workflows", func() {
+			result, err := executor.Engine().Run(ctx, fragmentRecipe("", call("quiesce")), Recipe.BackupWorkflowName)
+			Expect(err).To(MatchError(ContainSubstring(`workflow "quiesce" not found`)))
+			Expect(outcomes(result)).To(Equal([]workflow.Outcome{workflow.OutcomeFailed}))
+
+			recipe := fragmentRecipe("", call(Recipe.RestoreWorkflowName))
+			recipe.Spec.Workflows[1].Sequence = []map[string]string{group("data")}
+
+			result, err = executor.Engine().Run(ctx, recipe, Recipe.BackupWorkflowName)
+			Expect(err).To(MatchError(ContainSubstring(`workflow "restore" is reserved, reserved workflows cannot be called`)))
+			Expect(outcomes(result)).To(Equal([]workflow.Outcome{workflow.OutcomeFailed}))
+			Expect(executor.Calls()).To(BeEmpty())
+
+			recipe = fragmentRecipe("", call("quiesce-all"))
+			recipe.Spec.Workflows[2].Sequence = append(recipe.Spec.Workflows[2].Sequence, call("unquiesce-all"))
+			recipe.Spec.Workflows[3].Sequence = append(recipe.Spec.Workflows[3].Sequence, call("quiesce-all"))
+
+			_, err = executor.Engine().Run(ctx, recipe, Recipe.BackupWorkflowName)
+			Expect(err).To(MatchError(ContainSubstring(
+				`workflow "quiesce-all" calls itself: backup -> quiesce-all -> unquiesce-all -> quiesce-all`)))
+		})
+		It("stops the calling workflow when a called workflow is cancelled", func() {
+			cancelled, cancel := context.WithCancel(ctx)
+			engine := executor.Engine()
+			engine.Hooks[Recipe.HookTypeExec] = &cancellingExecutor{Executor: executor, cancel: cancel}
+
+			result, err := engine.Run(cancelled, fragmentRecipe("", call("quiesce-all"), group("data")),
+				Recipe.BackupWorkflowName)
+			Expect(err).To(MatchError(context.Canceled))
+			Expect(executor.Calls()).To(Equal([]string{"hook: db/quiesce", "hook: db/unquiesce"}))
+			Expect(result.Rollback).To(HaveLen(1))
+		})
+	})
+
+	Context("parallel sets", func() {
+		It("runs the steps of the set concurrently with bounded parallelism", func() {
+			concurrent := &concurrencyExecutor{Executor: executor}
+			engine := executor.Engine()
+			engine.Hooks[Recipe.HookTypeExec] = concurrent
+
+			result, err := engine.Run(ctx, parallelRecipe("", 8, 3), Recipe.BackupWorkflowName)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(concurrent.max).To(Equal(3))
+			Expect(executor.Calls()).To(HaveLen(9))
+			Expect(executor.Calls()[8]).To(Equal("backup: config"))
+			Expect(result.Steps).To(HaveLen(9))
+			Expect(result.Steps[0].Index).To(Equal(0))
+			Expect(result.Steps[0].Parallel).To(Equal("quiesce"))
+			Expect(result.Steps[8].Index).To(Equal(1))
+		})
+		It("defaults the parallelism", func() {
+			concurrent := &concurrencyExecutor{Executor: executor}
+			engine := executor.Engine()
+			engine.Hooks[Recipe.HookTypeExec] = concurrent
+
+			_, err := engine.Run(ctx, parallelRecipe("", 8, 0), Recipe.BackupWorkflowName)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(concurrent.max).To(Equal(Recipe.DefaultMaxParallel))
+		})
+		It("stops starting steps after a failure on any-error", func() {
+			executor.Fail("hook: s0/quiesce", errFake)
+
+			result, err := executor.Engine().Run(ctx, parallelRecipe("", 4, 1), Recipe.BackupWorkflowName)
+			Expect(err).To(MatchError(errFake))
+			Expect(executor.Calls()).To(Equal([]string{"hook: s0/quiesce"}))
+			Expect(outcomes(result)).To(Equal([]workflow.Outcome{workflow.OutcomeFailed}))
+		})
+		It("completes the set and continues on full-error", func() {
+			executor.Fail("hook: s0/quiesce", errFake)
+
+			result, err := executor.Engine().Run(ctx, parallelRecipe(workflow.FailOnFullError, 4, 2),
+				Recipe.BackupWorkflowName)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Steps).To(HaveLen(5))
+		})
+		It("fails on unknown sets", func() {
+			recipe := parallelRecipe("", 2, 1)
+			recipe.Spec.Workflows[0].Parallel = nil
+
+			result, err := executor.Engine().Run(ctx, recipe, Recipe.BackupWorkflowName)
+			Expect(err).To(MatchError(ContainSubstring(`parallel set "quiesce" not found`)))
+			Expect(result.Steps[0].Step.Kind).To(Equal(Recipe.StepKindParallel))
+		})
+	})
+})